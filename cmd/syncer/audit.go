@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// auditCommand checks the invariants of the indexed state and writes the report as json lines
+type auditCommand struct {
+	migration    *data.DBMigration
+	auditUsecase *biz.AuditUsecase
+}
+
+func newAuditCommand(m *data.DBMigration, auditUsecase *biz.AuditUsecase) *auditCommand {
+	return &auditCommand{
+		migration:    m,
+		auditUsecase: auditUsecase,
+	}
+}
+
+// auditRecord is a line of the report, a violation or the summary of an invariant after its violations
+type auditRecord struct {
+	Violation *biz.AuditViolation `json:"violation,omitempty"`
+	Summary   *biz.AuditSummary   `json:"summary,omitempty"`
+}
+
+// runAudit executes `syncer audit [-out report.jsonl]`, the report is written to stdout without -out
+func runAudit(args []string, database *config.Database, ckbNode *config.CkbNode, appConf *config.App, logger *logger.Logger) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	out := flags.String("out", "", "file to write the json lines report to, stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cmd, cleanup, err := initAuditCommand(database, ckbNode, appConf, logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return cmd.run(context.Background(), w)
+}
+
+// run audits a database migrated by the syncer, it does not migrate the schema itself
+func (c *auditCommand) run(ctx context.Context, w io.Writer) error {
+	if err := c.migration.Current(); err != nil {
+		return err
+	}
+	return c.report(ctx, w)
+}
+
+// report writes the violations as they are found, then the summaries
+func (c *auditCommand) report(ctx context.Context, w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buf)
+	summaries, err := c.auditUsecase.Run(ctx, func(violation biz.AuditViolation) error {
+		return encoder.Encode(auditRecord{Violation: &violation})
+	})
+	var violations uint64
+	for i := range summaries {
+		violations += summaries[i].Violations
+		if encodeErr := encoder.Encode(auditRecord{Summary: &summaries[i]}); encodeErr != nil && err == nil {
+			err = encodeErr
+		}
+	}
+	if flushErr := buf.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return err
+	}
+	if violations > 0 {
+		return biz.ErrAuditViolations
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// reportedAudit reports the violations of each invariant and counts them as checked rows
+type reportedAudit struct {
+	violations map[string][]biz.AuditViolation
+}
+
+func (a reportedAudit) audit(invariant string, report biz.AuditReport) (biz.AuditSummary, error) {
+	summary := biz.AuditSummary{Invariant: invariant, Checked: 10}
+	for _, violation := range a.violations[invariant] {
+		summary.Violations++
+		if err := report(violation); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+func (a reportedAudit) AuditIssued(_ context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	return a.audit(biz.IssuedInvariant, report)
+}
+
+func (a reportedAudit) AuditClaims(_ context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	return a.audit(biz.ClaimInvariant, report)
+}
+
+func (a reportedAudit) AuditOwners(_ context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	return a.audit(biz.SingleOwnerInvariant, report)
+}
+
+func (a reportedAudit) AuditCheckInfos(_ context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	return a.audit(biz.CheckInfoInvariant, report)
+}
+
+func TestAuditCommand_report(t *testing.T) {
+	tokenIndex := uint32(7)
+	tests := []struct {
+		name       string
+		violations map[string][]biz.AuditViolation
+		want       []string
+		wantErr    error
+	}{
+		{
+			name: "should write a summary per invariant",
+			want: []string{
+				`{"summary":{"invariant":"define_issued_matches_minted","checked":10,"violations":0}}`,
+				`{"summary":{"invariant":"claim_has_withdrawal","checked":10,"violations":0}}`,
+				`{"summary":{"invariant":"token_single_owner","checked":10,"violations":0}}`,
+				`{"summary":{"invariant":"check_info_continuous","checked":10,"violations":0}}`,
+			},
+		},
+		{
+			name: "should write the violations before the summaries and fail",
+			violations: map[string][]biz.AuditViolation{
+				biz.ClaimInvariant:     {{Invariant: biz.ClaimInvariant, CotaId: "aa", TokenIndex: &tokenIndex, BlockNumber: 3, Detail: "no withdrawal"}},
+				biz.CheckInfoInvariant: {{Invariant: biz.CheckInfoInvariant, BlockNumber: 9, Detail: "gap"}},
+			},
+			want: []string{
+				`{"violation":{"invariant":"claim_has_withdrawal","cota_id":"aa","token_index":7,"block_number":3,"detail":"no withdrawal"}}`,
+				`{"violation":{"invariant":"check_info_continuous","block_number":9,"detail":"gap"}}`,
+				`{"summary":{"invariant":"define_issued_matches_minted","checked":10,"violations":0}}`,
+				`{"summary":{"invariant":"claim_has_withdrawal","checked":10,"violations":1}}`,
+				`{"summary":{"invariant":"token_single_owner","checked":10,"violations":0}}`,
+				`{"summary":{"invariant":"check_info_continuous","checked":10,"violations":1}}`,
+			},
+			wantErr: biz.ErrAuditViolations,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newAuditCommand(nil, biz.NewAuditUsecase(reportedAudit{violations: tt.violations}, logger.NewLogger(io.Discard, "", 0)))
+			var out bytes.Buffer
+			if err := cmd.report(context.Background(), &out); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("report() error = %v, want %v", err, tt.wantErr)
+			}
+			if want := strings.Join(tt.want, "\n") + "\n"; out.String() != want {
+				t.Errorf("report =\n%swant\n%s", out.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// bootstrapCommand seeds the check infos of a fresh database and reports the check infos it leaves in place
+type bootstrapCommand struct {
+	migration        *data.DBMigration
+	bootstrapper     *data.Bootstrapper
+	checkInfoUsecase *biz.CheckInfoUsecase
+	chainGuard       *data.ChainGuard
+}
+
+func newBootstrapCommand(m *data.DBMigration, b *data.Bootstrapper, checkInfoUsecase *biz.CheckInfoUsecase, g *data.ChainGuard) *bootstrapCommand {
+	return &bootstrapCommand{
+		migration:        m,
+		bootstrapper:     b,
+		checkInfoUsecase: checkInfoUsecase,
+		chainGuard:       g,
+	}
+}
+
+// runBootstrap executes `syncer bootstrap [-block-number n] [-block-hash h] [-accept-scripts]`, the flags override the app config
+func runBootstrap(args []string, database *config.Database, ckbNode *config.CkbNode, appConf *config.App, logger *logger.Logger) error {
+	flags := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	blockNumber := flags.Uint64("block-number", appConf.StartBlockNumber, "last block treated as synced, 0 means the block before the cota deployment")
+	blockHash := flags.String("block-hash", appConf.StartBlockHash, "expected hash of the start block, looked up from the node when empty")
+	acceptScripts := flags.Bool("accept-scripts", false, "store the configured cota scripts as the ones of the database, e.g. after a script upgrade")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cmd, cleanup, err := initBootstrapCommand(database, ckbNode, appConf, logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	cmd.bootstrapper.SetStartBlock(*blockNumber, *blockHash)
+	return cmd.run(context.Background(), *acceptScripts)
+}
+
+func (c *bootstrapCommand) run(ctx context.Context, acceptScripts bool) error {
+	if err := c.migration.Up(); err != nil {
+		return err
+	}
+	if acceptScripts {
+		if err := c.chainGuard.AcceptScripts(ctx); err != nil {
+			return err
+		}
+	}
+	if err := c.chainGuard.Check(ctx); err != nil {
+		return err
+	}
+	start, err := c.bootstrapper.StartCheckInfo(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("start block: %d %s\n", start.BlockNumber, start.BlockHash)
+	seeded, err := c.bootstrapper.Seed(ctx)
+	if err != nil {
+		return err
+	}
+	for _, checkInfo := range seeded {
+		fmt.Printf("seeded %s at block %d\n", checkInfo.CheckType.String(), checkInfo.BlockNumber)
+	}
+	for _, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+		checkInfo := biz.CheckInfo{CheckType: checkType}
+		if err = c.checkInfoUsecase.LastCheckInfo(ctx, &checkInfo); err != nil {
+			return err
+		}
+		fmt.Printf("%s check info: %d %s\n", checkType.String(), checkInfo.BlockNumber, checkInfo.BlockHash)
+	}
+	return nil
+}
@@ -12,12 +12,12 @@ import (
 	"os"
 )
 
-func newApp(logger *logger.Logger, blockSyncSvc *service.BlockSyncService, checkInfoCleanerSvc *service.CheckInfoCleanerService, metadataSyncSvc *service.MetadataSyncService, invalidDataCleanerSvc *service.InvalidDataCleaner, m *data.DBMigration) *app.App {
+func newApp(logger *logger.Logger, blockSyncSvc *service.BlockSyncService, checkInfoCleanerSvc *service.CheckInfoCleanerService, metadataSyncSvc *service.MetadataSyncService, invalidDataCleanerSvc *service.InvalidDataCleaner, unconfirmedOverlaySvc *service.UnconfirmedOverlayService, unifiedSyncSvc *service.UnifiedSyncService, metricsSvc *service.MetricsService, m *data.DBMigration, b *data.Bootstrapper, g *data.ChainGuard) *app.App {
 	return app.NewApp(
 		app.Name("cota-nft-entries-syncer"),
 		app.Version("0.0.1"),
 		app.Logger(logger),
-		app.Services(blockSyncSvc, checkInfoCleanerSvc, metadataSyncSvc, invalidDataCleanerSvc), app.Migration(m))
+		app.Services(blockSyncSvc, checkInfoCleanerSvc, metadataSyncSvc, invalidDataCleanerSvc, unconfirmedOverlaySvc, unifiedSyncSvc, metricsSvc), app.Migration(m), app.Bootstrap(b), app.ChainGuard(g))
 }
 
 func main() {
@@ -45,7 +45,27 @@ func main() {
 		LocalTime:  true,
 	}, "", log.LstdFlags)
 
-	app, cleanup, err := initApp(&dataConf.Database, ckbNodeConf, logger)
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "bootstrap":
+			err = runBootstrap(os.Args[2:], &dataConf.Database, ckbNodeConf, appConf, logger)
+		case "resync":
+			err = runResync(os.Args[2:], &dataConf.Database, ckbNodeConf, appConf, logger)
+		case "verify-smt":
+			err = runVerifySmt(os.Args[2:], &dataConf.Database, ckbNodeConf, appConf, logger)
+		case "audit":
+			err = runAudit(os.Args[2:], &dataConf.Database, ckbNodeConf, appConf, logger)
+		default:
+			log.Fatalf("unknown command %q, expected bootstrap, resync, verify-smt or audit", os.Args[1])
+		}
+		if err != nil {
+			log.Fatalf("%s err: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	app, cleanup, err := initApp(&dataConf.Database, ckbNodeConf, appConf, logger)
 	if err != nil {
 		panic(err)
 	}
@@ -59,7 +79,10 @@ func main() {
 func setupAppConf(conf *config.Config) (*config.App, error) {
 	var appConf *config.App
 	err := conf.ReadSection("app", &appConf)
-	return appConf, err
+	if err != nil {
+		return nil, err
+	}
+	return appConf, appConf.Validate()
 }
 
 func setupDataConf(conf *config.Config) (*config.Data, error) {
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// resyncCommand re-processes a block range with the current parsers and prints the changed row counts
+type resyncCommand struct {
+	migration         *data.DBMigration
+	resyncer          *data.Resyncer
+	chainGuard        *data.ChainGuard
+	deadLetterUsecase *biz.DeadLetterUsecase
+}
+
+func newResyncCommand(m *data.DBMigration, resyncer *data.Resyncer, g *data.ChainGuard, deadLetterUsecase *biz.DeadLetterUsecase) *resyncCommand {
+	return &resyncCommand{
+		migration:         m,
+		resyncer:          resyncer,
+		chainGuard:        g,
+		deadLetterUsecase: deadLetterUsecase,
+	}
+}
+
+// defaultResyncMaxBlocks limits the blocks re-applied by a resync, it holds the sync fence for all of them
+const defaultResyncMaxBlocks = 10000
+
+// runResync executes `syncer resync -from a -to b`, `syncer resync -dead-letters` or `syncer resync -release-fence`
+func runResync(args []string, database *config.Database, ckbNode *config.CkbNode, appConf *config.App, logger *logger.Logger) error {
+	flags := flag.NewFlagSet("resync", flag.ContinueOnError)
+	from := flags.Uint64("from", 0, "first block of the range")
+	to := flags.Uint64("to", 0, "last block of the range")
+	releaseFence := flags.Bool("release-fence", false, "remove the fence left behind by an interrupted resync")
+	deadLetters := flags.Bool("dead-letters", false, "retry the blocks holding dead letters")
+	maxBlocks := flags.Uint64("max-blocks", defaultResyncMaxBlocks, "refuse a resync re-applying more blocks up to the synced head, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*releaseFence && !*deadLetters && *from == 0 {
+		return errors.New("-from is required")
+	}
+	// the cota cell index below the range may have been cleaned, inputs are always resolved through the node
+	resyncConf := *appConf
+	resyncConf.InputResolution = config.RpcInputResolution
+	cmd, cleanup, err := initResyncCommand(database, ckbNode, &resyncConf, logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if *releaseFence {
+		return cmd.resyncer.ReleaseFence(context.Background())
+	}
+	if *to == 0 {
+		*to = *from
+	}
+	return cmd.run(context.Background(), *from, *to, *maxBlocks, *deadLetters)
+}
+
+// run resyncs the range, with deadLetters the range spans the blocks holding dead letters instead. The dead letters
+// of the range are dropped by the rewind and stored again for the transactions that still fail. The blocks to re-apply
+// are printed before anything changes, a resync of more than maxBlocks is refused.
+func (c *resyncCommand) run(ctx context.Context, from, to, maxBlocks uint64, deadLetters bool) error {
+	if err := c.migration.Up(); err != nil {
+		return err
+	}
+	if err := c.chainGuard.Check(ctx); err != nil {
+		return err
+	}
+	if deadLetters {
+		var found bool
+		var err error
+		if from, to, found, err = c.deadLetterUsecase.BlockRange(ctx); err != nil {
+			return err
+		}
+		if !found {
+			fmt.Println("no dead letters")
+			return nil
+		}
+		fmt.Printf("dead letters in blocks [%d, %d]\n", from, to)
+	}
+	plan, err := c.resyncer.Plan(ctx, from, to, maxBlocks)
+	if plan.Head >= from {
+		fmt.Printf("resync of blocks [%d, %d] re-applies blocks [%d, %d], %d blocks\n", from, to, from, plan.Head, plan.Head-from+1)
+	}
+	if err != nil {
+		return err
+	}
+	summary, err := c.resyncer.Resync(ctx, from, to, maxBlocks)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("resynced blocks [%d, %d], re-applied up to block %d\n", summary.From, summary.To, summary.Head)
+	if len(summary.Changes) == 0 {
+		fmt.Println("no rows changed")
+		return nil
+	}
+	for _, change := range summary.Changes {
+		fmt.Printf("%s: %d added, %d removed\n", change.Table, change.Added, change.Removed)
+	}
+	return nil
+}
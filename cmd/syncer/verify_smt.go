@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// verifySmtCommand rebuilds the smt roots of live cota cells from the indexed kv pairs and prints the mismatches
+type verifySmtCommand struct {
+	migration      *data.DBMigration
+	smtRootUsecase *biz.SmtRootUsecase
+}
+
+func newVerifySmtCommand(m *data.DBMigration, smtRootUsecase *biz.SmtRootUsecase) *verifySmtCommand {
+	return &verifySmtCommand{
+		migration:      m,
+		smtRootUsecase: smtRootUsecase,
+	}
+}
+
+// runVerifySmt executes `syncer verify-smt [-lock-hashes h1,h2]`, without lock hashes every live cota cell is checked
+func runVerifySmt(args []string, database *config.Database, ckbNode *config.CkbNode, appConf *config.App, logger *logger.Logger) error {
+	flags := flag.NewFlagSet("verify-smt", flag.ContinueOnError)
+	lockHashes := flags.String("lock-hashes", "", "comma separated lock hashes without 0x, all locks with a live cota cell when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cmd, cleanup, err := initVerifySmtCommand(database, ckbNode, appConf, logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	var hashes []string
+	if *lockHashes != "" {
+		for _, hash := range strings.Split(*lockHashes, ",") {
+			hashes = append(hashes, strings.TrimPrefix(strings.TrimSpace(hash), "0x"))
+		}
+	}
+	return cmd.run(context.Background(), hashes)
+}
+
+func (c *verifySmtCommand) run(ctx context.Context, lockHashes []string) error {
+	if err := c.migration.Up(); err != nil {
+		return err
+	}
+	checks, err := c.smtRootUsecase.Check(ctx, lockHashes...)
+	if err != nil {
+		return err
+	}
+	var matched, mismatched int
+	for _, check := range checks {
+		if check.Matches() {
+			matched++
+			continue
+		}
+		mismatched++
+		fmt.Printf("mismatch %s: cell root %s at block %d, rebuilt root %s from %d leaves\n",
+			check.LockHash, check.CellRoot, check.BlockNumber, check.Root, check.Leaves)
+	}
+	fmt.Printf("%d cota cells checked: %d matched, %d mismatched\n", len(checks), matched, mismatched)
+	if mismatched > 0 {
+		return biz.ErrSmtRootMismatch
+	}
+	return nil
+}
@@ -13,6 +13,22 @@ import (
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/service"
 )
 
-func initApp(*config.Database, *config.CkbNode, *logger.Logger) (*app.App, func(), error) {
+func initApp(*config.Database, *config.CkbNode, *config.App, *logger.Logger) (*app.App, func(), error) {
 	panic(wire.Build(data.ProviderSet, biz.ProviderSet, service.ProviderSet, newApp))
 }
+
+func initBootstrapCommand(*config.Database, *config.CkbNode, *config.App, *logger.Logger) (*bootstrapCommand, func(), error) {
+	panic(wire.Build(data.ProviderSet, biz.ProviderSet, newBootstrapCommand))
+}
+
+func initResyncCommand(*config.Database, *config.CkbNode, *config.App, *logger.Logger) (*resyncCommand, func(), error) {
+	panic(wire.Build(data.ProviderSet, biz.ProviderSet, newResyncCommand))
+}
+
+func initVerifySmtCommand(*config.Database, *config.CkbNode, *config.App, *logger.Logger) (*verifySmtCommand, func(), error) {
+	panic(wire.Build(data.ProviderSet, biz.ProviderSet, newVerifySmtCommand))
+}
+
+func initAuditCommand(*config.Database, *config.CkbNode, *config.App, *logger.Logger) (*auditCommand, func(), error) {
+	panic(wire.Build(data.ProviderSet, biz.ProviderSet, newAuditCommand))
+}
@@ -17,7 +17,7 @@ import (
 
 // Injectors from wire.go:
 
-func initApp(database *config.Database, ckbNode *config.CkbNode, loggerLogger *logger.Logger) (*app.App, func(), error) {
+func initApp(database *config.Database, ckbNode *config.CkbNode, configApp *config.App, loggerLogger *logger.Logger) (*app.App, func(), error) {
 	dataData, cleanup, err := data.NewData(database, loggerLogger)
 	if err != nil {
 		return nil, nil, err
@@ -29,19 +29,20 @@ func initApp(database *config.Database, ckbNode *config.CkbNode, loggerLogger *l
 		cleanup()
 		return nil, nil, err
 	}
-	systemScripts := data.NewSystemScripts(ckbNodeClient, loggerLogger)
+	systemScripts := data.NewSystemScripts(ckbNodeClient, ckbNode, loggerLogger)
 	claimedCotaNftKvPairRepo := data.NewClaimedCotaNftKvPairRepo(dataData, loggerLogger)
 	claimedCotaNftKvPairUsecase := biz.NewClaimedCotaNftKvPairUsecase(claimedCotaNftKvPairRepo, loggerLogger)
 	defineCotaNftKvPairRepo := data.NewDefineCotaNftKvPairRepo(dataData, loggerLogger)
 	defineCotaNftKvPairUsecase := biz.NewDefineCotaNftKvPairUsecase(defineCotaNftKvPairRepo, loggerLogger)
 	holdCotaNftKvPairRepo := data.NewHoldCotaNftKvPairRepo(dataData, loggerLogger)
 	holdCotaNftKvPairUsecase := biz.NewHoldCotaNftKvPairUsecase(holdCotaNftKvPairRepo, loggerLogger)
-	registerCotaKvPairRepo := data.NewRegisterCotaKvPairRepo(dataData, loggerLogger)
-	registerCotaKvPairUsecase := biz.NewRegisterCotaKvPairUsecase(registerCotaKvPairRepo, loggerLogger)
 	withdrawCotaNftKvPairRepo := data.NewWithdrawCotaNftKvPairRepo(dataData, loggerLogger)
 	withdrawCotaNftKvPairUsecase := biz.NewWithdrawCotaNftKvPairUsecase(withdrawCotaNftKvPairRepo, loggerLogger)
-	cotaWitnessArgsParser := data.NewCotaWitnessArgsParser(ckbNodeClient)
-	kvPairRepo := data.NewKvPairRepo(dataData, loggerLogger)
+	cotaCellRepo := data.NewCotaCellRepo(dataData, loggerLogger)
+	cotaCellUsecase := biz.NewCotaCellUsecase(cotaCellRepo, loggerLogger)
+	registryParser := data.NewRegistryParser(ckbNodeClient, configApp)
+	cotaWitnessArgsParser := data.NewCotaWitnessArgsParser(ckbNodeClient, cotaCellUsecase, configApp)
+	kvPairRepo := data.NewKvPairRepo(dataData, configApp, loggerLogger)
 	syncKvPairUsecase := biz.NewSyncKvPairUsecase(kvPairRepo, loggerLogger)
 	mintCotaKvPairRepo := data.NewMintCotaKvPairRepo(dataData, loggerLogger)
 	mintCotaKvPairUsecase := biz.NewMintCotaKvPairUsecase(mintCotaKvPairRepo, loggerLogger)
@@ -51,17 +52,143 @@ func initApp(database *config.Database, ckbNode *config.CkbNode, loggerLogger *l
 	issuerInfoUsecase := biz.NewIssuerInfoUsecase(issuerInfoRepo, loggerLogger)
 	classInfoRepo := data.NewClassInfoRepo(dataData, loggerLogger)
 	classInfoUsecase := biz.NewClassInfoUsecase(classInfoRepo, loggerLogger)
-	blockSyncer := data.NewBlockSyncer(claimedCotaNftKvPairUsecase, defineCotaNftKvPairUsecase, holdCotaNftKvPairUsecase, registerCotaKvPairUsecase, withdrawCotaNftKvPairUsecase, cotaWitnessArgsParser, syncKvPairUsecase, mintCotaKvPairUsecase, transferCotaKvPairUsecase, issuerInfoUsecase, classInfoUsecase)
-	blockSyncService := service.NewBlockSyncService(checkInfoUsecase, loggerLogger, ckbNodeClient, systemScripts, blockSyncer)
-	checkInfoCleanerService := service.NewCheckInfoService(checkInfoUsecase, loggerLogger, ckbNodeClient)
-	metadataSyncer := data.NewMetadataSyncer(syncKvPairUsecase, cotaWitnessArgsParser, issuerInfoUsecase, classInfoUsecase)
-	metadataSyncService := service.NewMetadataSyncService(checkInfoUsecase, loggerLogger, ckbNodeClient, systemScripts, metadataSyncer)
+	entryHandlerRegistry := biz.NewEntryHandlerRegistry(defineCotaNftKvPairUsecase, mintCotaKvPairUsecase, withdrawCotaNftKvPairUsecase, claimedCotaNftKvPairUsecase, holdCotaNftKvPairUsecase, transferCotaKvPairUsecase)
+	blockSyncer := data.NewBlockSyncer(registryParser, cotaWitnessArgsParser, syncKvPairUsecase, entryHandlerRegistry, configApp, loggerLogger)
+	blockHeaderRepo := data.NewBlockHeaderRepo(dataData, loggerLogger)
+	blockHeaderUsecase := biz.NewBlockHeaderUsecase(blockHeaderRepo, loggerLogger)
+	chainReorganizer := data.NewChainReorganizer(ckbNodeClient, syncKvPairUsecase, blockHeaderUsecase, configApp, loggerLogger)
+	tipSubscriber := data.NewTipSubscriber(ckbNode, loggerLogger)
+	sparseScanner := data.NewSparseScanner(ckbNodeClient, syncKvPairUsecase, chainReorganizer, configApp, loggerLogger)
+	blockSyncService := service.NewBlockSyncService(checkInfoUsecase, loggerLogger, ckbNodeClient, systemScripts, blockSyncer, chainReorganizer, tipSubscriber, sparseScanner, configApp)
+	checkInfoCleanerService := service.NewCheckInfoService(checkInfoUsecase, blockHeaderUsecase, cotaCellUsecase, chainReorganizer, loggerLogger, ckbNodeClient)
+	metadataSyncer := data.NewMetadataSyncer(syncKvPairUsecase, cotaWitnessArgsParser, issuerInfoUsecase, classInfoUsecase, configApp, loggerLogger)
+	metadataSyncService := service.NewMetadataSyncService(checkInfoUsecase, loggerLogger, ckbNodeClient, systemScripts, metadataSyncer, chainReorganizer, tipSubscriber, sparseScanner, configApp)
 	invalidDataRepo := data.NewInvalidDateRepo(dataData, loggerLogger)
 	invalidDataUsecase := biz.NewInvalidDataUsecase(invalidDataRepo, loggerLogger)
 	invalidDataCleaner := service.NewInvalidDataService(invalidDataUsecase, loggerLogger, ckbNodeClient)
+	unconfirmedKvPairRepo := data.NewUnconfirmedKvPairRepo(dataData, loggerLogger)
+	unconfirmedKvPairUsecase := biz.NewUnconfirmedKvPairUsecase(unconfirmedKvPairRepo, loggerLogger)
+	unconfirmedOverlayService := service.NewUnconfirmedOverlayService(checkInfoUsecase, unconfirmedKvPairUsecase, loggerLogger, ckbNodeClient, systemScripts, blockSyncer, metadataSyncer, tipSubscriber, configApp)
+	unifiedSyncer := data.NewUnifiedSyncer(blockSyncer, metadataSyncer, syncKvPairUsecase)
+	unifiedSyncService := service.NewUnifiedSyncService(checkInfoUsecase, loggerLogger, ckbNodeClient, systemScripts, blockSyncer, metadataSyncer, unifiedSyncer, chainReorganizer, tipSubscriber, sparseScanner, configApp)
+	metricsService := service.NewMetricsService(loggerLogger, configApp)
 	dbMigration := data.NewDBMigration(dataData, loggerLogger)
-	appApp := newApp(loggerLogger, blockSyncService, checkInfoCleanerService, metadataSyncService, invalidDataCleaner, dbMigration)
+	bootstrapper := data.NewBootstrapper(ckbNodeClient, systemScripts, checkInfoUsecase, syncKvPairUsecase, cotaCellUsecase, configApp, loggerLogger)
+	chainIdentityRepo := data.NewChainIdentityRepo(dataData, loggerLogger)
+	chainIdentityUsecase := biz.NewChainIdentityUsecase(chainIdentityRepo, loggerLogger)
+	chainGuard := data.NewChainGuard(ckbNodeClient, systemScripts, chainIdentityUsecase)
+	appApp := newApp(loggerLogger, blockSyncService, checkInfoCleanerService, metadataSyncService, invalidDataCleaner, unconfirmedOverlayService, unifiedSyncService, metricsService, dbMigration, bootstrapper, chainGuard)
 	return appApp, func() {
 		cleanup()
 	}, nil
 }
+
+func initBootstrapCommand(database *config.Database, ckbNode *config.CkbNode, configApp *config.App, loggerLogger *logger.Logger) (*bootstrapCommand, func(), error) {
+	dataData, cleanup, err := data.NewData(database, loggerLogger)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbMigration := data.NewDBMigration(dataData, loggerLogger)
+	ckbNodeClient, err := data.NewCkbNodeClient(ckbNode, loggerLogger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	systemScripts := data.NewSystemScripts(ckbNodeClient, ckbNode, loggerLogger)
+	checkInfoRepo := data.NewCheckInfoRepo(dataData, loggerLogger)
+	checkInfoUsecase := biz.NewCheckInfoUsecase(checkInfoRepo, loggerLogger)
+	kvPairRepo := data.NewKvPairRepo(dataData, configApp, loggerLogger)
+	syncKvPairUsecase := biz.NewSyncKvPairUsecase(kvPairRepo, loggerLogger)
+	cotaCellRepo := data.NewCotaCellRepo(dataData, loggerLogger)
+	cotaCellUsecase := biz.NewCotaCellUsecase(cotaCellRepo, loggerLogger)
+	bootstrapper := data.NewBootstrapper(ckbNodeClient, systemScripts, checkInfoUsecase, syncKvPairUsecase, cotaCellUsecase, configApp, loggerLogger)
+	chainIdentityRepo := data.NewChainIdentityRepo(dataData, loggerLogger)
+	chainIdentityUsecase := biz.NewChainIdentityUsecase(chainIdentityRepo, loggerLogger)
+	chainGuard := data.NewChainGuard(ckbNodeClient, systemScripts, chainIdentityUsecase)
+	mainBootstrapCommand := newBootstrapCommand(dbMigration, bootstrapper, checkInfoUsecase, chainGuard)
+	return mainBootstrapCommand, func() {
+		cleanup()
+	}, nil
+}
+
+func initResyncCommand(database *config.Database, ckbNode *config.CkbNode, configApp *config.App, loggerLogger *logger.Logger) (*resyncCommand, func(), error) {
+	dataData, cleanup, err := data.NewData(database, loggerLogger)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbMigration := data.NewDBMigration(dataData, loggerLogger)
+	checkInfoRepo := data.NewCheckInfoRepo(dataData, loggerLogger)
+	checkInfoUsecase := biz.NewCheckInfoUsecase(checkInfoRepo, loggerLogger)
+	ckbNodeClient, err := data.NewCkbNodeClient(ckbNode, loggerLogger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	systemScripts := data.NewSystemScripts(ckbNodeClient, ckbNode, loggerLogger)
+	claimedCotaNftKvPairRepo := data.NewClaimedCotaNftKvPairRepo(dataData, loggerLogger)
+	claimedCotaNftKvPairUsecase := biz.NewClaimedCotaNftKvPairUsecase(claimedCotaNftKvPairRepo, loggerLogger)
+	defineCotaNftKvPairRepo := data.NewDefineCotaNftKvPairRepo(dataData, loggerLogger)
+	defineCotaNftKvPairUsecase := biz.NewDefineCotaNftKvPairUsecase(defineCotaNftKvPairRepo, loggerLogger)
+	holdCotaNftKvPairRepo := data.NewHoldCotaNftKvPairRepo(dataData, loggerLogger)
+	holdCotaNftKvPairUsecase := biz.NewHoldCotaNftKvPairUsecase(holdCotaNftKvPairRepo, loggerLogger)
+	withdrawCotaNftKvPairRepo := data.NewWithdrawCotaNftKvPairRepo(dataData, loggerLogger)
+	withdrawCotaNftKvPairUsecase := biz.NewWithdrawCotaNftKvPairUsecase(withdrawCotaNftKvPairRepo, loggerLogger)
+	cotaCellRepo := data.NewCotaCellRepo(dataData, loggerLogger)
+	cotaCellUsecase := biz.NewCotaCellUsecase(cotaCellRepo, loggerLogger)
+	registryParser := data.NewRegistryParser(ckbNodeClient, configApp)
+	cotaWitnessArgsParser := data.NewCotaWitnessArgsParser(ckbNodeClient, cotaCellUsecase, configApp)
+	kvPairRepo := data.NewKvPairRepo(dataData, configApp, loggerLogger)
+	syncKvPairUsecase := biz.NewSyncKvPairUsecase(kvPairRepo, loggerLogger)
+	mintCotaKvPairRepo := data.NewMintCotaKvPairRepo(dataData, loggerLogger)
+	mintCotaKvPairUsecase := biz.NewMintCotaKvPairUsecase(mintCotaKvPairRepo, loggerLogger)
+	transferCotaKvPairRepo := data.NewTransferCotaKvPairRepo(dataData, loggerLogger)
+	transferCotaKvPairUsecase := biz.NewTransferCotaKvPairUsecase(transferCotaKvPairRepo, loggerLogger)
+	issuerInfoRepo := data.NewIssuerInfoRepo(dataData, loggerLogger)
+	issuerInfoUsecase := biz.NewIssuerInfoUsecase(issuerInfoRepo, loggerLogger)
+	classInfoRepo := data.NewClassInfoRepo(dataData, loggerLogger)
+	classInfoUsecase := biz.NewClassInfoUsecase(classInfoRepo, loggerLogger)
+	entryHandlerRegistry := biz.NewEntryHandlerRegistry(defineCotaNftKvPairUsecase, mintCotaKvPairUsecase, withdrawCotaNftKvPairUsecase, claimedCotaNftKvPairUsecase, holdCotaNftKvPairUsecase, transferCotaKvPairUsecase)
+	blockSyncer := data.NewBlockSyncer(registryParser, cotaWitnessArgsParser, syncKvPairUsecase, entryHandlerRegistry, configApp, loggerLogger)
+	metadataSyncer := data.NewMetadataSyncer(syncKvPairUsecase, cotaWitnessArgsParser, issuerInfoUsecase, classInfoUsecase, configApp, loggerLogger)
+	unifiedSyncer := data.NewUnifiedSyncer(blockSyncer, metadataSyncer, syncKvPairUsecase)
+	syncFenceRepo := data.NewSyncFenceRepo(dataData, loggerLogger)
+	syncFenceUsecase := biz.NewSyncFenceUsecase(syncFenceRepo, loggerLogger)
+	resyncer := data.NewResyncer(ckbNodeClient, systemScripts, blockSyncer, unifiedSyncer, syncKvPairUsecase, checkInfoUsecase, syncFenceUsecase, configApp, loggerLogger)
+	chainIdentityRepo := data.NewChainIdentityRepo(dataData, loggerLogger)
+	chainIdentityUsecase := biz.NewChainIdentityUsecase(chainIdentityRepo, loggerLogger)
+	chainGuard := data.NewChainGuard(ckbNodeClient, systemScripts, chainIdentityUsecase)
+	deadLetterRepo := data.NewDeadLetterRepo(dataData, loggerLogger)
+	deadLetterUsecase := biz.NewDeadLetterUsecase(deadLetterRepo, loggerLogger)
+	mainResyncCommand := newResyncCommand(dbMigration, resyncer, chainGuard, deadLetterUsecase)
+	return mainResyncCommand, func() {
+		cleanup()
+	}, nil
+}
+
+func initVerifySmtCommand(database *config.Database, ckbNode *config.CkbNode, configApp *config.App, loggerLogger *logger.Logger) (*verifySmtCommand, func(), error) {
+	dataData, cleanup, err := data.NewData(database, loggerLogger)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbMigration := data.NewDBMigration(dataData, loggerLogger)
+	smtRootRepo := data.NewSmtRootRepo(dataData, loggerLogger)
+	smtRootUsecase := biz.NewSmtRootUsecase(smtRootRepo, loggerLogger)
+	mainVerifySmtCommand := newVerifySmtCommand(dbMigration, smtRootUsecase)
+	return mainVerifySmtCommand, func() {
+		cleanup()
+	}, nil
+}
+
+func initAuditCommand(database *config.Database, ckbNode *config.CkbNode, configApp *config.App, loggerLogger *logger.Logger) (*auditCommand, func(), error) {
+	dataData, cleanup, err := data.NewData(database, loggerLogger)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbMigration := data.NewDBMigration(dataData, loggerLogger)
+	auditRepo := data.NewAuditRepo(dataData, configApp, loggerLogger)
+	auditUsecase := biz.NewAuditUsecase(auditRepo, loggerLogger)
+	mainAuditCommand := newAuditCommand(dbMigration, auditUsecase)
+	return mainAuditCommand, func() {
+		cleanup()
+	}, nil
+}
@@ -67,6 +67,18 @@ func (a *App) Run(mode string) error {
 		a.options.logger.Errorf(context.TODO(), "DB Migration failed: %v", err)
 		return err
 	}
+	if a.options.chainGuard != nil {
+		if err := a.options.chainGuard.Check(ctx); err != nil {
+			a.options.logger.Errorf(context.TODO(), "Chain identity check failed: %v", err)
+			return err
+		}
+	}
+	if a.options.bootstrap != nil {
+		if _, err := a.options.bootstrap.Seed(ctx); err != nil {
+			a.options.logger.Errorf(context.TODO(), "Bootstrap check infos failed: %v", err)
+			return err
+		}
+	}
 	for _, srv := range a.options.services {
 		srv := srv
 		eg.Go(func() error {
@@ -28,6 +28,8 @@ type options struct {
 	stopTimeout time.Duration
 	services    []service.Service
 	migration   *data.DBMigration
+	bootstrap   *data.Bootstrapper
+	chainGuard  *data.ChainGuard
 }
 
 func ID(id string) Option {
@@ -83,3 +85,15 @@ func Migration(m *data.DBMigration) Option {
 		o.migration = m
 	}
 }
+
+func Bootstrap(b *data.Bootstrapper) Option {
+	return func(o *options) {
+		o.bootstrap = b
+	}
+}
+
+func ChainGuard(g *data.ChainGuard) Option {
+	return func(o *options) {
+		o.chainGuard = g
+	}
+}
@@ -0,0 +1,77 @@
+package biz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// ErrAuditViolations is returned by the audit command when an invariant is broken
+var ErrAuditViolations = errors.New("audit found violations")
+
+const (
+	IssuedInvariant      = "define_issued_matches_minted"
+	ClaimInvariant       = "claim_has_withdrawal"
+	SingleOwnerInvariant = "token_single_owner"
+	CheckInfoInvariant   = "check_info_continuous"
+)
+
+// AuditViolation is a row of the current state breaking an invariant, TokenIndex is nil for invariants not about a
+// single token
+type AuditViolation struct {
+	Invariant   string  `json:"invariant"`
+	LockHash    string  `json:"lock_hash,omitempty"`
+	CotaId      string  `json:"cota_id,omitempty"`
+	TokenIndex  *uint32 `json:"token_index,omitempty"`
+	BlockNumber uint64  `json:"block_number"`
+	Detail      string  `json:"detail"`
+}
+
+// AuditSummary counts the rows an invariant was checked on and the violations found, Skipped explains why an
+// invariant does not apply to the database
+type AuditSummary struct {
+	Invariant  string `json:"invariant"`
+	Checked    uint64 `json:"checked"`
+	Violations uint64 `json:"violations"`
+	Skipped    string `json:"skipped,omitempty"`
+}
+
+// AuditReport receives the violations one by one, so that no check holds them in memory
+type AuditReport func(violation AuditViolation) error
+
+type AuditRepo interface {
+	AuditIssued(ctx context.Context, report AuditReport) (AuditSummary, error)
+	AuditClaims(ctx context.Context, report AuditReport) (AuditSummary, error)
+	AuditOwners(ctx context.Context, report AuditReport) (AuditSummary, error)
+	AuditCheckInfos(ctx context.Context, report AuditReport) (AuditSummary, error)
+}
+
+type AuditUsecase struct {
+	repo   AuditRepo
+	logger *logger.Logger
+}
+
+func NewAuditUsecase(repo AuditRepo, logger *logger.Logger) *AuditUsecase {
+	return &AuditUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Run checks every invariant over the current state and returns their summaries in order
+func (uc *AuditUsecase) Run(ctx context.Context, report AuditReport) ([]AuditSummary, error) {
+	checks := []func(context.Context, AuditReport) (AuditSummary, error){
+		uc.repo.AuditIssued, uc.repo.AuditClaims, uc.repo.AuditOwners, uc.repo.AuditCheckInfos,
+	}
+	summaries := make([]AuditSummary, 0, len(checks))
+	for _, check := range checks {
+		summary, err := check(ctx, report)
+		if err != nil {
+			return summaries, err
+		}
+		uc.logger.Infof(ctx, "audit %s: %d checked, %d violations", summary.Invariant, summary.Checked, summary.Violations)
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
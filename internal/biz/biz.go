@@ -7,12 +7,16 @@ import (
 
 var ProviderSet = wire.NewSet(NewCheckInfoUsecase, NewRegisterCotaKvPairUsecase, NewDefineCotaNftKvPairUsecase,
 	NewHoldCotaNftKvPairUsecase, NewWithdrawCotaNftKvPairUsecase, NewClaimedCotaNftKvPairUsecase, NewSyncKvPairUsecase,
-	NewMintCotaKvPairUsecase, NewTransferCotaKvPairUsecase, NewIssuerInfoUsecase, NewClassInfoUsecase, NewInvalidDataUsecase)
+	NewMintCotaKvPairUsecase, NewTransferCotaKvPairUsecase, NewIssuerInfoUsecase, NewClassInfoUsecase, NewInvalidDataUsecase,
+	NewBlockHeaderUsecase, NewUnconfirmedKvPairUsecase, NewCotaCellUsecase, NewSyncFenceUsecase, NewChainIdentityUsecase,
+	NewDeadLetterUsecase, NewEntryHandlerRegistry,
+	NewSmtRootUsecase, NewAuditUsecase)
 
 type Entry struct {
-	InputType  []byte
-	OutputType []byte
-	LockScript *ckbTypes.Script
-	TxIndex    uint32
-	Version    uint8
+	InputType     []byte
+	OutputType    []byte
+	LockScript    *ckbTypes.Script
+	TxIndex       uint32
+	Version       uint8
+	ScriptVersion uint8
 }
@@ -0,0 +1,42 @@
+package biz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+var ErrReorgTooDeep = errors.New("chain reorganization is deeper than the stored header chain")
+
+type BlockHeader struct {
+	BlockNumber uint64
+	BlockHash   string
+}
+
+type BlockHeaderRepo interface {
+	FindLatestBlockHeaders(ctx context.Context, limit int) ([]BlockHeader, error)
+	CleanBlockHeaders(ctx context.Context, depth uint64) error
+}
+
+type BlockHeaderUsecase struct {
+	repo   BlockHeaderRepo
+	logger *logger.Logger
+}
+
+func NewBlockHeaderUsecase(repo BlockHeaderRepo, logger *logger.Logger) *BlockHeaderUsecase {
+	return &BlockHeaderUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// LatestBlockHeaders returns at most limit stored headers ordered by block number desc
+func (uc *BlockHeaderUsecase) LatestBlockHeaders(ctx context.Context, limit int) ([]BlockHeader, error) {
+	return uc.repo.FindLatestBlockHeaders(ctx, limit)
+}
+
+// Clean keeps the headers of the latest depth blocks
+func (uc *BlockHeaderUsecase) Clean(ctx context.Context, depth uint64) error {
+	return uc.repo.CleanBlockHeaders(ctx, depth)
+}
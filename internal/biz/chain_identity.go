@@ -0,0 +1,108 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+var ErrChainMismatch = errors.New("database was synced from another chain")
+
+// ChainIdentity tells which chain and cota deployments the database is synced from. Scripts is the canonical text of
+// the cota script set, see SystemScripts.Identity.
+type ChainIdentity struct {
+	Chain       string
+	GenesisHash string
+	Scripts     string
+}
+
+// BlockHashFunc returns the hash of the block at the number on the connected node, without the 0x prefix
+type BlockHashFunc func(ctx context.Context, blockNumber uint64) (string, error)
+
+type ChainIdentityRepo interface {
+	FindChainIdentity(ctx context.Context) (ChainIdentity, bool, error)
+	// FindSyncedBlock returns the oldest block with a hash kept in the check infos, or else in the block headers
+	FindSyncedBlock(ctx context.Context) (CheckInfo, bool, error)
+	CreateChainIdentity(ctx context.Context, identity ChainIdentity) error
+	UpdateChainIdentityScripts(ctx context.Context, scripts string) error
+}
+
+type ChainIdentityUsecase struct {
+	repo   ChainIdentityRepo
+	logger *logger.Logger
+}
+
+func NewChainIdentityUsecase(repo ChainIdentityRepo, logger *logger.Logger) *ChainIdentityUsecase {
+	return &ChainIdentityUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Check stores the identity on the first start and fails with ErrChainMismatch when it differs from the stored one
+func (uc *ChainIdentityUsecase) Check(ctx context.Context, identity ChainIdentity, blockHash BlockHashFunc) error {
+	stored, found, err := uc.repo.FindChainIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return uc.bind(ctx, identity, blockHash)
+	}
+	if err = sameChain(stored, identity); err != nil {
+		return err
+	}
+	if stored.Scripts != identity.Scripts {
+		return fmt.Errorf("%w: the database was synced with the cota scripts\n%s\nbut the configured ones are\n%s\nrun `bootstrap -accept-scripts` after a script upgrade",
+			ErrChainMismatch, stored.Scripts, identity.Scripts)
+	}
+	return nil
+}
+
+// AcceptScripts replaces the stored cota script set, e.g. after a script upgrade. The chain must still match.
+func (uc *ChainIdentityUsecase) AcceptScripts(ctx context.Context, identity ChainIdentity, blockHash BlockHashFunc) error {
+	stored, found, err := uc.repo.FindChainIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return uc.bind(ctx, identity, blockHash)
+	}
+	if err = sameChain(stored, identity); err != nil {
+		return err
+	}
+	if stored.Scripts != identity.Scripts {
+		uc.logger.Infof(ctx, "accepted the cota scripts\n%s", identity.Scripts)
+	}
+	return uc.repo.UpdateChainIdentityScripts(ctx, identity.Scripts)
+}
+
+// bind stores the identity of a database without one. A database synced before the identity was introduced already
+// holds blocks, the oldest one kept has to be on the chain of the node, the one least likely to be reorged out.
+func (uc *ChainIdentityUsecase) bind(ctx context.Context, identity ChainIdentity, blockHash BlockHashFunc) error {
+	synced, found, err := uc.repo.FindSyncedBlock(ctx)
+	if err != nil {
+		return err
+	}
+	if found {
+		hash, err := blockHash(ctx, synced.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if hash != synced.BlockHash {
+			return fmt.Errorf("%w: the database has block %d with hash %s, the node on chain %s has hash %s",
+				ErrChainMismatch, synced.BlockNumber, synced.BlockHash, identity.Chain, hash)
+		}
+	}
+	uc.logger.Infof(ctx, "database bound to chain %s with genesis hash %s", identity.Chain, identity.GenesisHash)
+	return uc.repo.CreateChainIdentity(ctx, identity)
+}
+
+func sameChain(stored, identity ChainIdentity) error {
+	if stored.Chain != identity.Chain || stored.GenesisHash != identity.GenesisHash {
+		return fmt.Errorf("%w: the database belongs to chain %s with genesis hash %s, the node is on chain %s with genesis hash %s",
+			ErrChainMismatch, stored.Chain, stored.GenesisHash, identity.Chain, identity.GenesisHash)
+	}
+	return nil
+}
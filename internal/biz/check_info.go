@@ -2,9 +2,12 @@ package biz
 
 import (
 	"context"
+	"errors"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 )
 
+var ErrCheckInfoConflict = errors.New("check info does not follow the last synced block")
+
 type CheckType uint8
 
 const (
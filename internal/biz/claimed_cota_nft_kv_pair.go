@@ -6,14 +6,15 @@ import (
 )
 
 type ClaimedCotaNftKvPair struct {
-	BlockNumber uint64
-	CotaId      string
-	CotaIdCRC   uint32
-	TokenIndex  uint32
-	OutPoint    string
-	OutPointCrc uint32
-	LockHash    string
-	LockHashCrc uint32
+	BlockNumber   uint64
+	CotaId        string
+	CotaIdCRC     uint32
+	TokenIndex    uint32
+	OutPoint      string
+	OutPointCrc   uint32
+	LockHash      string
+	LockHashCrc   uint32
+	ScriptVersion uint8
 }
 
 type ClaimedCotaNftKvPairRepo interface {
@@ -0,0 +1,68 @@
+package biz
+
+import (
+	"context"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+type CellOutPoint struct {
+	TxHash string
+	Index  uint32
+}
+
+// CotaCell is a cell with the cota type script, it stays in the index until it is consumed deep enough
+type CotaCell struct {
+	TxHash       string
+	OutputIndex  uint32
+	LockHash     string
+	TypeHash     string
+	TypeCodeHash string
+	TypeHashType string
+	TypeArgs     string
+	Version      uint8
+	SmtRoot      string
+	BlockNumber  uint64
+}
+
+type CotaCellRepo interface {
+	FindCotaCells(ctx context.Context, outPoints []CellOutPoint) ([]CotaCell, error)
+	DeleteConsumedCotaCells(ctx context.Context, blockNumber uint64) error
+	FindCoverage(ctx context.Context) (fromBlockNumber uint64, found bool, err error)
+	CreateCoverage(ctx context.Context, fromBlockNumber uint64) error
+}
+
+type CotaCellUsecase struct {
+	repo   CotaCellRepo
+	logger *logger.Logger
+}
+
+func NewCotaCellUsecase(repo CotaCellRepo, logger *logger.Logger) *CotaCellUsecase {
+	return &CotaCellUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// FindCotaCells returns the indexed cota cells among the out points, consumed cells included
+func (uc *CotaCellUsecase) FindCotaCells(ctx context.Context, outPoints []CellOutPoint) ([]CotaCell, error) {
+	return uc.repo.FindCotaCells(ctx, outPoints)
+}
+
+// Complete reports whether the index holds every cota cell since the deployment of the cota scripts, only then an
+// out point missing from it is known not to be a cota cell
+func (uc *CotaCellUsecase) Complete(ctx context.Context) (bool, error) {
+	_, found, err := uc.repo.FindCoverage(ctx)
+	return found, err
+}
+
+// RecordCoverage marks the index complete, the sync indexes the cota cells from fromBlockNumber on and no cota cell
+// was created before it
+func (uc *CotaCellUsecase) RecordCoverage(ctx context.Context, fromBlockNumber uint64) error {
+	return uc.repo.CreateCoverage(ctx, fromBlockNumber)
+}
+
+// Clean removes the cells consumed before blockNumber
+func (uc *CotaCellUsecase) Clean(ctx context.Context, blockNumber uint64) error {
+	return uc.repo.DeleteConsumedCotaCells(ctx, blockNumber)
+}
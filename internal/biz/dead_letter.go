@@ -0,0 +1,40 @@
+package biz
+
+import (
+	"context"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// DeadLetter is a transaction quarantined by the sync of CheckType because its entries failed to parse. Witnesses
+// holds the raw witnesses as a JSON array of hex strings.
+type DeadLetter struct {
+	BlockNumber uint64
+	TxIndex     uint32
+	TxHash      string
+	CheckType   CheckType
+	ErrorKind   string
+	Error       string
+	Witnesses   string
+}
+
+type DeadLetterRepo interface {
+	FindDeadLetterBlockRange(ctx context.Context) (from uint64, to uint64, found bool, err error)
+}
+
+type DeadLetterUsecase struct {
+	repo   DeadLetterRepo
+	logger *logger.Logger
+}
+
+func NewDeadLetterUsecase(repo DeadLetterRepo, logger *logger.Logger) *DeadLetterUsecase {
+	return &DeadLetterUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// BlockRange returns the lowest and the highest block with dead letters, found is false when there are none
+func (uc *DeadLetterUsecase) BlockRange(ctx context.Context) (from uint64, to uint64, found bool, err error) {
+	return uc.repo.FindDeadLetterBlockRange(ctx)
+}
@@ -7,15 +7,16 @@ import (
 )
 
 type DefineCotaNftKvPair struct {
-	BlockNumber uint64
-	CotaId      string
-	Total       uint32
-	Issued      uint32
-	Configure   uint8
-	LockHash    string
-	LockHashCRC uint32
-	TxIndex     uint32
-	UpdatedAt   time.Time
+	BlockNumber   uint64
+	CotaId        string
+	Total         uint32
+	Issued        uint32
+	Configure     uint8
+	LockHash      string
+	LockHashCRC   uint32
+	ScriptVersion uint8
+	TxIndex       uint32
+	UpdatedAt     time.Time
 }
 
 type DefineCotaNftKvPairRepo interface {
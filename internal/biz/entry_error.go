@@ -0,0 +1,41 @@
+package biz
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMalformedEntry means the witness or the entry bytes of a transaction cannot be decoded
+	ErrMalformedEntry = errors.New("malformed cota entry")
+	// ErrInvalidEntry means an entry was decoded but cannot be stored, e.g. metadata longer than its column
+	ErrInvalidEntry = errors.New("invalid cota entry")
+)
+
+// EntryError is a parse failure caused by the data of a single transaction, unlike node or database errors it does
+// not go away on retry. errors.Is matches it against its Kind, ErrMalformedEntry or ErrInvalidEntry.
+type EntryError struct {
+	Kind    error
+	TxIndex uint32
+	Err     error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("%v in tx %d: %v", e.Kind, e.TxIndex, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *EntryError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func MalformedEntry(txIndex uint32, err error) error {
+	return &EntryError{Kind: ErrMalformedEntry, TxIndex: txIndex, Err: err}
+}
+
+func InvalidEntry(txIndex uint32, err error) error {
+	return &EntryError{Kind: ErrInvalidEntry, TxIndex: txIndex, Err: err}
+}
@@ -0,0 +1,163 @@
+package biz
+
+import "sync"
+
+// The action types of the cota entries, the first byte of the witness input type
+const (
+	DefineAction         uint8 = 1
+	MintAction           uint8 = 2
+	WithdrawAction       uint8 = 3
+	ClaimAction          uint8 = 4
+	UpdateAction         uint8 = 5
+	TransferAction       uint8 = 6
+	ClaimUpdateAction    uint8 = 7
+	TransferUpdateAction uint8 = 8
+)
+
+// CotaEntryVersions are the cota cell versions, the first byte of the cell data, handled by the built-in handlers
+var CotaEntryVersions = []uint8{0, 1, 2}
+
+// EntryHandler converts a cota entry of the action type and version it is registered for into kv pairs, which it
+// adds to kvPair
+type EntryHandler interface {
+	Handle(blockNumber uint64, entry Entry, kvPair *KvPair) error
+}
+
+// EntryHandlerFunc adapts a function to an EntryHandler
+type EntryHandlerFunc func(blockNumber uint64, entry Entry, kvPair *KvPair) error
+
+func (f EntryHandlerFunc) Handle(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+	return f(blockNumber, entry, kvPair)
+}
+
+type entryHandlerKey struct {
+	actionType uint8
+	version    uint8
+}
+
+// EntryHandlerRegistry looks up the handler of a cota entry by its action type and version. The entries without a
+// handler are recorded as unrecognized. An application embedding the syncer registers its own handlers before the
+// sync starts, a handler registered for a taken key replaces the built-in one.
+type EntryHandlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[entryHandlerKey]EntryHandler
+}
+
+// NewEntryHandlerRegistry returns a registry holding the built-in handlers of the cota actions for every version
+func NewEntryHandlerRegistry(defineCotaUsecase *DefineCotaNftKvPairUsecase, mintCotaUsecase *MintCotaKvPairUsecase,
+	withdrawCotaUsecase *WithdrawCotaNftKvPairUsecase, claimedCotaUsecase *ClaimedCotaNftKvPairUsecase,
+	holdCotaUsecase *HoldCotaNftKvPairUsecase, transferCotaUsecase *TransferCotaKvPairUsecase) *EntryHandlerRegistry {
+	r := &EntryHandlerRegistry{handlers: make(map[entryHandlerKey]EntryHandler)}
+	builtins := map[uint8]EntryHandlerFunc{
+		// Define 创建 DefineCota Kv pairs
+		DefineAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			defineCotas, err := defineCotaUsecase.ParseDefineCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.DefineCotas = append(kvPair.DefineCotas, defineCotas...)
+			return nil
+		},
+		// Mint 更新 DefineCota Kv pairs 创建 withdrawCota kv pairs
+		MintAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			updatedDefineCotas, withdrawCotas, err := mintCotaUsecase.ParseMintCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.UpdatedDefineCotas = append(kvPair.UpdatedDefineCotas, updatedDefineCotas...)
+			kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
+			return nil
+		},
+		// Withdraw 删除 HoldCota kv pairs 创建 withdrawCota kv pairs
+		WithdrawAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			withdrawCotas, err := withdrawCotaUsecase.ParseWithdrawCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
+			return nil
+		},
+		// Claim 创建 HoldCota kv pairs 与 claimedCota kv pairs
+		ClaimAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			holdCotas, claimedCotas, err := claimedCotaUsecase.ParseClaimedCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
+			kvPair.HoldCotas = append(kvPair.HoldCotas, holdCotas...)
+			return nil
+		},
+		// Update 更新 HoldCota kv pairs
+		UpdateAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			holdCotas, err := holdCotaUsecase.ParseHoldCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.UpdatedHoldCotas = append(kvPair.UpdatedHoldCotas, holdCotas...)
+			return nil
+		},
+		// Transfer 创建 claimedCota kv pairs 与 withdrawCota kv pairs
+		TransferAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			claimedCotas, withdrawCotas, err := transferCotaUsecase.ParseTransferCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
+			kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
+			return nil
+		},
+		// Claim and Update 创建 HoldCota kv pairs 与 claimedCota kv pairs
+		ClaimUpdateAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			holdCotas, claimedCotas, err := claimedCotaUsecase.ParseClaimedUpdateCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
+			kvPair.HoldCotas = append(kvPair.HoldCotas, holdCotas...)
+			return nil
+		},
+		// Transfer and Update 创建 claimedCota kv pairs 与 withdrawCota kv pairs
+		TransferUpdateAction: func(blockNumber uint64, entry Entry, kvPair *KvPair) error {
+			claimedCotas, withdrawCotas, err := transferCotaUsecase.ParseTransferUpdateCotaEntries(blockNumber, entry)
+			if err != nil {
+				return err
+			}
+			kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
+			kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
+			return nil
+		},
+	}
+	for actionType, handler := range builtins {
+		for _, version := range CotaEntryVersions {
+			r.Register(actionType, version, handler)
+		}
+	}
+	return r
+}
+
+// Register sets the handler of the entries of the action type and version
+func (r *EntryHandlerRegistry) Register(actionType uint8, version uint8, handler EntryHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[entryHandlerKey{actionType: actionType, version: version}] = handler
+}
+
+// Lookup returns the handler of the entries of the action type and version
+func (r *EntryHandlerRegistry) Lookup(actionType uint8, version uint8) (EntryHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[entryHandlerKey{actionType: actionType, version: version}]
+	return handler, ok
+}
+
+// HasAction reports whether a handler is registered for the action type in any version
+func (r *EntryHandlerRegistry) HasAction(actionType uint8) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for key := range r.handlers {
+		if key.actionType == actionType {
+			return true
+		}
+	}
+	return false
+}
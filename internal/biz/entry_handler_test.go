@@ -0,0 +1,71 @@
+package biz
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// updateEntries parses every update entry into one hold cota with the token index of the transaction
+type updateEntries struct {
+	HoldCotaNftKvPairRepo
+}
+
+func (updateEntries) ParseHoldCotaEntries(blockNumber uint64, entry Entry) ([]HoldCotaNftKvPair, error) {
+	return []HoldCotaNftKvPair{{BlockNumber: blockNumber, TokenIndex: entry.TxIndex, TxIndex: entry.TxIndex}}, nil
+}
+
+func TestEntryHandlerRegistry_update(t *testing.T) {
+	hold := func(txIndex uint32) HoldCotaNftKvPair {
+		return HoldCotaNftKvPair{BlockNumber: 100, TokenIndex: txIndex, TxIndex: txIndex}
+	}
+	tests := []struct {
+		name        string
+		kvPair      KvPair
+		entries     []Entry
+		wantHolds   []HoldCotaNftKvPair
+		wantUpdated []HoldCotaNftKvPair
+	}{
+		{
+			name:        "should add the hold cotas of an update entry to the updated ones",
+			entries:     []Entry{{InputType: []byte{UpdateAction}, TxIndex: 1}},
+			wantUpdated: []HoldCotaNftKvPair{hold(1)},
+		},
+		{
+			name:        "should keep the updated hold cotas of two update entries in one block",
+			entries:     []Entry{{InputType: []byte{UpdateAction}, TxIndex: 1}, {InputType: []byte{UpdateAction}, TxIndex: 2}},
+			wantUpdated: []HoldCotaNftKvPair{hold(1), hold(2)},
+		},
+		{
+			name:        "should not copy the claimed hold cotas of the block into the updated ones",
+			kvPair:      KvPair{HoldCotas: []HoldCotaNftKvPair{hold(0)}},
+			entries:     []Entry{{InputType: []byte{UpdateAction}, TxIndex: 1}},
+			wantHolds:   []HoldCotaNftKvPair{hold(0)},
+			wantUpdated: []HoldCotaNftKvPair{hold(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holdCotaUsecase := NewHoldCotaNftKvPairUsecase(updateEntries{}, logger.NewLogger(io.Discard, "", 0))
+			registry := NewEntryHandlerRegistry(nil, nil, nil, nil, holdCotaUsecase, nil)
+			kvPair := tt.kvPair
+			for _, entry := range tt.entries {
+				handler, ok := registry.Lookup(UpdateAction, entry.Version)
+				if !ok {
+					t.Fatalf("no handler of the update action in version %d", entry.Version)
+				}
+				if err := handler.Handle(100, entry, &kvPair); err != nil {
+					t.Fatalf("Handle() error = %v", err)
+				}
+			}
+			if !reflect.DeepEqual(kvPair.HoldCotas, tt.wantHolds) {
+				t.Errorf("hold cotas = %v, want %v", kvPair.HoldCotas, tt.wantHolds)
+			}
+			if !reflect.DeepEqual(kvPair.UpdatedHoldCotas, tt.wantUpdated) {
+				t.Errorf("updated hold cotas = %v, want %v", kvPair.UpdatedHoldCotas, tt.wantUpdated)
+			}
+		})
+	}
+}
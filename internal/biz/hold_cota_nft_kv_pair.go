@@ -16,6 +16,7 @@ type HoldCotaNftKvPair struct {
 	Characteristic string
 	LockHash       string
 	LockHashCRC    uint32
+	ScriptVersion  uint8
 	TxIndex        uint32
 	UpdatedAt      time.Time
 }
@@ -0,0 +1,13 @@
+package biz
+
+// RegistryHistory records one update of the registry cell: the transaction registering the locks, the registry cell it
+// created and the registry smt root after the update, so that every registration can be audited against the chain.
+type RegistryHistory struct {
+	BlockNumber   uint64
+	TxIndex       uint32
+	TxHash        string
+	OutputIndex   uint32
+	SmtRoot       string
+	RegisterCount uint32
+	ScriptVersion uint8
+}
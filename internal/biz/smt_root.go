@@ -0,0 +1,47 @@
+package biz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// ErrSmtRootMismatch is returned under the halt smt verification when the indexed kv pairs of a lock do not reproduce
+// the smt root of its cota cell
+var ErrSmtRootMismatch = errors.New("smt root mismatch")
+
+// SmtRootCheck compares the smt root in the data of the live cota cell of a lock with the root rebuilt from the
+// indexed kv pairs of the lock.
+type SmtRootCheck struct {
+	LockHash    string
+	BlockNumber uint64
+	CellRoot    string
+	Root        string
+	Leaves      int
+}
+
+func (c SmtRootCheck) Matches() bool {
+	return c.CellRoot == c.Root
+}
+
+type SmtRootRepo interface {
+	CheckSmtRoots(ctx context.Context, lockHashes []string) ([]SmtRootCheck, error)
+}
+
+type SmtRootUsecase struct {
+	repo   SmtRootRepo
+	logger *logger.Logger
+}
+
+func NewSmtRootUsecase(repo SmtRootRepo, logger *logger.Logger) *SmtRootUsecase {
+	return &SmtRootUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Check rebuilds the smt roots of the live cota cells of the locks, of all live cota cells without lock hashes
+func (uc *SmtRootUsecase) Check(ctx context.Context, lockHashes ...string) ([]SmtRootCheck, error) {
+	return uc.repo.CheckSmtRoots(ctx, lockHashes)
+}
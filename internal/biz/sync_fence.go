@@ -0,0 +1,57 @@
+package biz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+var ErrSyncFenced = errors.New("sync is fenced off by a running resync")
+
+type fenceTokenKey struct{}
+
+// WithFenceToken marks the commits made with the returned context as the ones of the fence holder
+func WithFenceToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, fenceTokenKey{}, token)
+}
+
+// FenceToken returns the fence token carried by ctx, empty for the live syncer
+func FenceToken(ctx context.Context) string {
+	token, _ := ctx.Value(fenceTokenKey{}).(string)
+	return token
+}
+
+type SyncFenceRepo interface {
+	AcquireSyncFence(ctx context.Context, name string) (string, error)
+	ReleaseSyncFence(ctx context.Context, name string, token string) error
+	ForceReleaseSyncFence(ctx context.Context, name string) error
+}
+
+// SyncFenceUsecase fences the live syncer off the kv pair tables. While a fence is held every commit or rollback
+// without its token fails with ErrSyncFenced.
+type SyncFenceUsecase struct {
+	repo   SyncFenceRepo
+	logger *logger.Logger
+}
+
+func NewSyncFenceUsecase(repo SyncFenceRepo, logger *logger.Logger) *SyncFenceUsecase {
+	return &SyncFenceUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Acquire waits for the running commits to finish and returns the token of the new fence
+func (uc *SyncFenceUsecase) Acquire(ctx context.Context, name string) (string, error) {
+	return uc.repo.AcquireSyncFence(ctx, name)
+}
+
+func (uc *SyncFenceUsecase) Release(ctx context.Context, name string, token string) error {
+	return uc.repo.ReleaseSyncFence(ctx, name, token)
+}
+
+// ForceRelease removes a fence left behind by a crashed holder
+func (uc *SyncFenceUsecase) ForceRelease(ctx context.Context, name string) error {
+	return uc.repo.ForceReleaseSyncFence(ctx, name)
+}
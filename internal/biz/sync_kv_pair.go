@@ -2,20 +2,27 @@ package biz
 
 import (
 	"context"
+	"crypto/sha256"
+	"sort"
 
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 )
 
 type KvPair struct {
-	Registers          []RegisterCotaKvPair
-	DefineCotas        []DefineCotaNftKvPair
-	UpdatedDefineCotas []DefineCotaNftKvPair
-	HoldCotas          []HoldCotaNftKvPair
-	UpdatedHoldCotas   []HoldCotaNftKvPair
-	WithdrawCotas      []WithdrawCotaNftKvPair
-	ClaimedCotas       []ClaimedCotaNftKvPair
-	IssuerInfos        []IssuerInfo
-	ClassInfos         []ClassInfo
+	Registers           []RegisterCotaKvPair
+	RegistryHistories   []RegistryHistory
+	DefineCotas         []DefineCotaNftKvPair
+	UpdatedDefineCotas  []DefineCotaNftKvPair
+	HoldCotas           []HoldCotaNftKvPair
+	UpdatedHoldCotas    []HoldCotaNftKvPair
+	WithdrawCotas       []WithdrawCotaNftKvPair
+	ClaimedCotas        []ClaimedCotaNftKvPair
+	IssuerInfos         []IssuerInfo
+	ClassInfos          []ClassInfo
+	CotaCells           []CotaCell
+	ConsumedCells       []CellOutPoint
+	DeadLetters         []DeadLetter
+	UnrecognizedEntries []UnrecognizedEntry
 }
 
 func (p KvPair) HasRegisters() bool {
@@ -50,11 +57,84 @@ func (p KvPair) HasClassInfos() bool {
 	return len(p.ClassInfos) > 0
 }
 
+func (p KvPair) HasCotaCells() bool {
+	return len(p.CotaCells) > 0
+}
+
+func (p KvPair) HasDeadLetters() bool {
+	return len(p.DeadLetters) > 0
+}
+
 type KvPairRepo interface {
 	CreateCotaEntryKvPairs(ctx context.Context, checkInfo CheckInfo, kvPair *KvPair) error
 	RestoreCotaEntryKvPairs(ctx context.Context, blockNumber uint64) error
 	CreateMetadataKvPairs(ctx context.Context, checkInfo CheckInfo, kvPair *KvPair) error
 	RestoreMetadataKvPairs(ctx context.Context, blockNumber uint64) error
+	CreateKvPairs(ctx context.Context, checkInfo CheckInfo, kvPair *KvPair) error
+	SkipBlocks(ctx context.Context, last CheckInfo, checkInfo CheckInfo, checkTypes ...CheckType) error
+	RewindKvPairs(ctx context.Context, ancestor CheckInfo) error
+	SnapshotKvPairs(ctx context.Context, fromBlockNumber, toBlockNumber uint64) (KvPairSnapshot, error)
+}
+
+// KvPairSnapshot counts the rows of the kv pair tables by the hash of their canonical form, keyed by table name. Only
+// the hashes are kept, so that a snapshot of a long block range fits in memory.
+type KvPairSnapshot map[string]map[RowHash]int
+
+// RowHash is the truncated sha256 hash of the canonical form of a row
+type RowHash [16]byte
+
+// Add counts a row of the table given in its canonical form
+func (s KvPairSnapshot) Add(table string, row string) {
+	rows, ok := s[table]
+	if !ok {
+		rows = make(map[RowHash]int)
+		s[table] = rows
+	}
+	var hash RowHash
+	sum := sha256.Sum256([]byte(row))
+	copy(hash[:], sum[:])
+	rows[hash]++
+}
+
+// TableChange counts the rows of a table that only exist before (Removed) or after (Added) a change
+type TableChange struct {
+	Table   string
+	Added   int
+	Removed int
+}
+
+// DiffKvPairSnapshots returns the changed tables ordered by name
+func DiffKvPairSnapshots(before, after KvPairSnapshot) []TableChange {
+	tables := make(map[string]bool)
+	for table := range before {
+		tables[table] = true
+	}
+	for table := range after {
+		tables[table] = true
+	}
+	names := make([]string, 0, len(tables))
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+	var changes []TableChange
+	for _, table := range names {
+		change := TableChange{Table: table}
+		for hash, count := range after[table] {
+			if count > before[table][hash] {
+				change.Added += count - before[table][hash]
+			}
+		}
+		for hash, count := range before[table] {
+			if count > after[table][hash] {
+				change.Removed += count - after[table][hash]
+			}
+		}
+		if change.Added > 0 || change.Removed > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
 }
 
 type SyncKvPairUsecase struct {
@@ -84,3 +164,23 @@ func (uc SyncKvPairUsecase) CreateMetadataKvPairs(ctx context.Context, checkInfo
 func (uc SyncKvPairUsecase) RestoreMetadataKvPairs(ctx context.Context, blockNumber uint64) error {
 	return uc.repo.RestoreMetadataKvPairs(ctx, blockNumber)
 }
+
+// CreateKvPairs stores the cota entry and metadata kv pairs of a block and advances the check infos of both check types together
+func (uc SyncKvPairUsecase) CreateKvPairs(ctx context.Context, checkInfo CheckInfo, kvPair *KvPair) error {
+	return uc.repo.CreateKvPairs(ctx, checkInfo, kvPair)
+}
+
+// RewindKvPairs undoes every block above the ancestor through the version tables and moves both check infos back to it
+func (uc SyncKvPairUsecase) RewindKvPairs(ctx context.Context, ancestor CheckInfo) error {
+	return uc.repo.RewindKvPairs(ctx, ancestor)
+}
+
+// SnapshotKvPairs returns the rows of the kv pair tables written by the blocks in [fromBlockNumber, toBlockNumber]
+func (uc SyncKvPairUsecase) SnapshotKvPairs(ctx context.Context, fromBlockNumber, toBlockNumber uint64) (KvPairSnapshot, error) {
+	return uc.repo.SnapshotKvPairs(ctx, fromBlockNumber, toBlockNumber)
+}
+
+// SkipBlocks moves the check infos of the check types from last to checkInfo, the blocks in between have no cota transactions
+func (uc SyncKvPairUsecase) SkipBlocks(ctx context.Context, last CheckInfo, checkInfo CheckInfo, checkTypes ...CheckType) error {
+	return uc.repo.SkipBlocks(ctx, last, checkInfo, checkTypes...)
+}
@@ -0,0 +1,51 @@
+package biz
+
+import (
+	"context"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// UnconfirmedBlock is a block above the confirmation depth whose kv pairs are recorded in the overlay
+type UnconfirmedBlock struct {
+	BlockNumber uint64
+	BlockHash   string
+}
+
+type UnconfirmedKvPairRepo interface {
+	FindUnconfirmedBlocks(ctx context.Context) ([]UnconfirmedBlock, error)
+	CreateUnconfirmedKvPairs(ctx context.Context, block UnconfirmedBlock, kvPair *KvPair) error
+	DeleteConfirmedKvPairs(ctx context.Context, blockNumber uint64) error
+	DeleteOrphanedKvPairs(ctx context.Context, blockNumber uint64) error
+}
+
+type UnconfirmedKvPairUsecase struct {
+	repo   UnconfirmedKvPairRepo
+	logger *logger.Logger
+}
+
+func NewUnconfirmedKvPairUsecase(repo UnconfirmedKvPairRepo, logger *logger.Logger) *UnconfirmedKvPairUsecase {
+	return &UnconfirmedKvPairUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// UnconfirmedBlocks returns the recorded blocks ordered by block number asc
+func (uc *UnconfirmedKvPairUsecase) UnconfirmedBlocks(ctx context.Context) ([]UnconfirmedBlock, error) {
+	return uc.repo.FindUnconfirmedBlocks(ctx)
+}
+
+func (uc *UnconfirmedKvPairUsecase) Create(ctx context.Context, block UnconfirmedBlock, kvPair *KvPair) error {
+	return uc.repo.CreateUnconfirmedKvPairs(ctx, block, kvPair)
+}
+
+// Confirm removes the blocks at or below blockNumber, they are available in the final tables
+func (uc *UnconfirmedKvPairUsecase) Confirm(ctx context.Context, blockNumber uint64) error {
+	return uc.repo.DeleteConfirmedKvPairs(ctx, blockNumber)
+}
+
+// Rollback removes the blocks at or above blockNumber
+func (uc *UnconfirmedKvPairUsecase) Rollback(ctx context.Context, blockNumber uint64) error {
+	return uc.repo.DeleteOrphanedKvPairs(ctx, blockNumber)
+}
@@ -0,0 +1,14 @@
+package biz
+
+// UnrecognizedEntry is a cota entry whose action type or entry version the parsers do not know, e.g. one written by a
+// newer cota script. It is kept with its raw bytes instead of being dropped, RawData is the hex of the witness input
+// type including the action byte.
+type UnrecognizedEntry struct {
+	BlockNumber   uint64
+	TxIndex       uint32
+	LockHash      string
+	ActionType    uint8
+	Version       uint8
+	ScriptVersion uint8
+	RawData       string
+}
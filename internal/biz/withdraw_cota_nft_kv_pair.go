@@ -19,6 +19,7 @@ type WithdrawCotaNftKvPair struct {
 	LockHash             string
 	LockHashCrc          uint32
 	Version              uint8
+	ScriptVersion        uint8
 }
 
 type Script struct {
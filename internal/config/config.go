@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"github.com/spf13/viper"
 	"time"
 )
@@ -17,16 +18,101 @@ type Database struct {
 	ConnMaxLifeTime time.Duration `mapstructure:"conn_max_lifetime"`
 }
 
+const (
+	SeparateSyncMode = "separate"
+	UnifiedSyncMode  = "unified"
+
+	RpcInputResolution   = "rpc"
+	LocalInputResolution = "local"
+
+	RpcBlockSource  = "rpc"
+	FileBlockSource = "file"
+
+	JsonArchiveFormat     = "json"
+	MoleculeArchiveFormat = "molecule"
+
+	HaltEntryErrorPolicy       = "halt"
+	QuarantineEntryErrorPolicy = "quarantine"
+
+	OffSmtVerification    = "off"
+	ReportSmtVerification = "report"
+	HaltSmtVerification   = "halt"
+)
+
 type App struct {
-	LogSavePath string `mapstructure:"log_save_path"`
-	LogFileName string `mapstructure:"log_file_name"`
-	LogFileExt  string `mapstructure:"log_file_ext"`
-	Mode        string `mapstructure:"mode"`
+	LogSavePath        string `mapstructure:"log_save_path"`
+	LogFileName        string `mapstructure:"log_file_name"`
+	LogFileExt         string `mapstructure:"log_file_ext"`
+	Mode               string `mapstructure:"mode"`
+	PrefetchWindow     int    `mapstructure:"prefetch_window"`
+	ParseWorkers       int    `mapstructure:"parse_workers"`
+	MaxReorgDepth      uint64 `mapstructure:"max_reorg_depth"`
+	Confirmations      uint64 `mapstructure:"confirmations"`
+	UnconfirmedOverlay bool   `mapstructure:"unconfirmed_overlay"`
+	SyncMode           string `mapstructure:"sync_mode"`
+	InputCacheSize     int    `mapstructure:"input_cache_size"`
+	InputResolution    string `mapstructure:"input_resolution"`
+	MetricsAddr        string `mapstructure:"metrics_addr"`
+	SparseSync         bool   `mapstructure:"sparse_sync"`
+	StartBlockNumber   uint64 `mapstructure:"start_block_number"`
+	StartBlockHash     string `mapstructure:"start_block_hash"`
+	EntryErrorPolicy   string `mapstructure:"entry_error_policy"`
+	SmtVerification    string `mapstructure:"smt_verification"`
+}
+
+// ErrUnverifiedSmtHalt is returned for smt_verification: halt. The smt root is computed by internal/data/cotaext and
+// has not been checked against the roots of real cota cells yet, a mismatch from it must not stop the sync.
+var ErrUnverifiedSmtHalt = errors.New("smt_verification halt is not supported until the smt root passes golden fixtures of real cota cells, use report")
+
+// Validate rejects the app settings the syncer must not run with
+func (a *App) Validate() error {
+	if a.SmtVerification == HaltSmtVerification {
+		return ErrUnverifiedSmtHalt
+	}
+	return nil
 }
 
 type CkbNode struct {
-	RpcUrl string `mapstructure:"rpc_url"`
-	Mode   string `mapstructure:"mode"`
+	RpcUrl              string        `mapstructure:"rpc_url"`
+	RpcUrls             []string      `mapstructure:"rpc_urls"`
+	MaxTipLag           uint64        `mapstructure:"max_tip_lag"`
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	Mode                string        `mapstructure:"mode"`
+	SubscriptionUrl     string        `mapstructure:"subscription_url"`
+	IndexerUrl          string        `mapstructure:"indexer_url"`
+	BlockSource         string        `mapstructure:"block_source"`
+	ArchiveDir          string        `mapstructure:"archive_dir"`
+	Record              bool          `mapstructure:"record"`
+	ArchiveFormat       string        `mapstructure:"archive_format"`
+	MoleculeBlocks      bool          `mapstructure:"molecule_blocks"`
+	RpcTimeout          time.Duration `mapstructure:"rpc_timeout"`
+	RpcRetries          int           `mapstructure:"rpc_retries"`
+	RpcRetryDelay       time.Duration `mapstructure:"rpc_retry_delay"`
+	RpcMaxRetryDelay    time.Duration `mapstructure:"rpc_max_retry_delay"`
+	BreakerFailures     int           `mapstructure:"breaker_failures"`
+	BreakerCooldown     time.Duration `mapstructure:"breaker_cooldown"`
+	// SystemScripts holds the cota scripts by chain name, as reported by get_blockchain_info
+	SystemScripts map[string]ChainScripts `mapstructure:"system_scripts"`
+}
+
+// ChainScripts lists the deployments of the cota scripts on a chain
+type ChainScripts struct {
+	CotaRegistryType []Script `mapstructure:"cota_registry_type"`
+	CotaType         []Script `mapstructure:"cota_type"`
+}
+
+// Script is a deployment of a script and its cell dep, the hashes and args are 0x prefixed hex. The deployment is
+// valid from FromBlock to ToBlock inclusive, a zero ToBlock means no end.
+type Script struct {
+	CodeHash  string `mapstructure:"code_hash"`
+	HashType  string `mapstructure:"hash_type"`
+	Args      string `mapstructure:"args"`
+	TxHash    string `mapstructure:"tx_hash"`
+	Index     uint   `mapstructure:"index"`
+	DepType   string `mapstructure:"dep_type"`
+	Version   uint8  `mapstructure:"version"`
+	FromBlock uint64 `mapstructure:"from_block"`
+	ToBlock   uint64 `mapstructure:"to_block"`
 }
 
 type Config struct {
@@ -0,0 +1,249 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+var _ biz.AuditRepo = (*auditRepo)(nil)
+
+// auditBatchSize bounds the rows an audit check loads at once, the tables are walked by primary key
+const auditBatchSize = 1000
+
+type auditRepo struct {
+	data       *Data
+	sparseSync bool
+	logger     *logger.Logger
+}
+
+func NewAuditRepo(data *Data, appConf *config.App, logger *logger.Logger) biz.AuditRepo {
+	return &auditRepo{
+		data:       data,
+		sparseSync: appConf.SparseSync,
+		logger:     logger,
+	}
+}
+
+type tokenKey struct {
+	cotaId     string
+	tokenIndex uint32
+}
+
+// AuditIssued compares the issued count of every definition with the distinct token indexes withdrawn from the cota
+// id. Every mint withdraws a new token and later withdrawals only move minted tokens, so the counts are equal and no
+// token index reaches issued.
+func (rp auditRepo) AuditIssued(ctx context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	summary := biz.AuditSummary{Invariant: biz.IssuedInvariant}
+	var lastId uint
+	for {
+		var defines []DefineCotaNftKvPair
+		if err := rp.data.db.WithContext(ctx).Select("id", "block_number", "cota_id", "issued", "lock_hash").
+			Where("id > ?", lastId).Order("id").Limit(auditBatchSize).Find(&defines).Error; err != nil {
+			return summary, err
+		}
+		if len(defines) == 0 {
+			return summary, nil
+		}
+		lastId = defines[len(defines)-1].ID
+		cotaIds := make([]string, len(defines))
+		cotaIdCrcs := make([]uint32, len(defines))
+		for i, define := range defines {
+			cotaIds[i] = define.CotaId
+			cotaIdCrcs[i] = crc32.ChecksumIEEE([]byte(define.CotaId))
+		}
+		var minted []struct {
+			CotaId   string
+			Minted   uint32
+			MaxIndex uint32
+		}
+		if err := rp.data.db.WithContext(ctx).Model(WithdrawCotaNftKvPair{}).
+			Select("cota_id, count(distinct token_index) as minted, max(token_index) as max_index").
+			Where("cota_id_crc IN ? and cota_id IN ?", cotaIdCrcs, cotaIds).Group("cota_id").Scan(&minted).Error; err != nil {
+			return summary, err
+		}
+		mintedByCotaId := make(map[string]int, len(minted))
+		for i, m := range minted {
+			mintedByCotaId[m.CotaId] = i
+		}
+		for _, define := range defines {
+			summary.Checked++
+			var count, maxIndex uint32
+			if i, ok := mintedByCotaId[define.CotaId]; ok {
+				count, maxIndex = minted[i].Minted, minted[i].MaxIndex
+			}
+			if count == define.Issued && (count == 0 || maxIndex < define.Issued) {
+				continue
+			}
+			summary.Violations++
+			if err := report(biz.AuditViolation{
+				Invariant:   summary.Invariant,
+				LockHash:    define.LockHash,
+				CotaId:      define.CotaId,
+				BlockNumber: define.BlockNumber,
+				Detail:      fmt.Sprintf("issued %d, %d distinct tokens withdrawn, highest token index %d", define.Issued, count, maxIndex),
+			}); err != nil {
+				return summary, err
+			}
+		}
+	}
+}
+
+// AuditClaims looks up the withdrawal of every claim by cota id, token index and out point
+func (rp auditRepo) AuditClaims(ctx context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	summary := biz.AuditSummary{Invariant: biz.ClaimInvariant}
+	var lastId uint
+	for {
+		var claims []ClaimedCotaNftKvPair
+		if err := rp.data.db.WithContext(ctx).Select("id", "block_number", "cota_id", "cota_id_crc", "token_index", "out_point", "out_point_crc", "lock_hash").
+			Where("id > ?", lastId).Order("id").Limit(auditBatchSize).Find(&claims).Error; err != nil {
+			return summary, err
+		}
+		if len(claims) == 0 {
+			return summary, nil
+		}
+		lastId = claims[len(claims)-1].ID
+		outPointCrcs := make([]uint32, len(claims))
+		cotaIdCrcs := make([]uint32, len(claims))
+		tokenIndexes := make([]uint32, len(claims))
+		for i, claim := range claims {
+			outPointCrcs[i] = claim.OutPointCrc
+			cotaIdCrcs[i] = claim.CotaIdCRC
+			tokenIndexes[i] = claim.TokenIndex
+		}
+		var withdrawals []WithdrawCotaNftKvPair
+		if err := rp.data.db.WithContext(ctx).Select("cota_id", "token_index", "out_point").
+			Where("out_point_crc IN ? and cota_id_crc IN ? and token_index IN ?", outPointCrcs, cotaIdCrcs, tokenIndexes).
+			Find(&withdrawals).Error; err != nil {
+			return summary, err
+		}
+		type withdrawalKey struct {
+			tokenKey
+			outPoint string
+		}
+		withdrawn := make(map[withdrawalKey]bool, len(withdrawals))
+		for _, withdrawal := range withdrawals {
+			withdrawn[withdrawalKey{tokenKey{withdrawal.CotaId, withdrawal.TokenIndex}, withdrawal.OutPoint}] = true
+		}
+		for _, claim := range claims {
+			summary.Checked++
+			if withdrawn[withdrawalKey{tokenKey{claim.CotaId, claim.TokenIndex}, claim.OutPoint}] {
+				continue
+			}
+			summary.Violations++
+			tokenIndex := claim.TokenIndex
+			if err := report(biz.AuditViolation{
+				Invariant:   summary.Invariant,
+				LockHash:    claim.LockHash,
+				CotaId:      claim.CotaId,
+				TokenIndex:  &tokenIndex,
+				BlockNumber: claim.BlockNumber,
+				Detail:      fmt.Sprintf("no withdrawal with out point %s", claim.OutPoint),
+			}); err != nil {
+				return summary, err
+			}
+		}
+	}
+}
+
+// AuditOwners checks that no held token was withdrawn after its holder got it. A withdrawal removes the hold of the
+// sender, a later one left next to the hold means the token belongs to the holder and to the receiver. The unique
+// key of hold_cota_nft_kv_pairs already rules out two holders.
+func (rp auditRepo) AuditOwners(ctx context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	summary := biz.AuditSummary{Invariant: biz.SingleOwnerInvariant}
+	var lastId uint
+	for {
+		var holds []HoldCotaNftKvPair
+		if err := rp.data.db.WithContext(ctx).Select("id", "block_number", "cota_id", "token_index", "lock_hash").
+			Where("id > ?", lastId).Order("id").Limit(auditBatchSize).Find(&holds).Error; err != nil {
+			return summary, err
+		}
+		if len(holds) == 0 {
+			return summary, nil
+		}
+		lastId = holds[len(holds)-1].ID
+		cotaIdCrcs := make([]uint32, len(holds))
+		tokenIndexes := make([]uint32, len(holds))
+		for i, hold := range holds {
+			cotaIdCrcs[i] = crc32.ChecksumIEEE([]byte(hold.CotaId))
+			tokenIndexes[i] = hold.TokenIndex
+		}
+		var withdrawals []struct {
+			CotaId      string
+			TokenIndex  uint32
+			BlockNumber uint64
+		}
+		if err := rp.data.db.WithContext(ctx).Model(WithdrawCotaNftKvPair{}).
+			Select("cota_id, token_index, max(block_number) as block_number").
+			Where("cota_id_crc IN ? and token_index IN ?", cotaIdCrcs, tokenIndexes).
+			Group("cota_id, token_index").Scan(&withdrawals).Error; err != nil {
+			return summary, err
+		}
+		lastWithdrawn := make(map[tokenKey]uint64, len(withdrawals))
+		for _, withdrawal := range withdrawals {
+			lastWithdrawn[tokenKey{withdrawal.CotaId, withdrawal.TokenIndex}] = withdrawal.BlockNumber
+		}
+		for _, hold := range holds {
+			summary.Checked++
+			withdrawnAt, ok := lastWithdrawn[tokenKey{hold.CotaId, hold.TokenIndex}]
+			if !ok || withdrawnAt <= hold.BlockNumber {
+				continue
+			}
+			summary.Violations++
+			tokenIndex := hold.TokenIndex
+			if err := report(biz.AuditViolation{
+				Invariant:   summary.Invariant,
+				LockHash:    hold.LockHash,
+				CotaId:      hold.CotaId,
+				TokenIndex:  &tokenIndex,
+				BlockNumber: hold.BlockNumber,
+				Detail:      fmt.Sprintf("held since block %d but withdrawn at block %d", hold.BlockNumber, withdrawnAt),
+			}); err != nil {
+				return summary, err
+			}
+		}
+	}
+}
+
+// AuditCheckInfos walks the check infos of every check type by block number and reports the missing blocks. The
+// cleaner keeps only the latest check infos, so the check covers those. The sparse sync skips blocks on purpose, its
+// check infos are not checked.
+func (rp auditRepo) AuditCheckInfos(ctx context.Context, report biz.AuditReport) (biz.AuditSummary, error) {
+	summary := biz.AuditSummary{Invariant: biz.CheckInfoInvariant}
+	if rp.sparseSync {
+		summary.Skipped = "sparse_sync skips blocks without cota transactions"
+		return summary, nil
+	}
+	rows, err := rp.data.db.WithContext(ctx).Model(CheckInfo{}).Select("check_type, block_number").
+		Order("check_type, block_number").Rows()
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+	var last CheckInfo
+	for rows.Next() {
+		var info CheckInfo
+		if err := rows.Scan(&info.CheckType, &info.BlockNumber); err != nil {
+			return summary, err
+		}
+		summary.Checked++
+		previous := last
+		last = info
+		if summary.Checked == 1 || previous.CheckType != info.CheckType || info.BlockNumber <= previous.BlockNumber+1 {
+			continue
+		}
+		summary.Violations++
+		if err := report(biz.AuditViolation{
+			Invariant:   summary.Invariant,
+			BlockNumber: info.BlockNumber,
+			Detail:      fmt.Sprintf("%s has no check info for the blocks (%d, %d)", info.CheckType, previous.BlockNumber, info.BlockNumber),
+		}); err != nil {
+			return summary, err
+		}
+	}
+	return summary, rows.Err()
+}
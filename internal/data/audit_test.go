@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+func TestAuditRepo(t *testing.T) {
+	cotaId := func(i int) string { return fmt.Sprintf("%040x", i) }
+	outPoint := func(i int) string { return fmt.Sprintf("%048x", i) }
+	define := func(id int, issued uint32, blockNumber uint64) DefineCotaNftKvPair {
+		return DefineCotaNftKvPair{BlockNumber: blockNumber, CotaId: cotaId(id), Total: 100, Issued: issued}
+	}
+	withdraw := func(id int, tokenIndex uint32, out int, blockNumber uint64) WithdrawCotaNftKvPair {
+		return WithdrawCotaNftKvPair{BlockNumber: blockNumber, CotaId: cotaId(id), CotaIdCRC: crc32.ChecksumIEEE([]byte(cotaId(id))),
+			TokenIndex: tokenIndex, OutPoint: outPoint(out), OutPointCrc: crc32.ChecksumIEEE([]byte(outPoint(out)))}
+	}
+	claim := func(id int, tokenIndex uint32, out int, blockNumber uint64) ClaimedCotaNftKvPair {
+		return ClaimedCotaNftKvPair{BlockNumber: blockNumber, CotaId: cotaId(id), CotaIdCRC: crc32.ChecksumIEEE([]byte(cotaId(id))),
+			TokenIndex: tokenIndex, OutPoint: outPoint(out), OutPointCrc: crc32.ChecksumIEEE([]byte(outPoint(out)))}
+	}
+	hold := func(id int, tokenIndex uint32, blockNumber uint64) HoldCotaNftKvPair {
+		return HoldCotaNftKvPair{BlockNumber: blockNumber, CotaId: cotaId(id), TokenIndex: tokenIndex}
+	}
+	checkInfos := func(checkType biz.CheckType, blockNumbers ...uint64) []CheckInfo {
+		infos := make([]CheckInfo, len(blockNumbers))
+		for i, blockNumber := range blockNumbers {
+			infos[i] = CheckInfo{BlockNumber: blockNumber, BlockHash: blockHash(blockNumber).String()[2:], CheckType: checkType}
+		}
+		return infos
+	}
+	// the last of auditBatchSize+1 definitions is loaded by the second batch
+	boundaryDefines := make([]DefineCotaNftKvPair, auditBatchSize+1)
+	for i := range boundaryDefines {
+		boundaryDefines[i] = define(i+1, 0, uint64(i+1))
+	}
+	boundaryDefines[auditBatchSize].Issued = 1
+
+	issued := func(rp auditRepo) func(context.Context, biz.AuditReport) (biz.AuditSummary, error) {
+		return rp.AuditIssued
+	}
+	claims := func(rp auditRepo) func(context.Context, biz.AuditReport) (biz.AuditSummary, error) {
+		return rp.AuditClaims
+	}
+	owners := func(rp auditRepo) func(context.Context, biz.AuditReport) (biz.AuditSummary, error) {
+		return rp.AuditOwners
+	}
+	continuous := func(rp auditRepo) func(context.Context, biz.AuditReport) (biz.AuditSummary, error) {
+		return rp.AuditCheckInfos
+	}
+	tests := []struct {
+		name       string
+		sparseSync bool
+		rows       []any
+		audit      func(rp auditRepo) func(context.Context, biz.AuditReport) (biz.AuditSummary, error)
+		want       biz.AuditSummary
+		// wantBlocks are the block numbers of the violations in report order
+		wantBlocks []uint64
+	}{
+		{
+			name:  "should accept an issued count equal to the minted tokens",
+			rows:  []any{[]DefineCotaNftKvPair{define(1, 2, 1)}, []WithdrawCotaNftKvPair{withdraw(1, 0, 1, 2), withdraw(1, 1, 2, 2), withdraw(1, 0, 3, 3)}},
+			audit: issued,
+			want:  biz.AuditSummary{Invariant: biz.IssuedInvariant, Checked: 1},
+		},
+		{
+			name:       "should report an issued count above the minted tokens",
+			rows:       []any{[]DefineCotaNftKvPair{define(1, 3, 1)}, []WithdrawCotaNftKvPair{withdraw(1, 0, 1, 2), withdraw(1, 1, 2, 2)}},
+			audit:      issued,
+			want:       biz.AuditSummary{Invariant: biz.IssuedInvariant, Checked: 1, Violations: 1},
+			wantBlocks: []uint64{1},
+		},
+		{
+			name:       "should report a token index at the issued count",
+			rows:       []any{[]DefineCotaNftKvPair{define(1, 2, 1)}, []WithdrawCotaNftKvPair{withdraw(1, 0, 1, 2), withdraw(1, 2, 2, 2)}},
+			audit:      issued,
+			want:       biz.AuditSummary{Invariant: biz.IssuedInvariant, Checked: 1, Violations: 1},
+			wantBlocks: []uint64{1},
+		},
+		{
+			name:       "should check the definitions past the batch boundary",
+			rows:       []any{boundaryDefines},
+			audit:      issued,
+			want:       biz.AuditSummary{Invariant: biz.IssuedInvariant, Checked: auditBatchSize + 1, Violations: 1},
+			wantBlocks: []uint64{auditBatchSize + 1},
+		},
+		{
+			name:  "should accept a claim of a withdrawal",
+			rows:  []any{[]WithdrawCotaNftKvPair{withdraw(1, 0, 1, 2)}, []ClaimedCotaNftKvPair{claim(1, 0, 1, 3)}},
+			audit: claims,
+			want:  biz.AuditSummary{Invariant: biz.ClaimInvariant, Checked: 1},
+		},
+		{
+			name:       "should report a claim without a withdrawal",
+			rows:       []any{[]WithdrawCotaNftKvPair{withdraw(1, 0, 1, 2)}, []ClaimedCotaNftKvPair{claim(1, 0, 1, 3), claim(1, 0, 2, 4)}},
+			audit:      claims,
+			want:       biz.AuditSummary{Invariant: biz.ClaimInvariant, Checked: 2, Violations: 1},
+			wantBlocks: []uint64{4},
+		},
+		{
+			name: "should report a held token withdrawn after its holder got it",
+			rows: []any{[]HoldCotaNftKvPair{hold(1, 0, 3), hold(1, 1, 3)},
+				[]WithdrawCotaNftKvPair{withdraw(1, 0, 1, 2), withdraw(1, 1, 2, 2), withdraw(1, 1, 3, 5)}},
+			audit:      owners,
+			want:       biz.AuditSummary{Invariant: biz.SingleOwnerInvariant, Checked: 2, Violations: 1},
+			wantBlocks: []uint64{3},
+		},
+		{
+			name:       "should report a gap in the check infos of a check type",
+			rows:       []any{checkInfos(biz.SyncBlock, 1, 2, 5, 6), checkInfos(biz.SyncMetadata, 3, 4)},
+			audit:      continuous,
+			want:       biz.AuditSummary{Invariant: biz.CheckInfoInvariant, Checked: 6, Violations: 1},
+			wantBlocks: []uint64{5},
+		},
+		{
+			name:       "should skip the check infos of a sparse sync",
+			sparseSync: true,
+			rows:       []any{checkInfos(biz.SyncBlock, 1, 5)},
+			audit:      continuous,
+			want:       biz.AuditSummary{Invariant: biz.CheckInfoInvariant, Skipped: "sparse_sync skips blocks without cota transactions"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTestData(t)
+			for _, rows := range tt.rows {
+				if err := data.db.CreateInBatches(rows, 500).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			rp := NewAuditRepo(data, &config.App{SparseSync: tt.sparseSync}, logger.NewLogger(io.Discard, "", 0)).(*auditRepo)
+			var blocks []uint64
+			got, err := tt.audit(*rp)(context.Background(), func(violation biz.AuditViolation) error {
+				blocks = append(blocks, violation.BlockNumber)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("audit error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("summary = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(blocks, tt.wantBlocks) {
+				t.Errorf("violation blocks = %v, want %v", blocks, tt.wantBlocks)
+			}
+		})
+	}
+}
+
+func TestDBMigration_Current(t *testing.T) {
+	data := newTestData(t)
+	migration := &DBMigration{data: data, logger: logger.NewLogger(io.Discard, "", 0), sourceURL: "file://../db/migrations"}
+	if err := migration.Current(); err != nil {
+		t.Fatalf("Current() error = %v", err)
+	}
+	var version uint
+	if err := data.db.Raw("SELECT version FROM schema_migrations").Scan(&version).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { data.db.Exec("UPDATE schema_migrations SET version = ?", version) })
+	if err := data.db.Exec("UPDATE schema_migrations SET version = ?", version-1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := migration.Current(); !errors.Is(err, ErrSchemaNotCurrent) {
+		t.Errorf("Current() error = %v, want %v", err, ErrSchemaNotCurrent)
+	}
+}
@@ -0,0 +1,637 @@
+package data
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/blockchain"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// A block archive is a directory of append-only files holding the blocks, the headers and previous transactions the
+// syncer looked up, and the chain info. The blocks and transactions are either JSON lines of the ckb-sdk-go types or
+// molecule encoded, each transaction prefixed with the hash of its block. Headers are always JSON lines. Later records
+// win, so a block recorded again after a reorg replaces the orphaned one.
+const (
+	archiveChainFile                = "chain.json"
+	archiveHeadersFile              = "headers.jsonl"
+	archiveBlocksFile               = "blocks.jsonl"
+	archiveTransactionsFile         = "transactions.jsonl"
+	archiveMoleculeBlocksFile       = "blocks.mol"
+	archiveMoleculeTransactionsFile = "transactions.mol"
+)
+
+var (
+	errNotArchived        = errors.New("not found in the block archive")
+	errIndexerNotArchived = errors.New("the block archive has no indexer")
+)
+
+type archiveRecord struct {
+	offset int64
+	length int
+}
+
+// archiveKey identifies a record, transactions and headers only have a hash
+type archiveKey struct {
+	number uint64
+	hash   ckbTypes.Hash
+}
+
+// archiveCodec frames and converts the records of an archive file
+type archiveCodec struct {
+	// next returns the next complete record, io.EOF or io.ErrUnexpectedEOF end the file
+	next   func(reader *bufio.Reader) ([]byte, error)
+	key    func(record []byte) (archiveKey, error)
+	encode func(v any) ([]byte, error)
+	decode func(record []byte, v any) error
+}
+
+type archiveFile struct {
+	name  string
+	codec archiveCodec
+	file  *os.File
+	size  int64
+}
+
+var (
+	jsonBlockCodec = archiveCodec{
+		next: nextLine,
+		key: func(record []byte) (archiveKey, error) {
+			var block struct {
+				Header archivedHeader `json:"header"`
+			}
+			err := json.Unmarshal(record, &block)
+			return archiveKey{number: block.Header.Number, hash: block.Header.Hash}, err
+		},
+		encode: encodeLine,
+		decode: json.Unmarshal,
+	}
+	jsonHeaderCodec = archiveCodec{
+		next: nextLine,
+		key: func(record []byte) (archiveKey, error) {
+			var header archivedHeader
+			err := json.Unmarshal(record, &header)
+			return archiveKey{number: header.Number, hash: header.Hash}, err
+		},
+		encode: encodeLine,
+		decode: json.Unmarshal,
+	}
+	jsonTransactionCodec = archiveCodec{
+		next: nextLine,
+		key: func(record []byte) (archiveKey, error) {
+			var tx struct {
+				Transaction struct {
+					Hash ckbTypes.Hash `json:"hash"`
+				} `json:"transaction"`
+			}
+			err := json.Unmarshal(record, &tx)
+			return archiveKey{hash: tx.Transaction.Hash}, err
+		},
+		encode: encodeLine,
+		decode: json.Unmarshal,
+	}
+	moleculeBlockCodec = archiveCodec{
+		next: func(reader *bufio.Reader) ([]byte, error) {
+			return nextMolecule(reader, 0)
+		},
+		key: func(record []byte) (archiveKey, error) {
+			header, err := decodeBlockHeader(record)
+			if err != nil {
+				return archiveKey{}, err
+			}
+			return archiveKey{number: header.Number, hash: header.Hash}, nil
+		},
+		encode: func(v any) ([]byte, error) {
+			return encodeBlock(v.(*ckbTypes.Block))
+		},
+		decode: func(record []byte, v any) error {
+			block, err := decodeBlock(record)
+			if err != nil {
+				return err
+			}
+			*v.(*ckbTypes.Block) = *block
+			return nil
+		},
+	}
+	moleculeTransactionCodec = archiveCodec{
+		next: func(reader *bufio.Reader) ([]byte, error) {
+			return nextMolecule(reader, ckbTypes.HashLength)
+		},
+		key: func(record []byte) (archiveKey, error) {
+			hash, err := blake2b.Blake256(blockchain.TransactionFromSliceUnchecked(record[ckbTypes.HashLength:]).Raw().AsSlice())
+			return archiveKey{hash: ckbTypes.BytesToHash(hash)}, err
+		},
+		encode: func(v any) ([]byte, error) {
+			tx := v.(*ckbTypes.TransactionWithStatus)
+			if tx.TxStatus == nil || tx.TxStatus.BlockHash == nil {
+				return nil, fmt.Errorf("transaction %s is not committed", tx.Transaction.Hash.String())
+			}
+			encoded, err := encodeTransaction(tx.Transaction)
+			if err != nil {
+				return nil, err
+			}
+			return append(tx.TxStatus.BlockHash.Bytes(), encoded...), nil
+		},
+		decode: func(record []byte, v any) error {
+			tx, err := decodeTransaction(record[ckbTypes.HashLength:])
+			if err != nil {
+				return err
+			}
+			blockHash := ckbTypes.BytesToHash(record[:ckbTypes.HashLength])
+			*v.(*ckbTypes.TransactionWithStatus) = ckbTypes.TransactionWithStatus{
+				Transaction: tx,
+				TxStatus:    &ckbTypes.TxStatus{BlockHash: &blockHash, Status: ckbTypes.TransactionStatusCommitted},
+			}
+			return nil
+		},
+	}
+)
+
+type archivedHeader struct {
+	Number uint64        `json:"number"`
+	Hash   ckbTypes.Hash `json:"hash"`
+}
+
+func nextLine(reader *bufio.Reader) ([]byte, error) {
+	line, err := reader.ReadBytes('\n')
+	if errors.Is(err, io.EOF) && len(line) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return line, err
+}
+
+func encodeLine(v any) ([]byte, error) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// nextMolecule reads a record of prefix bytes followed by a molecule value starting with its total size
+func nextMolecule(reader *bufio.Reader, prefix int) ([]byte, error) {
+	head, err := reader.Peek(prefix + 4)
+	if errors.Is(err, io.EOF) && len(head) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, prefix+int(binary.LittleEndian.Uint32(head[prefix:])))
+	if _, err = io.ReadFull(reader, record); errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return record, err
+}
+
+type blockArchive struct {
+	dir          string
+	mu           sync.RWMutex
+	blocksFile   *archiveFile
+	headersFile  *archiveFile
+	txsFile      *archiveFile
+	chain        *ckbTypes.BlockchainInfo
+	blocks       map[uint64]archiveRecord
+	blockHashes  map[uint64]ckbTypes.Hash
+	blockNumbers map[ckbTypes.Hash]uint64
+	headers      map[ckbTypes.Hash]archiveRecord
+	headerHashes map[uint64]ckbTypes.Hash
+	transactions map[ckbTypes.Hash]archiveRecord
+	tip          uint64
+}
+
+// openBlockArchive indexes the archive in dir. For reading the format is detected from the blocks file, for recording
+// the archive is created in the given format when missing and a record cut off by a crash is dropped.
+func openBlockArchive(dir string, format string, writable bool) (*blockArchive, error) {
+	if dir == "" {
+		return nil, errors.New("ckb_node.archive_dir is not configured")
+	}
+	if writable {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	} else if _, err := os.Stat(filepath.Join(dir, archiveMoleculeBlocksFile)); err == nil {
+		format = config.MoleculeArchiveFormat
+	}
+	a := &blockArchive{
+		dir:          dir,
+		blocksFile:   &archiveFile{name: archiveBlocksFile, codec: jsonBlockCodec},
+		headersFile:  &archiveFile{name: archiveHeadersFile, codec: jsonHeaderCodec},
+		txsFile:      &archiveFile{name: archiveTransactionsFile, codec: jsonTransactionCodec},
+		blocks:       make(map[uint64]archiveRecord),
+		blockHashes:  make(map[uint64]ckbTypes.Hash),
+		blockNumbers: make(map[ckbTypes.Hash]uint64),
+		headers:      make(map[ckbTypes.Hash]archiveRecord),
+		headerHashes: make(map[uint64]ckbTypes.Hash),
+		transactions: make(map[ckbTypes.Hash]archiveRecord),
+	}
+	switch format {
+	case "", config.JsonArchiveFormat:
+	case config.MoleculeArchiveFormat:
+		a.blocksFile = &archiveFile{name: archiveMoleculeBlocksFile, codec: moleculeBlockCodec}
+		a.txsFile = &archiveFile{name: archiveMoleculeTransactionsFile, codec: moleculeTransactionCodec}
+	default:
+		return nil, fmt.Errorf("unknown archive format %s", format)
+	}
+	chain, err := os.ReadFile(filepath.Join(dir, archiveChainFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(chain) > 0 {
+		a.chain = &ckbTypes.BlockchainInfo{}
+		if err = json.Unmarshal(chain, a.chain); err != nil {
+			return nil, fmt.Errorf("%s: %w", archiveChainFile, err)
+		}
+	}
+	indexes := map[*archiveFile]func(key archiveKey, record archiveRecord){
+		a.blocksFile:  a.indexBlock,
+		a.headersFile: a.indexHeader,
+		a.txsFile:     a.indexTransaction,
+	}
+	for file, index := range indexes {
+		if err = a.openFile(file, writable, index); err != nil {
+			a.close()
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func (a *blockArchive) openFile(f *archiveFile, writable bool, index func(key archiveKey, record archiveRecord)) error {
+	flag := os.O_RDONLY
+	if writable {
+		flag = os.O_RDWR | os.O_CREATE
+	}
+	file, err := os.OpenFile(filepath.Join(a.dir, f.name), flag, 0o644)
+	if errors.Is(err, os.ErrNotExist) && f != a.blocksFile {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f.file = file
+	reader := bufio.NewReader(file)
+	for {
+		record, err := f.codec.next(reader)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		key, err := f.codec.key(record)
+		if err != nil {
+			return fmt.Errorf("%s at offset %d: %w", f.name, f.size, err)
+		}
+		index(key, archiveRecord{offset: f.size, length: len(record)})
+		f.size += int64(len(record))
+	}
+	if writable {
+		return file.Truncate(f.size)
+	}
+	return nil
+}
+
+func (a *blockArchive) indexBlock(key archiveKey, record archiveRecord) {
+	a.blocks[key.number] = record
+	a.blockHashes[key.number] = key.hash
+	a.blockNumbers[key.hash] = key.number
+	if key.number > a.tip {
+		a.tip = key.number
+	}
+}
+
+func (a *blockArchive) indexHeader(key archiveKey, record archiveRecord) {
+	a.headers[key.hash] = record
+	a.headerHashes[key.number] = key.hash
+}
+
+func (a *blockArchive) indexTransaction(key archiveKey, record archiveRecord) {
+	a.transactions[key.hash] = record
+}
+
+func (a *blockArchive) read(f *archiveFile, record archiveRecord, v any) error {
+	content := make([]byte, record.length)
+	if _, err := f.file.ReadAt(content, record.offset); err != nil {
+		return err
+	}
+	return f.codec.decode(content, v)
+}
+
+// append writes v as a new record of the file and indexes it
+func (a *blockArchive) append(f *archiveFile, v any, index func(key archiveKey, record archiveRecord)) error {
+	if f.file == nil {
+		return fmt.Errorf("%s is not opened for recording", f.name)
+	}
+	content, err := f.codec.encode(v)
+	if err != nil {
+		return err
+	}
+	key, err := f.codec.key(content)
+	if err != nil {
+		return err
+	}
+	if _, err = f.file.WriteAt(content, f.size); err != nil {
+		return err
+	}
+	index(key, archiveRecord{offset: f.size, length: len(content)})
+	f.size += int64(len(content))
+	return nil
+}
+
+func (a *blockArchive) chainInfo() (*ckbTypes.BlockchainInfo, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.chain == nil {
+		return nil, fmt.Errorf("chain info %w", errNotArchived)
+	}
+	return a.chain, nil
+}
+
+func (a *blockArchive) tipBlockNumber() uint64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.tip
+}
+
+func (a *blockArchive) block(number uint64) (*ckbTypes.Block, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	record, ok := a.blocks[number]
+	if !ok {
+		return nil, fmt.Errorf("block %d %w", number, errNotArchived)
+	}
+	var block ckbTypes.Block
+	if err := a.read(a.blocksFile, record, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+func (a *blockArchive) blockHash(number uint64) (*ckbTypes.Hash, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if hash, ok := a.blockHashes[number]; ok {
+		return &hash, nil
+	}
+	if hash, ok := a.headerHashes[number]; ok {
+		return &hash, nil
+	}
+	return nil, fmt.Errorf("block hash %d %w", number, errNotArchived)
+}
+
+func (a *blockArchive) header(hash ckbTypes.Hash) (*ckbTypes.Header, error) {
+	a.mu.RLock()
+	number, ok := a.blockNumbers[hash]
+	a.mu.RUnlock()
+	if ok {
+		block, err := a.block(number)
+		if err != nil {
+			return nil, err
+		}
+		return block.Header, nil
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	record, ok := a.headers[hash]
+	if !ok {
+		return nil, fmt.Errorf("header %s %w", hash.String(), errNotArchived)
+	}
+	var header ckbTypes.Header
+	if err := a.read(a.headersFile, record, &header); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+func (a *blockArchive) transaction(hash ckbTypes.Hash) (*ckbTypes.TransactionWithStatus, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	record, ok := a.transactions[hash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s %w", hash.String(), errNotArchived)
+	}
+	var tx ckbTypes.TransactionWithStatus
+	if err := a.read(a.txsFile, record, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (a *blockArchive) setChainInfo(info *ckbTypes.BlockchainInfo) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	content, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(a.dir, archiveChainFile), content, 0o644); err != nil {
+		return err
+	}
+	a.chain = info
+	return nil
+}
+
+func (a *blockArchive) addBlock(block *ckbTypes.Block) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if hash, ok := a.blockHashes[block.Header.Number]; ok && hash == block.Header.Hash {
+		return nil
+	}
+	return a.append(a.blocksFile, block, a.indexBlock)
+}
+
+func (a *blockArchive) addHeader(header *ckbTypes.Header) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.blockNumbers[header.Hash]; ok {
+		return nil
+	}
+	if _, ok := a.headers[header.Hash]; ok {
+		return nil
+	}
+	return a.append(a.headersFile, header, a.indexHeader)
+}
+
+// addTransaction skips the transactions not committed yet, they are never resolved as previous transactions
+func (a *blockArchive) addTransaction(tx *ckbTypes.TransactionWithStatus) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if tx.TxStatus == nil || tx.TxStatus.BlockHash == nil {
+		return nil
+	}
+	if _, ok := a.transactions[tx.Transaction.Hash]; ok {
+		return nil
+	}
+	return a.append(a.txsFile, tx, a.indexTransaction)
+}
+
+func (a *blockArchive) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, f := range []*archiveFile{a.blocksFile, a.headersFile, a.txsFile} {
+		if f.file != nil {
+			f.file.Close()
+		}
+	}
+}
+
+var _ BlockSource = (*fileBlockSource)(nil)
+
+// fileBlockSource replays a block archive, its tip is the highest archived block
+type fileBlockSource struct {
+	archive *blockArchive
+}
+
+func newFileBlockSource(dir string) (*fileBlockSource, error) {
+	archive, err := openBlockArchive(dir, "", false)
+	if err != nil {
+		return nil, err
+	}
+	return &fileBlockSource{archive: archive}, nil
+}
+
+func (s *fileBlockSource) GetBlockchainInfo(context.Context) (*ckbTypes.BlockchainInfo, error) {
+	return s.archive.chainInfo()
+}
+
+func (s *fileBlockSource) GetTipBlockNumber(context.Context) (uint64, error) {
+	return s.archive.tipBlockNumber(), nil
+}
+
+func (s *fileBlockSource) GetBlockByNumber(_ context.Context, number uint64) (*ckbTypes.Block, error) {
+	return s.archive.block(number)
+}
+
+func (s *fileBlockSource) GetBlockHash(_ context.Context, number uint64) (*ckbTypes.Hash, error) {
+	return s.archive.blockHash(number)
+}
+
+func (s *fileBlockSource) GetHeader(_ context.Context, hash ckbTypes.Hash) (*ckbTypes.Header, error) {
+	return s.archive.header(hash)
+}
+
+func (s *fileBlockSource) GetHeaderByNumber(_ context.Context, number uint64) (*ckbTypes.Header, error) {
+	hash, err := s.archive.blockHash(number)
+	if err != nil {
+		return nil, err
+	}
+	return s.archive.header(*hash)
+}
+
+func (s *fileBlockSource) GetTransaction(_ context.Context, hash ckbTypes.Hash) (*ckbTypes.TransactionWithStatus, error) {
+	return s.archive.transaction(hash)
+}
+
+func (s *fileBlockSource) BatchTransactions(_ context.Context, batch []ckbTypes.BatchTransactionItem) error {
+	for i := range batch {
+		batch[i].Result, batch[i].Error = s.archive.transaction(batch[i].Hash)
+	}
+	return nil
+}
+
+func (s *fileBlockSource) GetTip(context.Context) (*indexer.TipHeader, error) {
+	return nil, errIndexerNotArchived
+}
+
+func (s *fileBlockSource) GetTransactions(context.Context, *indexer.SearchKey, indexer.SearchOrder, uint64, string) (*indexer.Transactions, error) {
+	return nil, errIndexerNotArchived
+}
+
+func (s *fileBlockSource) Close() {
+	s.archive.close()
+}
+
+// recordingBlockSource passes the calls to the node and writes everything the syncer reads into a block archive
+type recordingBlockSource struct {
+	BlockSource
+	archive *blockArchive
+}
+
+func newRecordingBlockSource(source BlockSource, dir string, format string) (*recordingBlockSource, error) {
+	archive, err := openBlockArchive(dir, format, true)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingBlockSource{BlockSource: source, archive: archive}, nil
+}
+
+func (s *recordingBlockSource) GetBlockchainInfo(ctx context.Context) (*ckbTypes.BlockchainInfo, error) {
+	info, err := s.BlockSource.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return info, s.archive.setChainInfo(info)
+}
+
+func (s *recordingBlockSource) GetBlockByNumber(ctx context.Context, number uint64) (*ckbTypes.Block, error) {
+	block, err := s.BlockSource.GetBlockByNumber(ctx, number)
+	if err != nil || block == nil {
+		return block, err
+	}
+	return block, s.archive.addBlock(block)
+}
+
+// GetBlockHash reads the whole header so that a replay can look the hash up as well
+func (s *recordingBlockSource) GetBlockHash(ctx context.Context, number uint64) (*ckbTypes.Hash, error) {
+	header, err := s.GetHeaderByNumber(ctx, number)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	return &header.Hash, nil
+}
+
+func (s *recordingBlockSource) GetHeader(ctx context.Context, hash ckbTypes.Hash) (*ckbTypes.Header, error) {
+	header, err := s.BlockSource.GetHeader(ctx, hash)
+	if err != nil || header == nil {
+		return header, err
+	}
+	return header, s.archive.addHeader(header)
+}
+
+func (s *recordingBlockSource) GetHeaderByNumber(ctx context.Context, number uint64) (*ckbTypes.Header, error) {
+	header, err := s.BlockSource.GetHeaderByNumber(ctx, number)
+	if err != nil || header == nil {
+		return header, err
+	}
+	return header, s.archive.addHeader(header)
+}
+
+func (s *recordingBlockSource) GetTransaction(ctx context.Context, hash ckbTypes.Hash) (*ckbTypes.TransactionWithStatus, error) {
+	tx, err := s.BlockSource.GetTransaction(ctx, hash)
+	if err != nil || tx == nil || tx.Transaction == nil {
+		return tx, err
+	}
+	return tx, s.archive.addTransaction(tx)
+}
+
+func (s *recordingBlockSource) BatchTransactions(ctx context.Context, batch []ckbTypes.BatchTransactionItem) error {
+	if err := s.BlockSource.BatchTransactions(ctx, batch); err != nil {
+		return err
+	}
+	for _, item := range batch {
+		if item.Error != nil || item.Result == nil || item.Result.Transaction == nil {
+			continue
+		}
+		if err := s.archive.addTransaction(item.Result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *recordingBlockSource) Close() {
+	s.BlockSource.Close()
+	s.archive.close()
+}
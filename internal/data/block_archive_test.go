@@ -0,0 +1,202 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// archiveNode serves the blocks of a chain and the transactions spent by them
+type archiveNode struct {
+	rpc.Client
+	blocks map[uint64]*ckbTypes.Block
+	txs    map[ckbTypes.Hash]*ckbTypes.TransactionWithStatus
+}
+
+func (n archiveNode) GetBlockByNumber(_ context.Context, blockNumber uint64) (*ckbTypes.Block, error) {
+	return n.blocks[blockNumber], nil
+}
+
+func (n archiveNode) GetHeaderByNumber(_ context.Context, blockNumber uint64) (*ckbTypes.Header, error) {
+	return &ckbTypes.Header{Number: blockNumber, Hash: blockHash(blockNumber), Nonce: big.NewInt(1)}, nil
+}
+
+func (n archiveNode) GetTransaction(_ context.Context, hash ckbTypes.Hash) (*ckbTypes.TransactionWithStatus, error) {
+	return n.txs[hash], nil
+}
+
+func (n archiveNode) Close() {}
+
+// archiveBlock returns a block with the hashes a node would report
+func archiveBlock(blockNumber uint64, salt byte) *ckbTypes.Block {
+	tx := &ckbTypes.Transaction{
+		CellDeps:   []*ckbTypes.CellDep{},
+		HeaderDeps: []ckbTypes.Hash{},
+		Inputs: []*ckbTypes.CellInput{{
+			PreviousOutput: &ckbTypes.OutPoint{TxHash: blockHash(blockNumber - 1), Index: 1},
+		}},
+		Outputs: []*ckbTypes.CellOutput{{
+			Capacity: 61_00000000,
+			Lock:     &ckbTypes.Script{CodeHash: blockHash(7), HashType: ckbTypes.HashTypeType, Args: []byte{salt}},
+		}},
+		OutputsData: [][]byte{{0x01, salt}},
+		Witnesses:   [][]byte{{0x02, salt}},
+	}
+	raw, err := encodeBlock(&ckbTypes.Block{
+		Header: &ckbTypes.Header{
+			Number:     blockNumber,
+			ParentHash: blockHash(blockNumber - 1),
+			Nonce:      big.NewInt(int64(salt) + 1),
+		},
+		Proposals:    []string{},
+		Transactions: []*ckbTypes.Transaction{tx},
+		Uncles:       []*ckbTypes.UncleBlock{},
+	})
+	if err != nil {
+		panic(err)
+	}
+	block, err := decodeBlock(raw)
+	if err != nil {
+		panic(err)
+	}
+	return block
+}
+
+func Test_blockArchive(t *testing.T) {
+	for _, format := range []string{config.JsonArchiveFormat, config.MoleculeArchiveFormat} {
+		t.Run(format, func(t *testing.T) {
+			testBlockArchive(t, format)
+		})
+	}
+}
+
+func testBlockArchive(t *testing.T, format string) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	previousTx := &ckbTypes.TransactionWithStatus{
+		Transaction: archiveBlock(9, 0).Transactions[0],
+		TxStatus:    &ckbTypes.TxStatus{Status: ckbTypes.TransactionStatusCommitted, BlockHash: &ckbTypes.Hash{9}},
+	}
+	node := archiveNode{
+		blocks: map[uint64]*ckbTypes.Block{10: archiveBlock(10, 0), 11: archiveBlock(11, 0)},
+		txs:    map[ckbTypes.Hash]*ckbTypes.TransactionWithStatus{previousTx.Transaction.Hash: previousTx},
+	}
+
+	recorder, err := newRecordingBlockSource(node, dir, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, blockNumber := range []uint64{10, 11, 11} {
+		if _, err = recorder.GetBlockByNumber(ctx, blockNumber); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = recorder.GetTransaction(ctx, previousTx.Transaction.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = recorder.GetBlockHash(ctx, 9); err != nil {
+		t.Fatal(err)
+	}
+	// block 11 is replaced by a reorg, the replay only sees the new one
+	node.blocks[11] = archiveBlock(11, 1)
+	if _, err = recorder.GetBlockByNumber(ctx, 11); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Close()
+
+	// a record cut off by a crash is dropped when recording resumes
+	blocks, err := os.OpenFile(filepath.Join(dir, recorder.archive.blocksFile.name), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = blocks.Write([]byte{0xff, 0x01, 0x00, 0x00, 0x7b}); err != nil {
+		t.Fatal(err)
+	}
+	blocks.Close()
+	recorder, err = newRecordingBlockSource(node, dir, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Close()
+
+	source, err := newFileBlockSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	tests := []struct {
+		name    string
+		call    func() (any, error)
+		want    any
+		wantErr error
+	}{
+		{
+			name: "should return the highest archived block as tip",
+			call: func() (any, error) { return source.GetTipBlockNumber(ctx) },
+			want: uint64(11),
+		},
+		{
+			name: "should replay the recorded block",
+			call: func() (any, error) { return source.GetBlockByNumber(ctx, 10) },
+			want: node.blocks[10],
+		},
+		{
+			name: "should replay the block recorded last",
+			call: func() (any, error) { return source.GetBlockByNumber(ctx, 11) },
+			want: node.blocks[11],
+		},
+		{
+			name: "should replay the previous transaction",
+			call: func() (any, error) { return source.GetTransaction(ctx, previousTx.Transaction.Hash) },
+			want: previousTx,
+		},
+		{
+			name: "should replay the hash of a recorded header",
+			call: func() (any, error) {
+				hash, err := source.GetBlockHash(ctx, 9)
+				return *hash, err
+			},
+			want: blockHash(9),
+		},
+		{
+			name: "should return the header of a recorded block",
+			call: func() (any, error) { return source.GetHeaderByNumber(ctx, 11) },
+			want: node.blocks[11].Header,
+		},
+		{
+			name:    "should fail for a block not in the archive",
+			call:    func() (any, error) { return source.GetBlockByNumber(ctx, 12) },
+			wantErr: errNotArchived,
+		},
+		{
+			name:    "should fail for a transaction not in the archive",
+			call:    func() (any, error) { return source.GetTransaction(ctx, blockHash(8)) },
+			wantErr: errNotArchived,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package data
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/blockchain"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// The molecule encoding of blocks and transactions, as returned by the node with verbosity 0. Decoding yields the same
+// ckb-sdk-go types as the json rpc, including the hashes the node adds to the json.
+
+var scriptHashTypes = []ckbTypes.ScriptHashType{ckbTypes.HashTypeData, ckbTypes.HashTypeType, ckbTypes.HashTypeData1}
+
+var depTypes = []ckbTypes.DepType{ckbTypes.DepTypeCode, ckbTypes.DepTypeDepGroup}
+
+// decodeBlock accepts a Block or a BlockV1, the extension is dropped like in the json rpc client
+func decodeBlock(raw []byte) (*ckbTypes.Block, error) {
+	block, err := blockchain.BlockFromSlice(raw, true)
+	if err != nil {
+		return nil, err
+	}
+	header, err := decodeHeader(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	uncleVec := block.Uncles()
+	uncles := make([]*ckbTypes.UncleBlock, uncleVec.Len())
+	for i := range uncles {
+		uncle := uncleVec.Get(uint(i))
+		if uncles[i], err = decodeUncle(uncle); err != nil {
+			return nil, err
+		}
+	}
+	txVec := block.Transactions()
+	txs := make([]*ckbTypes.Transaction, txVec.Len())
+	for i := range txs {
+		if txs[i], err = decodeMoleculeTransaction(txVec.Get(uint(i))); err != nil {
+			return nil, err
+		}
+	}
+	return &ckbTypes.Block{
+		Header:       header,
+		Proposals:    decodeProposals(block.Proposals()),
+		Transactions: txs,
+		Uncles:       uncles,
+	}, nil
+}
+
+// decodeBlockHeader decodes the header of an encoded block without the rest of the block
+func decodeBlockHeader(raw []byte) (*ckbTypes.Header, error) {
+	if len(raw) < 12 {
+		return nil, fmt.Errorf("block of %d bytes is too short", len(raw))
+	}
+	start, end := binary.LittleEndian.Uint32(raw[4:]), binary.LittleEndian.Uint32(raw[8:])
+	if start > end || uint32(len(raw)) < end {
+		return nil, fmt.Errorf("block of %d bytes is broken", len(raw))
+	}
+	header, err := blockchain.HeaderFromSlice(raw[start:end], false)
+	if err != nil {
+		return nil, err
+	}
+	return decodeHeader(header)
+}
+
+func decodeTransaction(raw []byte) (*ckbTypes.Transaction, error) {
+	tx, err := blockchain.TransactionFromSlice(raw, true)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMoleculeTransaction(tx)
+}
+
+func decodeHeader(header *blockchain.Header) (*ckbTypes.Header, error) {
+	hash, err := blake2b.Blake256(header.AsSlice())
+	if err != nil {
+		return nil, err
+	}
+	raw := header.Raw()
+	return &ckbTypes.Header{
+		CompactTarget:    uint(decodeUint32(raw.CompactTarget())),
+		Dao:              ckbTypes.BytesToHash(raw.Dao().RawData()),
+		Epoch:            decodeUint64(raw.Epoch()),
+		Hash:             ckbTypes.BytesToHash(hash),
+		Nonce:            decodeUint128(header.Nonce()),
+		Number:           decodeUint64(raw.Number()),
+		ParentHash:       ckbTypes.BytesToHash(raw.ParentHash().RawData()),
+		ProposalsHash:    ckbTypes.BytesToHash(raw.ProposalsHash().RawData()),
+		Timestamp:        decodeUint64(raw.Timestamp()),
+		TransactionsRoot: ckbTypes.BytesToHash(raw.TransactionsRoot().RawData()),
+		ExtraHash:        ckbTypes.BytesToHash(raw.ExtraHash().RawData()),
+		Version:          uint(decodeUint32(raw.Version())),
+	}, nil
+}
+
+func decodeUncle(uncle *blockchain.UncleBlock) (*ckbTypes.UncleBlock, error) {
+	header, err := decodeHeader(uncle.Header())
+	if err != nil {
+		return nil, err
+	}
+	return &ckbTypes.UncleBlock{Header: header, Proposals: decodeProposals(uncle.Proposals())}, nil
+}
+
+func decodeProposals(proposalVec *blockchain.ProposalShortIdVec) []string {
+	proposals := make([]string, proposalVec.Len())
+	for i := range proposals {
+		proposals[i] = "0x" + hex.EncodeToString(proposalVec.Get(uint(i)).RawData())
+	}
+	return proposals
+}
+
+func decodeMoleculeTransaction(tx *blockchain.Transaction) (*ckbTypes.Transaction, error) {
+	raw := tx.Raw()
+	hash, err := blake2b.Blake256(raw.AsSlice())
+	if err != nil {
+		return nil, err
+	}
+	depVec := raw.CellDeps()
+	cellDeps := make([]*ckbTypes.CellDep, depVec.Len())
+	for i := range cellDeps {
+		dep := depVec.Get(uint(i))
+		depType := int(dep.DepType().AsSlice()[0])
+		if depType >= len(depTypes) {
+			return nil, fmt.Errorf("unknown dep type %d", depType)
+		}
+		cellDeps[i] = &ckbTypes.CellDep{OutPoint: decodeOutPoint(dep.OutPoint()), DepType: depTypes[depType]}
+	}
+	headerDepVec := raw.HeaderDeps()
+	headerDeps := make([]ckbTypes.Hash, headerDepVec.Len())
+	for i := range headerDeps {
+		headerDeps[i] = ckbTypes.BytesToHash(headerDepVec.Get(uint(i)).RawData())
+	}
+	inputVec := raw.Inputs()
+	inputs := make([]*ckbTypes.CellInput, inputVec.Len())
+	for i := range inputs {
+		input := inputVec.Get(uint(i))
+		inputs[i] = &ckbTypes.CellInput{Since: decodeUint64(input.Since()), PreviousOutput: decodeOutPoint(input.PreviousOutput())}
+	}
+	outputVec := raw.Outputs()
+	outputs := make([]*ckbTypes.CellOutput, outputVec.Len())
+	for i := range outputs {
+		output := outputVec.Get(uint(i))
+		outputs[i] = &ckbTypes.CellOutput{Capacity: decodeUint64(output.Capacity())}
+		if outputs[i].Lock, err = decodeScript(output.Lock()); err != nil {
+			return nil, err
+		}
+		if typeScript, err := output.Type().IntoScript(); err == nil {
+			if outputs[i].Type, err = decodeScript(typeScript); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &ckbTypes.Transaction{
+		Version:     uint(decodeUint32(raw.Version())),
+		Hash:        ckbTypes.BytesToHash(hash),
+		CellDeps:    cellDeps,
+		HeaderDeps:  headerDeps,
+		Inputs:      inputs,
+		Outputs:     outputs,
+		OutputsData: decodeBytesVec(raw.OutputsData()),
+		Witnesses:   decodeBytesVec(tx.Witnesses()),
+	}, nil
+}
+
+func decodeScript(script *blockchain.Script) (*ckbTypes.Script, error) {
+	hashType := int(script.HashType().AsSlice()[0])
+	if hashType >= len(scriptHashTypes) {
+		return nil, fmt.Errorf("unknown script hash type %d", hashType)
+	}
+	return &ckbTypes.Script{
+		CodeHash: ckbTypes.BytesToHash(script.CodeHash().RawData()),
+		HashType: scriptHashTypes[hashType],
+		Args:     append([]byte{}, script.Args().RawData()...),
+	}, nil
+}
+
+func decodeOutPoint(outPoint *blockchain.OutPoint) *ckbTypes.OutPoint {
+	return &ckbTypes.OutPoint{
+		TxHash: ckbTypes.BytesToHash(outPoint.TxHash().RawData()),
+		Index:  uint(decodeUint32(outPoint.Index())),
+	}
+}
+
+// decodeBytesVec copies the items so that the decoded block does not keep the whole encoded block alive
+func decodeBytesVec(vec *blockchain.BytesVec) [][]byte {
+	items := make([][]byte, vec.Len())
+	for i := range items {
+		items[i] = append([]byte{}, vec.Get(uint(i)).RawData()...)
+	}
+	return items
+}
+
+func decodeUint32(v *blockchain.Uint32) uint32 {
+	return binary.LittleEndian.Uint32(v.RawData())
+}
+
+func decodeUint64(v *blockchain.Uint64) uint64 {
+	return binary.LittleEndian.Uint64(v.RawData())
+}
+
+func decodeUint128(v *blockchain.Uint128) *big.Int {
+	le := v.RawData()
+	be := make([]byte, len(le))
+	for i := range le {
+		be[len(le)-1-i] = le[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// encodeBlock encodes a block without extension, the hashes are not part of the encoding
+func encodeBlock(block *ckbTypes.Block) ([]byte, error) {
+	header, err := encodeHeader(block.Header)
+	if err != nil {
+		return nil, err
+	}
+	uncles := make([]blockchain.UncleBlock, len(block.Uncles))
+	for i, uncle := range block.Uncles {
+		uncleHeader, err := encodeHeader(uncle.Header)
+		if err != nil {
+			return nil, err
+		}
+		proposals, err := encodeProposals(uncle.Proposals)
+		if err != nil {
+			return nil, err
+		}
+		uncles[i] = blockchain.NewUncleBlockBuilder().Header(uncleHeader).Proposals(proposals).Build()
+	}
+	txs := make([]blockchain.Transaction, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		if txs[i], err = encodeMoleculeTransaction(tx); err != nil {
+			return nil, err
+		}
+	}
+	proposals, err := encodeProposals(block.Proposals)
+	if err != nil {
+		return nil, err
+	}
+	encoded := blockchain.NewBlockBuilder().
+		Header(header).
+		Uncles(blockchain.NewUncleBlockVecBuilder().Set(uncles).Build()).
+		Transactions(blockchain.NewTransactionVecBuilder().Set(txs).Build()).
+		Proposals(proposals).
+		Build()
+	return encoded.AsSlice(), nil
+}
+
+func encodeTransaction(tx *ckbTypes.Transaction) ([]byte, error) {
+	encoded, err := encodeMoleculeTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	return encoded.AsSlice(), nil
+}
+
+func encodeHeader(header *ckbTypes.Header) (blockchain.Header, error) {
+	if header.Nonce == nil || header.Nonce.Sign() < 0 || header.Nonce.BitLen() > 128 {
+		return blockchain.Header{}, fmt.Errorf("invalid nonce of header %d", header.Number)
+	}
+	nonce := make([]byte, 16)
+	header.Nonce.FillBytes(nonce)
+	for i, j := 0, len(nonce)-1; i < j; i, j = i+1, j-1 {
+		nonce[i], nonce[j] = nonce[j], nonce[i]
+	}
+	raw := blockchain.NewRawHeaderBuilder().
+		Version(*blockchain.Uint32FromSliceUnchecked(encodeUint32(uint32(header.Version)))).
+		CompactTarget(*blockchain.Uint32FromSliceUnchecked(encodeUint32(uint32(header.CompactTarget)))).
+		Timestamp(*blockchain.Uint64FromSliceUnchecked(encodeUint64(header.Timestamp))).
+		Number(*blockchain.Uint64FromSliceUnchecked(encodeUint64(header.Number))).
+		Epoch(*blockchain.Uint64FromSliceUnchecked(encodeUint64(header.Epoch))).
+		ParentHash(*blockchain.Byte32FromSliceUnchecked(header.ParentHash.Bytes())).
+		TransactionsRoot(*blockchain.Byte32FromSliceUnchecked(header.TransactionsRoot.Bytes())).
+		ProposalsHash(*blockchain.Byte32FromSliceUnchecked(header.ProposalsHash.Bytes())).
+		ExtraHash(*blockchain.Byte32FromSliceUnchecked(header.ExtraHash.Bytes())).
+		Dao(*blockchain.Byte32FromSliceUnchecked(header.Dao.Bytes())).
+		Build()
+	return blockchain.NewHeaderBuilder().Raw(raw).Nonce(*blockchain.Uint128FromSliceUnchecked(nonce)).Build(), nil
+}
+
+func encodeProposals(proposals []string) (blockchain.ProposalShortIdVec, error) {
+	ids := make([]blockchain.ProposalShortId, len(proposals))
+	for i, proposal := range proposals {
+		id, err := hex.DecodeString(strings.TrimPrefix(proposal, "0x"))
+		if err != nil {
+			return blockchain.ProposalShortIdVec{}, err
+		}
+		shortId, err := blockchain.ProposalShortIdFromSlice(id, false)
+		if err != nil {
+			return blockchain.ProposalShortIdVec{}, err
+		}
+		ids[i] = *shortId
+	}
+	return blockchain.NewProposalShortIdVecBuilder().Set(ids).Build(), nil
+}
+
+func encodeMoleculeTransaction(tx *ckbTypes.Transaction) (blockchain.Transaction, error) {
+	cellDeps := make([]blockchain.CellDep, len(tx.CellDeps))
+	for i, dep := range tx.CellDeps {
+		depType := indexOf(depTypes, dep.DepType)
+		if depType < 0 {
+			return blockchain.Transaction{}, fmt.Errorf("unknown dep type %s", dep.DepType)
+		}
+		cellDeps[i] = blockchain.NewCellDepBuilder().OutPoint(encodeOutPoint(dep.OutPoint)).DepType(blockchain.NewByte(byte(depType))).Build()
+	}
+	headerDeps := make([]blockchain.Byte32, len(tx.HeaderDeps))
+	for i, hash := range tx.HeaderDeps {
+		headerDeps[i] = *blockchain.Byte32FromSliceUnchecked(hash.Bytes())
+	}
+	inputs := make([]blockchain.CellInput, len(tx.Inputs))
+	for i, input := range tx.Inputs {
+		inputs[i] = blockchain.NewCellInputBuilder().
+			Since(*blockchain.Uint64FromSliceUnchecked(encodeUint64(input.Since))).
+			PreviousOutput(encodeOutPoint(input.PreviousOutput)).
+			Build()
+	}
+	outputs := make([]blockchain.CellOutput, len(tx.Outputs))
+	for i, output := range tx.Outputs {
+		lock, err := encodeScript(output.Lock)
+		if err != nil {
+			return blockchain.Transaction{}, err
+		}
+		typeScript := blockchain.NewScriptOptBuilder()
+		if output.Type != nil {
+			script, err := encodeScript(output.Type)
+			if err != nil {
+				return blockchain.Transaction{}, err
+			}
+			typeScript.Set(script)
+		}
+		outputs[i] = blockchain.NewCellOutputBuilder().
+			Capacity(*blockchain.Uint64FromSliceUnchecked(encodeUint64(output.Capacity))).
+			Lock(lock).
+			Type(typeScript.Build()).
+			Build()
+	}
+	raw := blockchain.NewRawTransactionBuilder().
+		Version(*blockchain.Uint32FromSliceUnchecked(encodeUint32(uint32(tx.Version)))).
+		CellDeps(blockchain.NewCellDepVecBuilder().Set(cellDeps).Build()).
+		HeaderDeps(blockchain.NewByte32VecBuilder().Set(headerDeps).Build()).
+		Inputs(blockchain.NewCellInputVecBuilder().Set(inputs).Build()).
+		Outputs(blockchain.NewCellOutputVecBuilder().Set(outputs).Build()).
+		OutputsData(encodeBytesVec(tx.OutputsData)).
+		Build()
+	return blockchain.NewTransactionBuilder().Raw(raw).Witnesses(encodeBytesVec(tx.Witnesses)).Build(), nil
+}
+
+func encodeScript(script *ckbTypes.Script) (blockchain.Script, error) {
+	hashType := indexOf(scriptHashTypes, script.HashType)
+	if hashType < 0 {
+		return blockchain.Script{}, fmt.Errorf("unknown script hash type %s", script.HashType)
+	}
+	return blockchain.NewScriptBuilder().
+		CodeHash(*blockchain.Byte32FromSliceUnchecked(script.CodeHash.Bytes())).
+		HashType(blockchain.NewByte(byte(hashType))).
+		Args(encodeBytes(script.Args)).
+		Build(), nil
+}
+
+func encodeOutPoint(outPoint *ckbTypes.OutPoint) blockchain.OutPoint {
+	return blockchain.NewOutPointBuilder().
+		TxHash(*blockchain.Byte32FromSliceUnchecked(outPoint.TxHash.Bytes())).
+		Index(*blockchain.Uint32FromSliceUnchecked(encodeUint32(uint32(outPoint.Index)))).
+		Build()
+}
+
+func encodeBytes(data []byte) blockchain.Bytes {
+	encoded := make([]byte, 4+len(data))
+	binary.LittleEndian.PutUint32(encoded, uint32(len(data)))
+	copy(encoded[4:], data)
+	return *blockchain.BytesFromSliceUnchecked(encoded)
+}
+
+func encodeBytesVec(items [][]byte) blockchain.BytesVec {
+	vec := make([]blockchain.Bytes, len(items))
+	for i, item := range items {
+		vec[i] = encodeBytes(item)
+	}
+	return blockchain.NewBytesVecBuilder().Set(vec).Build()
+}
+
+func encodeUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func encodeUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
+
+func indexOf[T comparable](values []T, v T) int {
+	for i, value := range values {
+		if value == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,281 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/blockchain"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// codecNode answers get_block_by_number with the json of the rpc or, with verbosity 0, the encoded block
+type codecNode struct {
+	blocks map[uint64]*ckbTypes.Block
+	raw    map[uint64][]byte
+}
+
+func (n codecNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     json.RawMessage  `json:"id"`
+		Params []hexutil.Uint64 `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var result any
+	if block, ok := n.blocks[uint64(request.Params[0])]; ok {
+		result = rpcBlock(block)
+		if len(request.Params) > 1 && request.Params[1] == 0 {
+			result = hexutil.Bytes(n.raw[uint64(request.Params[0])])
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result})
+}
+
+func rpcHeader(header *ckbTypes.Header) map[string]any {
+	return map[string]any{
+		"compact_target":    hexutil.Uint(header.CompactTarget),
+		"dao":               header.Dao,
+		"epoch":             hexutil.Uint64(header.Epoch),
+		"hash":              header.Hash,
+		"nonce":             (*hexutil.Big)(header.Nonce),
+		"number":            hexutil.Uint64(header.Number),
+		"parent_hash":       header.ParentHash,
+		"proposals_hash":    header.ProposalsHash,
+		"timestamp":         hexutil.Uint64(header.Timestamp),
+		"transactions_root": header.TransactionsRoot,
+		"extra_hash":        header.ExtraHash,
+		"version":           hexutil.Uint(header.Version),
+	}
+}
+
+func rpcScript(script *ckbTypes.Script) map[string]any {
+	if script == nil {
+		return nil
+	}
+	return map[string]any{"code_hash": script.CodeHash, "hash_type": script.HashType, "args": hexutil.Bytes(script.Args)}
+}
+
+func rpcOutPoint(outPoint *ckbTypes.OutPoint) map[string]any {
+	return map[string]any{"tx_hash": outPoint.TxHash, "index": hexutil.Uint(outPoint.Index)}
+}
+
+func rpcBytes(items [][]byte) []hexutil.Bytes {
+	result := make([]hexutil.Bytes, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result
+}
+
+// rpcBlock renders a block the way the node does in the json rpc
+func rpcBlock(block *ckbTypes.Block) map[string]any {
+	var txs, uncles []map[string]any
+	for _, tx := range block.Transactions {
+		var cellDeps, inputs, outputs []map[string]any
+		for _, dep := range tx.CellDeps {
+			cellDeps = append(cellDeps, map[string]any{"out_point": rpcOutPoint(dep.OutPoint), "dep_type": dep.DepType})
+		}
+		for _, input := range tx.Inputs {
+			inputs = append(inputs, map[string]any{"since": hexutil.Uint64(input.Since), "previous_output": rpcOutPoint(input.PreviousOutput)})
+		}
+		for _, output := range tx.Outputs {
+			outputs = append(outputs, map[string]any{"capacity": hexutil.Uint64(output.Capacity), "lock": rpcScript(output.Lock), "type": rpcScript(output.Type)})
+		}
+		txs = append(txs, map[string]any{
+			"version":      hexutil.Uint(tx.Version),
+			"hash":         tx.Hash,
+			"cell_deps":    append([]map[string]any{}, cellDeps...),
+			"header_deps":  append([]ckbTypes.Hash{}, tx.HeaderDeps...),
+			"inputs":       append([]map[string]any{}, inputs...),
+			"outputs":      append([]map[string]any{}, outputs...),
+			"outputs_data": rpcBytes(tx.OutputsData),
+			"witnesses":    rpcBytes(tx.Witnesses),
+		})
+	}
+	for _, uncle := range block.Uncles {
+		uncles = append(uncles, map[string]any{"header": rpcHeader(uncle.Header), "proposals": uncle.Proposals})
+	}
+	return map[string]any{
+		"header":       rpcHeader(block.Header),
+		"proposals":    block.Proposals,
+		"transactions": append([]map[string]any{}, txs...),
+		"uncles":       append([]map[string]any{}, uncles...),
+	}
+}
+
+// codecBlock fills in the hashes, the transaction hashes come from ckb-sdk-go
+func codecBlock(t *testing.T, block *ckbTypes.Block) *ckbTypes.Block {
+	for _, tx := range block.Transactions {
+		hash, err := tx.ComputeHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Hash = hash
+	}
+	for _, header := range append([]*ckbTypes.Header{block.Header}, uncleHeaders(block)...) {
+		encoded, err := encodeHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := decodeHeader(&encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header.Hash = decoded.Hash
+	}
+	return block
+}
+
+func uncleHeaders(block *ckbTypes.Block) []*ckbTypes.Header {
+	var headers []*ckbTypes.Header
+	for _, uncle := range block.Uncles {
+		headers = append(headers, uncle.Header)
+	}
+	return headers
+}
+
+// withExtension re-encodes a block as BlockV1
+func withExtension(raw []byte, extension []byte) []byte {
+	block := blockchain.BlockFromSliceUnchecked(raw)
+	blockV1 := blockchain.NewBlockV1Builder().
+		Header(*block.Header()).
+		Uncles(*block.Uncles()).
+		Transactions(*block.Transactions()).
+		Proposals(*block.Proposals()).
+		Extension(encodeBytes(extension)).
+		Build()
+	return blockV1.AsSlice()
+}
+
+func Test_moleculeBlockSource(t *testing.T) {
+	nonce, _ := new(big.Int).SetString("f3cb0c1a8e5b4cbd2d8bcb0e1ea67d51", 16)
+	header := func(number uint64) *ckbTypes.Header {
+		return &ckbTypes.Header{
+			CompactTarget:    0x1a08a97e,
+			Dao:              blockHash(0xda0),
+			Epoch:            0x70806cc000fc3,
+			Nonce:            nonce,
+			Number:           number,
+			ParentHash:       blockHash(number - 1),
+			ProposalsHash:    blockHash(0xb0),
+			Timestamp:        1660000000000 + number,
+			TransactionsRoot: blockHash(0xa0),
+			ExtraHash:        blockHash(0xe0),
+		}
+	}
+	cellbase := &ckbTypes.Transaction{
+		CellDeps:   []*ckbTypes.CellDep{},
+		HeaderDeps: []ckbTypes.Hash{},
+		Inputs: []*ckbTypes.CellInput{{
+			Since:          100,
+			PreviousOutput: &ckbTypes.OutPoint{Index: 0xffffffff},
+		}},
+		Outputs:     []*ckbTypes.CellOutput{},
+		OutputsData: [][]byte{},
+		Witnesses:   [][]byte{{0x59, 0x00, 0x00, 0x00}},
+	}
+	cotaTx := &ckbTypes.Transaction{
+		CellDeps: []*ckbTypes.CellDep{
+			{OutPoint: &ckbTypes.OutPoint{TxHash: blockHash(1), Index: 0}, DepType: ckbTypes.DepTypeDepGroup},
+			{OutPoint: &ckbTypes.OutPoint{TxHash: blockHash(2), Index: 3}, DepType: ckbTypes.DepTypeCode},
+		},
+		HeaderDeps: []ckbTypes.Hash{blockHash(99)},
+		Inputs: []*ckbTypes.CellInput{
+			{Since: 0, PreviousOutput: &ckbTypes.OutPoint{TxHash: blockHash(3), Index: 1}},
+		},
+		Outputs: []*ckbTypes.CellOutput{
+			{
+				Capacity: 150_00000000,
+				Lock:     &ckbTypes.Script{CodeHash: blockHash(4), HashType: ckbTypes.HashTypeType, Args: []byte{0x01, 0x02}},
+				Type:     &ckbTypes.Script{CodeHash: blockHash(5), HashType: ckbTypes.HashTypeData1, Args: []byte{}},
+			},
+			{
+				Capacity: 61_00000000,
+				Lock:     &ckbTypes.Script{CodeHash: blockHash(6), HashType: ckbTypes.HashTypeData, Args: []byte{}},
+			},
+		},
+		OutputsData: [][]byte{{0x02, 0xaa}, {}},
+		Witnesses:   [][]byte{{}, {0x10, 0x00, 0x00, 0x00}},
+	}
+	blocks := map[uint64]*ckbTypes.Block{
+		10: codecBlock(t, &ckbTypes.Block{
+			Header:       header(10),
+			Proposals:    []string{},
+			Transactions: []*ckbTypes.Transaction{cellbase},
+			Uncles:       []*ckbTypes.UncleBlock{},
+		}),
+		11: codecBlock(t, &ckbTypes.Block{
+			Header:       header(11),
+			Proposals:    []string{"0x0102030405060708090a", "0xa0a1a2a3a4a5a6a7a8a9"},
+			Transactions: []*ckbTypes.Transaction{cellbase, cotaTx},
+			Uncles: []*ckbTypes.UncleBlock{
+				{Header: header(10), Proposals: []string{"0xffffffffffffffffffff"}},
+			},
+		}),
+		12: codecBlock(t, &ckbTypes.Block{
+			Header:       header(12),
+			Proposals:    []string{},
+			Transactions: []*ckbTypes.Transaction{cotaTx},
+			Uncles:       []*ckbTypes.UncleBlock{},
+		}),
+	}
+	node := codecNode{blocks: blocks, raw: make(map[uint64][]byte)}
+	for number, block := range blocks {
+		raw, err := encodeBlock(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.raw[number] = raw
+	}
+	node.raw[12] = withExtension(node.raw[12], []byte{0xee})
+	server := httptest.NewServer(node)
+	defer server.Close()
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	source := moleculeBlockSource{Client: client}
+
+	tests := []struct {
+		name        string
+		blockNumber uint64
+		wantErr     error
+	}{
+		{name: "should decode a block with the cellbase only", blockNumber: 10},
+		{name: "should decode a block with uncles, proposals and scripts", blockNumber: 11},
+		{name: "should decode a block with extension", blockNumber: 12},
+		{name: "should report a missing block", blockNumber: 13, wantErr: rpc.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := client.GetBlockByNumber(context.Background(), tt.blockNumber)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("json error = %v, want %v", err, tt.wantErr)
+			}
+			got, err := source.GetBlockByNumber(context.Background(), tt.blockNumber)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("molecule error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			wantJson, _ := json.Marshal(want)
+			gotJson, _ := json.Marshal(got)
+			if !bytes.Equal(gotJson, wantJson) {
+				t.Errorf("GetBlockByNumber() = %s, want %s", gotJson, wantJson)
+			}
+		})
+	}
+}
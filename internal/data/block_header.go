@@ -0,0 +1,70 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz.BlockHeaderRepo = (*blockHeaderRepo)(nil)
+
+type BlockHeader struct {
+	ID          uint `gorm:"primaryKey"`
+	BlockNumber uint64
+	BlockHash   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type blockHeaderRepo struct {
+	data   *Data
+	logger *logger.Logger
+}
+
+func NewBlockHeaderRepo(data *Data, logger *logger.Logger) biz.BlockHeaderRepo {
+	return &blockHeaderRepo{
+		data:   data,
+		logger: logger,
+	}
+}
+
+func (rp blockHeaderRepo) FindLatestBlockHeaders(ctx context.Context, limit int) ([]biz.BlockHeader, error) {
+	var headers []BlockHeader
+	if err := rp.data.db.WithContext(ctx).Order("block_number desc").Limit(limit).Find(&headers).Error; err != nil {
+		return nil, err
+	}
+	result := make([]biz.BlockHeader, len(headers))
+	for i, header := range headers {
+		result[i] = biz.BlockHeader{
+			BlockNumber: header.BlockNumber,
+			BlockHash:   header.BlockHash,
+		}
+	}
+	return result, nil
+}
+
+func (rp blockHeaderRepo) CleanBlockHeaders(ctx context.Context, depth uint64) error {
+	var header BlockHeader
+	if err := rp.data.db.WithContext(ctx).Order("block_number desc").Limit(1).Find(&header).Error; err != nil {
+		return err
+	}
+	if header.BlockNumber <= depth {
+		return nil
+	}
+	return rp.data.db.WithContext(ctx).Where("block_number < ?", header.BlockNumber-depth).Delete(BlockHeader{}).Error
+}
+
+// saveBlockHeader records the header of a committed block. The first syncer committing a height wins, so a stale
+// header of the other syncer is kept until the reorganizer removes it and the fork can still be detected.
+func saveBlockHeader(ctx context.Context, tx *gorm.DB, checkInfo biz.CheckInfo) error {
+	return tx.Model(BlockHeader{}).WithContext(ctx).Clauses(clause.OnConflict{
+		DoNothing: true,
+	}).Create(&BlockHeader{
+		BlockNumber: checkInfo.BlockNumber,
+		BlockHash:   checkInfo.BlockHash,
+	}).Error
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+var _ BlockSource = (rpc.Client)(nil)
+
+// BlockSource is the part of the node rpc the syncer reads the chain from. The rpc client of ckb-sdk-go is the live
+// source, a block archive replays the chain without a node.
+type BlockSource interface {
+	GetBlockchainInfo(ctx context.Context) (*ckbTypes.BlockchainInfo, error)
+	GetTipBlockNumber(ctx context.Context) (uint64, error)
+	GetBlockByNumber(ctx context.Context, number uint64) (*ckbTypes.Block, error)
+	GetBlockHash(ctx context.Context, number uint64) (*ckbTypes.Hash, error)
+	GetHeader(ctx context.Context, hash ckbTypes.Hash) (*ckbTypes.Header, error)
+	GetHeaderByNumber(ctx context.Context, number uint64) (*ckbTypes.Header, error)
+	GetTransaction(ctx context.Context, hash ckbTypes.Hash) (*ckbTypes.TransactionWithStatus, error)
+	BatchTransactions(ctx context.Context, batch []ckbTypes.BatchTransactionItem) error
+	// GetTip and GetTransactions are served by the indexer
+	GetTip(ctx context.Context) (*indexer.TipHeader, error)
+	GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.Transactions, error)
+	Close()
+}
+
+// moleculeBlockSource fetches blocks with verbosity 0 and decodes the molecule encoding, which costs a fraction of the
+// json decoding of a full block during a catch-up
+type moleculeBlockSource struct {
+	rpc.Client
+}
+
+func (s moleculeBlockSource) GetBlockByNumber(ctx context.Context, number uint64) (*ckbTypes.Block, error) {
+	var raw *hexutil.Bytes
+	if err := s.CallContext(ctx, &raw, "get_block_by_number", hexutil.Uint64(number), hexutil.Uint64(0)); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, rpc.NotFound
+	}
+	return decodeBlock(*raw)
+}
@@ -4,92 +4,159 @@ import (
 	"context"
 
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+	"golang.org/x/sync/errgroup"
 )
 
 type BlockSyncer struct {
-	claimedCotaUsecase    *biz.ClaimedCotaNftKvPairUsecase
-	defineCotaUsecase     *biz.DefineCotaNftKvPairUsecase
-	holdCotaUsecase       *biz.HoldCotaNftKvPairUsecase
-	registerCotaUsecase   *biz.RegisterCotaKvPairUsecase
-	withdrawCotaUsecase   *biz.WithdrawCotaNftKvPairUsecase
+	registryParser        RegistryParser
 	cotaWitnessArgsParser CotaWitnessArgsParser
 	kvPairUsecase         *biz.SyncKvPairUsecase
-	mintCotaUsecase       *biz.MintCotaKvPairUsecase
-	transferCotaUsecase   *biz.TransferCotaKvPairUsecase
-	issuerInfoUsecase     *biz.IssuerInfoUsecase
-	classInfoUsecase      *biz.ClassInfoUsecase
+	entryHandlers         *biz.EntryHandlerRegistry
+	policy                entryPolicy
+	logger                *logger.Logger
 }
 
-func NewBlockSyncer(claimedCotaUsecase *biz.ClaimedCotaNftKvPairUsecase, defineCotaUsecase *biz.DefineCotaNftKvPairUsecase,
-	holdCotaUsecase *biz.HoldCotaNftKvPairUsecase, registerCotaUsecase *biz.RegisterCotaKvPairUsecase,
-	withdrawCotaUsecase *biz.WithdrawCotaNftKvPairUsecase, cotaWitnessArgsParser CotaWitnessArgsParser,
-	kvPairUsecase *biz.SyncKvPairUsecase, mintCotaUsecase *biz.MintCotaKvPairUsecase, transferCotaUsecase *biz.TransferCotaKvPairUsecase,
-	issuerInfoUsecase *biz.IssuerInfoUsecase, classInfoUsecase *biz.ClassInfoUsecase) BlockSyncer {
+func NewBlockSyncer(registryParser RegistryParser, cotaWitnessArgsParser CotaWitnessArgsParser,
+	kvPairUsecase *biz.SyncKvPairUsecase, entryHandlers *biz.EntryHandlerRegistry, appConf *config.App, logger *logger.Logger) BlockSyncer {
 	return BlockSyncer{
-		claimedCotaUsecase:    claimedCotaUsecase,
-		defineCotaUsecase:     defineCotaUsecase,
-		holdCotaUsecase:       holdCotaUsecase,
-		registerCotaUsecase:   registerCotaUsecase,
-		withdrawCotaUsecase:   withdrawCotaUsecase,
+		registryParser:        registryParser,
 		cotaWitnessArgsParser: cotaWitnessArgsParser,
 		kvPairUsecase:         kvPairUsecase,
-		mintCotaUsecase:       mintCotaUsecase,
-		transferCotaUsecase:   transferCotaUsecase,
-		issuerInfoUsecase:     issuerInfoUsecase,
-		classInfoUsecase:      classInfoUsecase,
+		entryHandlers:         entryHandlers,
+		policy:                newEntryPolicy(appConf, logger),
+		logger:                logger,
 	}
 }
 
+// ParsedBlock holds the registry pairs, registry updates and cota witness entries extracted from a block,
+// ordered by transaction index. DeadLetters holds the transactions quarantined instead and UnrecognizedEntries the
+// entries left out of Entries because the parsers do not know their action type or version.
+type ParsedBlock struct {
+	Block               *ckbTypes.Block
+	Registers           []biz.RegisterCotaKvPair
+	RegistryHistories   []biz.RegistryHistory
+	Entries             []biz.Entry
+	CotaCells           []biz.CotaCell
+	ConsumedCells       []biz.CellOutPoint
+	DeadLetters         []biz.DeadLetter
+	UnrecognizedEntries []biz.UnrecognizedEntry
+}
+
 func (bp BlockSyncer) Sync(ctx context.Context, block *ckbTypes.Block, checkInfo biz.CheckInfo, systemScripts SystemScripts) error {
-	var entryVec []biz.Entry
-	kvPair := biz.KvPair{}
+	parsedBlock, err := bp.Parse(ctx, block, systemScripts, 1)
+	if err != nil {
+		return err
+	}
+	return bp.Save(ctx, parsedBlock, checkInfo)
+}
+
+// Parse extracts the registry pairs and cota entries of the block, the transactions are parsed by at most workers goroutines
+func (bp BlockSyncer) Parse(ctx context.Context, block *ckbTypes.Block, systemScripts SystemScripts, workers int) (ParsedBlock, error) {
+	systemScripts = systemScripts.At(block.Header.Number)
+	if err := bp.registryParser.ResolveInputs(ctx, block.Transactions, systemScripts.CotaRegistryType); err != nil {
+		return ParsedBlock{}, err
+	}
+	if err := bp.cotaWitnessArgsParser.ResolveInputs(ctx, block.Transactions, systemScripts.CotaType); err != nil {
+		return ParsedBlock{}, err
+	}
+	txRegisters := make([][]biz.RegisterCotaKvPair, len(block.Transactions))
+	txHistories := make([]*biz.RegistryHistory, len(block.Transactions))
+	txEntries := make([][]biz.Entry, len(block.Transactions))
+	txDeadLetters := make([]*biz.DeadLetter, len(block.Transactions))
+	txUnrecognized := make([][]biz.UnrecognizedEntry, len(block.Transactions))
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(workers)
 	for index, tx := range block.Transactions {
-		// ParseRegistryEntries TODO 拆到独立到 repo 中
-		if bp.hasCotaRegistryCell(tx.Outputs, systemScripts.CotaRegistryType) && bp.isUpdateCotaRegistryTx(tx.Witnesses[0]) {
-			registers, err := bp.registerCotaUsecase.ParseRegistryEntries(ctx, block.Header.Number, tx)
-			if err != nil && err.Error() == "No data" {
-				continue
-			} else if err != nil {
-				return err
+		index, tx := index, tx
+		eg.Go(func() error {
+			registers, history, entries, unrecognized, err := bp.parseTx(ctx, block.Header.Number, tx, uint32(index), systemScripts)
+			if err != nil {
+				deadLetter, err := bp.policy.handle(ctx, block, biz.SyncBlock, err)
+				if err != nil {
+					return err
+				}
+				txDeadLetters[index] = &deadLetter
+				return nil
 			}
-			kvPair.Registers = append(kvPair.Registers, registers...)
+			txRegisters[index] = registers
+			txHistories[index] = history
+			txEntries[index] = entries
+			txUnrecognized[index] = unrecognized
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return ParsedBlock{}, err
+	}
+	cotaCells, consumedCells, err := blockCotaCells(block, systemScripts.CotaType)
+	if err != nil {
+		return ParsedBlock{}, err
+	}
+	parsedBlock := ParsedBlock{Block: block, CotaCells: cotaCells, ConsumedCells: consumedCells}
+	for index := range block.Transactions {
+		parsedBlock.Registers = append(parsedBlock.Registers, txRegisters[index]...)
+		if txHistories[index] != nil {
+			parsedBlock.RegistryHistories = append(parsedBlock.RegistryHistories, *txHistories[index])
 		}
-		entries, err := bp.cotaWitnessArgsParser.Parse(tx, uint32(index), systemScripts.CotaType)
-		if err != nil && err.Error() == "No data" {
-			continue
-		} else if err != nil {
-			return err
+		parsedBlock.Entries = append(parsedBlock.Entries, txEntries[index]...)
+		parsedBlock.UnrecognizedEntries = append(parsedBlock.UnrecognizedEntries, txUnrecognized[index]...)
+		if txDeadLetters[index] != nil {
+			parsedBlock.DeadLetters = append(parsedBlock.DeadLetters, *txDeadLetters[index])
 		}
-		entryVec = append(entryVec, entries...)
 	}
-	pairs, err := bp.parseCotaEntries(block.Header.Number, entryVec)
-	pairs.Registers = kvPair.Registers
-	err = bp.kvPairUsecase.CreateCotaEntryKvPairs(ctx, checkInfo, &pairs)
+	return parsedBlock, nil
+}
+
+// Save converts the parsed entries to kv pairs and stores them together with the check info
+func (bp BlockSyncer) Save(ctx context.Context, parsedBlock ParsedBlock, checkInfo biz.CheckInfo) error {
+	pairs, err := bp.KvPairs(parsedBlock)
 	if err != nil {
 		return err
 	}
-	return nil
-}
-
-func (bp BlockSyncer) isUpdateCotaRegistryTx(firstWitness []byte) bool {
-	return len(firstWitness) != 0
+	return bp.kvPairUsecase.CreateCotaEntryKvPairs(ctx, checkInfo, &pairs)
 }
 
-func (bp BlockSyncer) isCotaRegistryCell(output *ckbTypes.CellOutput, registryType SystemScript) bool {
-	if output.Type == nil {
-		return false
+// KvPairs converts the parsed entries to kv pairs without storing them
+func (bp BlockSyncer) KvPairs(parsedBlock ParsedBlock) (biz.KvPair, error) {
+	pairs, err := bp.parseCotaEntries(parsedBlock.Block.Header.Number, parsedBlock.Entries)
+	if err != nil {
+		return pairs, err
 	}
-	return output.Type.CodeHash == registryType.CodeHash && output.Type.HashType == registryType.HashType && argsEq(output.Type.Args, registryType.Args)
+	pairs.Registers = parsedBlock.Registers
+	pairs.RegistryHistories = parsedBlock.RegistryHistories
+	pairs.CotaCells = parsedBlock.CotaCells
+	pairs.ConsumedCells = parsedBlock.ConsumedCells
+	pairs.DeadLetters = parsedBlock.DeadLetters
+	pairs.UnrecognizedEntries = parsedBlock.UnrecognizedEntries
+	return pairs, nil
 }
 
-func (bp BlockSyncer) hasCotaRegistryCell(outputs []*ckbTypes.CellOutput, registryType SystemScript) (result bool) {
-	for _, output := range outputs {
-		if result = bp.isCotaRegistryCell(output, registryType); result {
-			break
+// parseTx returns the registry pairs, the registry update and the cota entries of the transaction, the entries the
+// parsers do not know are returned apart. Under the quarantine policy the entries are converted to kv pairs once here,
+// so that a transaction failing later on is quarantined as a whole.
+func (bp BlockSyncer) parseTx(ctx context.Context, blockNumber uint64, tx *ckbTypes.Transaction, txIndex uint32, systemScripts SystemScripts) (registers []biz.RegisterCotaKvPair, history *biz.RegistryHistory, entries []biz.Entry, unrecognized []biz.UnrecognizedEntry, err error) {
+	defer recoverEntry(txIndex, &err)
+	registers, history, err = bp.registryParser.Parse(ctx, blockNumber, tx, txIndex, systemScripts.CotaRegistryType)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	entries, err = bp.cotaWitnessArgsParser.Parse(ctx, tx, txIndex, systemScripts.CotaType)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	entries, unrecognized, err = recognizeEntries(ctx, blockNumber, entries, bp.entryHandlers, bp.logger)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if bp.policy.quarantine {
+		if _, err = bp.parseCotaEntries(blockNumber, entries); err != nil {
+			return nil, nil, nil, nil, err
 		}
 	}
-	return result
+	return registers, history, entries, unrecognized, nil
 }
 
 func (bp BlockSyncer) Rollback(ctx context.Context, blockNumber uint64) error {
@@ -99,75 +166,27 @@ func (bp BlockSyncer) Rollback(ctx context.Context, blockNumber uint64) error {
 func (bp BlockSyncer) parseCotaEntries(blockNumber uint64, entries []biz.Entry) (biz.KvPair, error) {
 	var kvPair biz.KvPair
 	for _, entry := range entries {
-		if len(entry.InputType) > 0 {
-			switch entry.InputType[0] {
-			//	Define 创建 DefineCota Kv pairs
-			case 1:
-				defineCotas, err := bp.defineCotaUsecase.ParseDefineCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.DefineCotas = append(kvPair.DefineCotas, defineCotas...)
-			//	Mint 更新 DefineCota Kv pairs 创建 withdrawCota kv pairs
-			case 2:
-				updatedDefineCotas, withdrawCotas, err := bp.mintCotaUsecase.ParseMintCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.UpdatedDefineCotas = append(kvPair.UpdatedDefineCotas, updatedDefineCotas...)
-				kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
-			//	Withdraw 删除 HoldCota kv pairs 创建 withdrawCota kv pairs
-			case 3:
-				withdrawCotas, err := bp.withdrawCotaUsecase.ParseWithdrawCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
-			//	Claim 创建 HoldCota kv pairs 与 claimedCota kv pairs
-			case 4:
-				holdCotas, claimedCotas, err := bp.claimedCotaUsecase.ParseClaimedCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
-				kvPair.HoldCotas = append(kvPair.HoldCotas, holdCotas...)
-			//	Update 更新 HoldCota kv pairs
-			case 5:
-				holdCotas, err := bp.holdCotaUsecase.ParseHoldCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.UpdatedHoldCotas = append(kvPair.HoldCotas, holdCotas...)
-			//	Transfer 创建 claimedCota kv pairs 与 withdrawCota kv pairs
-			case 6:
-				claimedCotas, withdrawCotas, err := bp.transferCotaUsecase.ParseTransferCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
-				kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
-			//	Claim and Update 创建 HoldCota kv pairs 与 claimedCota kv pairs
-			case 7:
-				holdCotas, claimedCotas, err := bp.claimedCotaUsecase.ParseClaimedUpdateCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
-				kvPair.HoldCotas = append(kvPair.HoldCotas, holdCotas...)
-			//	Transfer and Update 创建 claimedCota kv pairs 与 withdrawCota kv pairs
-			case 8:
-				claimedCotas, withdrawCotas, err := bp.transferCotaUsecase.ParseTransferUpdateCotaEntries(blockNumber, entry)
-				if err != nil {
-					return kvPair, err
-				}
-				kvPair.ClaimedCotas = append(kvPair.ClaimedCotas, claimedCotas...)
-				kvPair.WithdrawCotas = append(kvPair.WithdrawCotas, withdrawCotas...)
-			}
+		if err := bp.parseCotaEntry(blockNumber, entry, &kvPair); err != nil {
+			return kvPair, err
 		}
 	}
 	return kvPair, nil
 }
 
+// parseCotaEntry converts the entry with the handler of its action type and version, recognizeEntries has set
+// aside the entries without one
+func (bp BlockSyncer) parseCotaEntry(blockNumber uint64, entry biz.Entry, kvPair *biz.KvPair) (err error) {
+	defer recoverEntry(entry.TxIndex, &err)
+	if len(entry.InputType) == 0 {
+		return nil
+	}
+	handler, ok := bp.entryHandlers.Lookup(entry.InputType[0], entry.Version)
+	if !ok {
+		return nil
+	}
+	return handler.Handle(blockNumber, entry, kvPair)
+}
+
 func argsEq(args1, args2 []byte) bool {
 	if args1 == nil || args2 == nil {
 		return false
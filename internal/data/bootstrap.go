@@ -0,0 +1,163 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// Bootstrapper seeds the check info of every check type that has none yet with the start block, so that the sync
+// services begin right after it. The start block is start_block_number / start_block_hash of the app config and
+// defaults to the block before the cota scripts were deployed on the connected network.
+type Bootstrapper struct {
+	client           *CkbNodeClient
+	systemScripts    SystemScripts
+	checkInfoUsecase *biz.CheckInfoUsecase
+	kvPairUsecase    *biz.SyncKvPairUsecase
+	cotaCellUsecase  *biz.CotaCellUsecase
+	logger           *logger.Logger
+	startBlockNumber uint64
+	startBlockHash   string
+}
+
+func NewBootstrapper(client *CkbNodeClient, systemScripts SystemScripts, checkInfoUsecase *biz.CheckInfoUsecase, kvPairUsecase *biz.SyncKvPairUsecase,
+	cotaCellUsecase *biz.CotaCellUsecase, appConf *config.App, logger *logger.Logger) *Bootstrapper {
+	return &Bootstrapper{
+		client:           client,
+		systemScripts:    systemScripts,
+		checkInfoUsecase: checkInfoUsecase,
+		kvPairUsecase:    kvPairUsecase,
+		cotaCellUsecase:  cotaCellUsecase,
+		logger:           logger,
+		startBlockNumber: appConf.StartBlockNumber,
+		startBlockHash:   strings.TrimPrefix(appConf.StartBlockHash, "0x"),
+	}
+}
+
+// SetStartBlock overrides the configured start block, an empty hash is looked up from the node
+func (b *Bootstrapper) SetStartBlock(blockNumber uint64, blockHash string) {
+	b.startBlockNumber = blockNumber
+	b.startBlockHash = strings.TrimPrefix(blockHash, "0x")
+}
+
+// StartCheckInfo resolves the start block on the node and checks that the configured hash is canonical at its height
+func (b *Bootstrapper) StartCheckInfo(ctx context.Context) (biz.CheckInfo, error) {
+	blockNumber := b.startBlockNumber
+	if blockNumber == 0 && b.startBlockHash != "" {
+		header, err := b.client.Rpc.GetHeader(ctx, ckbTypes.HexToHash("0x"+b.startBlockHash))
+		if err != nil {
+			return biz.CheckInfo{}, fmt.Errorf("get start block header %s error: %w", b.startBlockHash, err)
+		}
+		if header == nil {
+			return biz.CheckInfo{}, fmt.Errorf("start block %s not found on the node", b.startBlockHash)
+		}
+		blockNumber = header.Number
+	}
+	if blockNumber == 0 && b.startBlockHash == "" {
+		deployment, err := b.deploymentBlockNumber(ctx)
+		if err != nil {
+			return biz.CheckInfo{}, err
+		}
+		if deployment > 0 {
+			blockNumber = deployment - 1
+		}
+	}
+	tipBlockNumber, err := b.client.Rpc.GetTipBlockNumber(ctx)
+	if err != nil {
+		return biz.CheckInfo{}, err
+	}
+	if blockNumber > tipBlockNumber {
+		return biz.CheckInfo{}, fmt.Errorf("start block %d is above the tip block %d", blockNumber, tipBlockNumber)
+	}
+	blockHash, err := b.client.Rpc.GetBlockHash(ctx, blockNumber)
+	if err != nil {
+		return biz.CheckInfo{}, err
+	}
+	if b.startBlockHash != "" && b.startBlockHash != blockHash.String()[2:] {
+		return biz.CheckInfo{}, fmt.Errorf("start block hash %s does not match the canonical block %s at %d", b.startBlockHash, blockHash.String()[2:], blockNumber)
+	}
+	return biz.CheckInfo{BlockNumber: blockNumber, BlockHash: blockHash.String()[2:]}, nil
+}
+
+// Seed creates the start check info for the check types without one and returns the seeded check infos
+func (b *Bootstrapper) Seed(ctx context.Context) ([]biz.CheckInfo, error) {
+	var missing []biz.CheckType
+	for _, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+		checkInfo := biz.CheckInfo{CheckType: checkType}
+		if err := b.checkInfoUsecase.LastCheckInfo(ctx, &checkInfo); err != nil {
+			return nil, err
+		}
+		if checkInfo.Id == 0 {
+			missing = append(missing, checkType)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	start, err := b.StartCheckInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var seeded []biz.CheckInfo
+	for _, checkType := range missing {
+		start.CheckType = checkType
+		// skipping from an empty check info only succeeds while the check type still has no check info
+		err = b.kvPairUsecase.SkipBlocks(ctx, biz.CheckInfo{}, start, checkType)
+		if errors.Is(err, biz.ErrCheckInfoConflict) {
+			continue
+		}
+		if err != nil {
+			return seeded, err
+		}
+		b.logger.Infof(ctx, "seed %s check info at block %d %s", checkType.String(), start.BlockNumber, start.BlockHash)
+		seeded = append(seeded, start)
+		if checkType == biz.SyncBlock {
+			if err = b.recordCoverage(ctx, start); err != nil {
+				return seeded, err
+			}
+		}
+	}
+	return seeded, nil
+}
+
+// recordCoverage marks the cota cell index complete when the block sync starts before the deployment of the cota
+// scripts, only then local input resolution may take an out point missing from the index for a plain cell
+func (b *Bootstrapper) recordCoverage(ctx context.Context, start biz.CheckInfo) error {
+	deployment, err := b.deploymentBlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if start.BlockNumber >= deployment {
+		b.logger.Infof(ctx, "cota cell index starts at block %d after the deployment at block %d, inputs missing from it are fetched from the node", start.BlockNumber+1, deployment)
+		return nil
+	}
+	return b.cotaCellUsecase.RecordCoverage(ctx, start.BlockNumber+1)
+}
+
+// deploymentBlockNumber returns the block holding the earliest cell dep of the cota scripts
+func (b *Bootstrapper) deploymentBlockNumber(ctx context.Context) (uint64, error) {
+	var deployment uint64
+	for _, script := range b.systemScripts.All() {
+		tx, err := b.client.Rpc.GetTransaction(ctx, script.OutPoint.TxHash)
+		if err != nil {
+			return 0, fmt.Errorf("get deployment transaction %s error: %w", script.OutPoint.TxHash.String(), err)
+		}
+		if tx == nil || tx.TxStatus == nil || tx.TxStatus.BlockHash == nil {
+			return 0, fmt.Errorf("deployment transaction %s is not committed", script.OutPoint.TxHash.String())
+		}
+		header, err := b.client.Rpc.GetHeader(ctx, *tx.TxStatus.BlockHash)
+		if err != nil {
+			return 0, err
+		}
+		if deployment == 0 || header.Number < deployment {
+			deployment = header.Number
+		}
+	}
+	return deployment, nil
+}
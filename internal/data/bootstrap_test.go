@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// deploymentNode is a chain where block n has the hash blockHash(n) and the cota scripts were deployed at deployment
+type deploymentNode struct {
+	rpc.Client
+	tip        uint64
+	deployment uint64
+}
+
+func (n deploymentNode) GetTipBlockNumber(context.Context) (uint64, error) {
+	return n.tip, nil
+}
+
+func (n deploymentNode) GetBlockHash(_ context.Context, blockNumber uint64) (*ckbTypes.Hash, error) {
+	hash := blockHash(blockNumber)
+	return &hash, nil
+}
+
+func (n deploymentNode) GetTransaction(context.Context, ckbTypes.Hash) (*ckbTypes.TransactionWithStatus, error) {
+	hash := blockHash(n.deployment)
+	return &ckbTypes.TransactionWithStatus{TxStatus: &ckbTypes.TxStatus{BlockHash: &hash, Status: ckbTypes.TransactionStatusCommitted}}, nil
+}
+
+func (n deploymentNode) GetHeader(_ context.Context, hash ckbTypes.Hash) (*ckbTypes.Header, error) {
+	for blockNumber := uint64(0); blockNumber <= n.tip; blockNumber++ {
+		if blockHash(blockNumber) == hash {
+			return &ckbTypes.Header{Number: blockNumber, Hash: hash}, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestBootstrapper_StartCheckInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		blockNumber uint64
+		blockHash   string
+		want        uint64
+		wantErr     bool
+	}{
+		{
+			name: "should default to the block before the deployment",
+			want: 99,
+		},
+		{
+			name:        "should use the configured block number",
+			blockNumber: 150,
+			want:        150,
+		},
+		{
+			name:        "should accept the canonical hash with 0x prefix",
+			blockNumber: 150,
+			blockHash:   blockHash(150).String(),
+			want:        150,
+		},
+		{
+			name:      "should look up the block number of a hash",
+			blockHash: blockHash(120).String()[2:],
+			want:      120,
+		},
+		{
+			name:        "should reject a hash that is not canonical at the block number",
+			blockNumber: 150,
+			blockHash:   blockHash(151).String(),
+			wantErr:     true,
+		},
+		{
+			name:        "should reject a block above the tip",
+			blockNumber: 201,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bootstrapper{
+				client:        &CkbNodeClient{Rpc: deploymentNode{tip: 200, deployment: 100}},
+				systemScripts: SystemScripts{CotaRegistryType: SystemScriptList{{}}, CotaType: SystemScriptList{{}}},
+				logger:        logger.NewLogger(io.Discard, "", 0),
+			}
+			b.SetStartBlock(tt.blockNumber, tt.blockHash)
+			got, err := b.StartCheckInfo(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StartCheckInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := biz.CheckInfo{BlockNumber: tt.want, BlockHash: blockHash(tt.want).String()[2:]}
+			if got != want {
+				t.Errorf("StartCheckInfo() got = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"gorm.io/gorm"
+)
+
+var _ biz.ChainIdentityRepo = (*chainIdentityRepo)(nil)
+
+// chainIdentityID is the id of the single chain identity row
+const chainIdentityID = 1
+
+type ChainIdentity struct {
+	ID          uint `gorm:"primaryKey"`
+	Chain       string
+	GenesisHash string
+	Scripts     string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type chainIdentityRepo struct {
+	data   *Data
+	logger *logger.Logger
+}
+
+func NewChainIdentityRepo(data *Data, logger *logger.Logger) biz.ChainIdentityRepo {
+	return &chainIdentityRepo{
+		data:   data,
+		logger: logger,
+	}
+}
+
+func (rp chainIdentityRepo) FindChainIdentity(ctx context.Context) (biz.ChainIdentity, bool, error) {
+	var identity ChainIdentity
+	err := rp.data.db.WithContext(ctx).First(&identity, chainIdentityID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return biz.ChainIdentity{}, false, nil
+	}
+	if err != nil {
+		return biz.ChainIdentity{}, false, err
+	}
+	return biz.ChainIdentity{Chain: identity.Chain, GenesisHash: identity.GenesisHash, Scripts: identity.Scripts}, true, nil
+}
+
+// FindSyncedBlock skips the rows without a block hash, e.g. the start block check infos seeded by hand or by old
+// versions, which cannot be compared with the node
+func (rp chainIdentityRepo) FindSyncedBlock(ctx context.Context) (biz.CheckInfo, bool, error) {
+	var checkInfo CheckInfo
+	if err := rp.data.db.WithContext(ctx).Where("block_hash <> ''").Order("block_number").Limit(1).Find(&checkInfo).Error; err != nil {
+		return biz.CheckInfo{}, false, err
+	}
+	if checkInfo.ID != 0 {
+		return biz.CheckInfo{BlockNumber: checkInfo.BlockNumber, BlockHash: checkInfo.BlockHash}, true, nil
+	}
+	var header BlockHeader
+	if err := rp.data.db.WithContext(ctx).Where("block_hash <> ''").Order("block_number").Limit(1).Find(&header).Error; err != nil {
+		return biz.CheckInfo{}, false, err
+	}
+	return biz.CheckInfo{BlockNumber: header.BlockNumber, BlockHash: header.BlockHash}, header.ID != 0, nil
+}
+
+func (rp chainIdentityRepo) CreateChainIdentity(ctx context.Context, identity biz.ChainIdentity) error {
+	return rp.data.db.WithContext(ctx).Create(&ChainIdentity{
+		ID:          chainIdentityID,
+		Chain:       identity.Chain,
+		GenesisHash: identity.GenesisHash,
+		Scripts:     identity.Scripts,
+	}).Error
+}
+
+func (rp chainIdentityRepo) UpdateChainIdentityScripts(ctx context.Context, scripts string) error {
+	return rp.data.db.WithContext(ctx).Model(&ChainIdentity{ID: chainIdentityID}).Update("scripts", scripts).Error
+}
+
+// ChainGuard binds the database to the chain of the connected node and to the configured cota scripts, so that a
+// syncer of one network never writes into the database of another.
+type ChainGuard struct {
+	client               *CkbNodeClient
+	systemScripts        SystemScripts
+	chainIdentityUsecase *biz.ChainIdentityUsecase
+}
+
+func NewChainGuard(client *CkbNodeClient, systemScripts SystemScripts, chainIdentityUsecase *biz.ChainIdentityUsecase) *ChainGuard {
+	return &ChainGuard{
+		client:               client,
+		systemScripts:        systemScripts,
+		chainIdentityUsecase: chainIdentityUsecase,
+	}
+}
+
+// Check fails with biz.ErrChainMismatch when the node or the config does not match the chain the database was
+// synced from, the first start stores the identity
+func (g *ChainGuard) Check(ctx context.Context) error {
+	identity, err := g.identity(ctx)
+	if err != nil {
+		return err
+	}
+	return g.chainIdentityUsecase.Check(ctx, identity, g.blockHash)
+}
+
+// AcceptScripts stores the configured cota scripts as the ones of the database
+func (g *ChainGuard) AcceptScripts(ctx context.Context) error {
+	identity, err := g.identity(ctx)
+	if err != nil {
+		return err
+	}
+	return g.chainIdentityUsecase.AcceptScripts(ctx, identity, g.blockHash)
+}
+
+func (g *ChainGuard) blockHash(ctx context.Context, blockNumber uint64) (string, error) {
+	hash, err := g.client.Rpc.GetBlockHash(ctx, blockNumber)
+	if err != nil {
+		return "", fmt.Errorf("get block hash of %d error: %w", blockNumber, err)
+	}
+	return hash.String()[2:], nil
+}
+
+func (g *ChainGuard) identity(ctx context.Context) (biz.ChainIdentity, error) {
+	chainInfo, err := g.client.Rpc.GetBlockchainInfo(ctx)
+	if err != nil {
+		return biz.ChainIdentity{}, fmt.Errorf("get blockchain info error: %w", err)
+	}
+	genesisHash, err := g.client.Rpc.GetBlockHash(ctx, 0)
+	if err != nil {
+		return biz.ChainIdentity{}, fmt.Errorf("get genesis block hash error: %w", err)
+	}
+	return biz.ChainIdentity{
+		Chain:       chainInfo.Chain,
+		GenesisHash: genesisHash.String()[2:],
+		Scripts:     g.systemScripts.Identity(),
+	}, nil
+}
@@ -0,0 +1,230 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+	"gorm.io/gorm"
+)
+
+// memChainIdentityRepo keeps the chain identity and the oldest synced block in memory
+type memChainIdentityRepo struct {
+	identity *biz.ChainIdentity
+	synced   *biz.CheckInfo
+}
+
+func (rp *memChainIdentityRepo) FindChainIdentity(_ context.Context) (biz.ChainIdentity, bool, error) {
+	if rp.identity == nil {
+		return biz.ChainIdentity{}, false, nil
+	}
+	return *rp.identity, true, nil
+}
+
+func (rp *memChainIdentityRepo) FindSyncedBlock(_ context.Context) (biz.CheckInfo, bool, error) {
+	if rp.synced == nil {
+		return biz.CheckInfo{}, false, nil
+	}
+	return *rp.synced, true, nil
+}
+
+func (rp *memChainIdentityRepo) CreateChainIdentity(_ context.Context, identity biz.ChainIdentity) error {
+	rp.identity = &identity
+	return nil
+}
+
+func (rp *memChainIdentityRepo) UpdateChainIdentityScripts(_ context.Context, scripts string) error {
+	rp.identity.Scripts = scripts
+	return nil
+}
+
+func TestChainIdentityUsecase_Check(t *testing.T) {
+	oldType := SystemScript{CodeHash: ckbTypes.HexToHash("0x03"), HashType: ckbTypes.HashTypeType}
+	newType := SystemScript{CodeHash: ckbTypes.HexToHash("0x04"), HashType: ckbTypes.HashTypeType}
+	registry := SystemScript{CodeHash: ckbTypes.HexToHash("0x01"), HashType: ckbTypes.HashTypeType, Args: []byte{0xab}}
+	scripts := SystemScripts{CotaRegistryType: SystemScriptList{registry}, CotaType: SystemScriptList{oldType}}
+	testnet := biz.ChainIdentity{Chain: "ckb_testnet", GenesisHash: "10", Scripts: scripts.Identity()}
+	upgraded := biz.ChainIdentity{Chain: "ckb_testnet", GenesisHash: "10", Scripts: SystemScripts{CotaRegistryType: SystemScriptList{registry}, CotaType: SystemScriptList{oldType, newType}}.Identity()}
+	tests := []struct {
+		name          string
+		stored        *biz.ChainIdentity
+		synced        *biz.CheckInfo
+		identity      biz.ChainIdentity
+		acceptScripts bool
+		wantErr       error
+		wantStored    biz.ChainIdentity
+	}{
+		{
+			name:       "should store the identity on the first start",
+			identity:   testnet,
+			wantStored: testnet,
+		},
+		{
+			name:       "should store the identity of a database with blocks of the chain",
+			synced:     &biz.CheckInfo{BlockNumber: 5, BlockHash: "05"},
+			identity:   testnet,
+			wantStored: testnet,
+		},
+		{
+			name:     "should refuse a database with blocks of another chain",
+			synced:   &biz.CheckInfo{BlockNumber: 5, BlockHash: "92"},
+			identity: testnet,
+			wantErr:  biz.ErrChainMismatch,
+		},
+		{
+			name:          "should not accept the scripts of a database with blocks of another chain",
+			synced:        &biz.CheckInfo{BlockNumber: 5, BlockHash: "92"},
+			identity:      testnet,
+			acceptScripts: true,
+			wantErr:       biz.ErrChainMismatch,
+		},
+		{
+			name:       "should accept the same chain",
+			stored:     &testnet,
+			identity:   testnet,
+			wantStored: testnet,
+		},
+		{
+			name:       "should refuse another chain",
+			stored:     &testnet,
+			identity:   biz.ChainIdentity{Chain: "ckb", GenesisHash: "92", Scripts: testnet.Scripts},
+			wantErr:    biz.ErrChainMismatch,
+			wantStored: testnet,
+		},
+		{
+			name:       "should refuse a reset chain of the same name",
+			stored:     &testnet,
+			identity:   biz.ChainIdentity{Chain: "ckb_testnet", GenesisHash: "11", Scripts: testnet.Scripts},
+			wantErr:    biz.ErrChainMismatch,
+			wantStored: testnet,
+		},
+		{
+			name:   "should refuse other cota scripts",
+			stored: &testnet,
+			identity: biz.ChainIdentity{Chain: "ckb_testnet", GenesisHash: "10",
+				Scripts: SystemScripts{CotaRegistryType: SystemScriptList{registry}, CotaType: SystemScriptList{newType}}.Identity()},
+			wantErr:    biz.ErrChainMismatch,
+			wantStored: testnet,
+		},
+		{
+			name:   "should ignore the order of the scripts",
+			stored: &upgraded,
+			identity: biz.ChainIdentity{Chain: "ckb_testnet", GenesisHash: "10",
+				Scripts: SystemScripts{CotaRegistryType: SystemScriptList{registry}, CotaType: SystemScriptList{newType, oldType}}.Identity()},
+			wantStored: upgraded,
+		},
+		{
+			name:          "should accept upgraded scripts on request",
+			stored:        &testnet,
+			identity:      upgraded,
+			acceptScripts: true,
+			wantStored:    upgraded,
+		},
+		{
+			name:          "should not accept the scripts of another chain",
+			stored:        &testnet,
+			identity:      biz.ChainIdentity{Chain: "ckb", GenesisHash: "92", Scripts: "other"},
+			acceptScripts: true,
+			wantErr:       biz.ErrChainMismatch,
+			wantStored:    testnet,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memChainIdentityRepo{synced: tt.synced}
+			if tt.stored != nil {
+				stored := *tt.stored
+				repo.identity = &stored
+			}
+			uc := biz.NewChainIdentityUsecase(repo, logger.NewLogger(io.Discard, "", 0))
+			// the node is on the chain whose block hashes are the block numbers
+			blockHash := func(_ context.Context, blockNumber uint64) (string, error) {
+				return fmt.Sprintf("%02x", blockNumber), nil
+			}
+			var err error
+			if tt.acceptScripts {
+				err = uc.AcceptScripts(context.Background(), tt.identity, blockHash)
+			}
+			if err == nil {
+				err = uc.Check(context.Background(), tt.identity, blockHash)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			var stored biz.ChainIdentity
+			if repo.identity != nil {
+				stored = *repo.identity
+			}
+			if stored != tt.wantStored {
+				t.Errorf("stored identity = %+v, want %+v", stored, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestChainIdentityRepo_FindSyncedBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkInfos []CheckInfo
+		headers    []BlockHeader
+		want       biz.CheckInfo
+		wantFound  bool
+	}{
+		{
+			name: "should find no block in an empty database",
+		},
+		{
+			name:       "should take the oldest check info",
+			checkInfos: []CheckInfo{{BlockNumber: 8, BlockHash: "08", CheckType: biz.SyncBlock}, {BlockNumber: 7, BlockHash: "07", CheckType: biz.SyncMetadata}},
+			headers:    []BlockHeader{{BlockNumber: 3, BlockHash: "03"}},
+			want:       biz.CheckInfo{BlockNumber: 7, BlockHash: "07"},
+			wantFound:  true,
+		},
+		{
+			name: "should skip the check infos without a block hash",
+			checkInfos: []CheckInfo{{BlockNumber: 5, CheckType: biz.SyncBlock}, {BlockNumber: 5, CheckType: biz.SyncMetadata},
+				{BlockNumber: 6, BlockHash: "06", CheckType: biz.SyncBlock}},
+			want:      biz.CheckInfo{BlockNumber: 6, BlockHash: "06"},
+			wantFound: true,
+		},
+		{
+			name:       "should fall back to the oldest block header when no check info has a block hash",
+			checkInfos: []CheckInfo{{BlockNumber: 5, CheckType: biz.SyncBlock}},
+			headers:    []BlockHeader{{BlockNumber: 6, BlockHash: "06"}},
+			want:       biz.CheckInfo{BlockNumber: 6, BlockHash: "06"},
+			wantFound:  true,
+		},
+		{
+			name:       "should find no block when no row has a block hash",
+			checkInfos: []CheckInfo{{BlockNumber: 5, CheckType: biz.SyncBlock}, {BlockNumber: 5, CheckType: biz.SyncMetadata}},
+		},
+		{
+			name:      "should fall back to the oldest block header",
+			headers:   []BlockHeader{{BlockNumber: 4, BlockHash: "04"}, {BlockNumber: 3, BlockHash: "03"}},
+			want:      biz.CheckInfo{BlockNumber: 3, BlockHash: "03"},
+			wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTestData(t)
+			for _, rows := range []any{tt.checkInfos, tt.headers} {
+				if err := data.db.Create(rows).Error; err != nil && !errors.Is(err, gorm.ErrEmptySlice) {
+					t.Fatal(err)
+				}
+			}
+			got, found, err := NewChainIdentityRepo(data, logger.NewLogger(io.Discard, "", 0)).FindSyncedBlock(context.Background())
+			if err != nil {
+				t.Fatalf("FindSyncedBlock() error = %v", err)
+			}
+			if found != tt.wantFound || got != tt.want {
+				t.Errorf("FindSyncedBlock() = %+v, %v, want %+v, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+const defaultMaxReorgDepth = 100
+
+// ChainReorganizer rolls the synced data back to the common ancestor of the stored header chain and the node
+type ChainReorganizer struct {
+	client        *CkbNodeClient
+	kvPairUsecase *biz.SyncKvPairUsecase
+	headerUsecase *biz.BlockHeaderUsecase
+	logger        *logger.Logger
+	maxDepth      uint64
+	mu            sync.Mutex
+}
+
+func NewChainReorganizer(client *CkbNodeClient, kvPairUsecase *biz.SyncKvPairUsecase, headerUsecase *biz.BlockHeaderUsecase, appConf *config.App, logger *logger.Logger) *ChainReorganizer {
+	maxDepth := appConf.MaxReorgDepth
+	if maxDepth == 0 {
+		maxDepth = defaultMaxReorgDepth
+	}
+	return &ChainReorganizer{
+		client:        client,
+		kvPairUsecase: kvPairUsecase,
+		headerUsecase: headerUsecase,
+		logger:        logger,
+		maxDepth:      maxDepth,
+	}
+}
+
+// MaxDepth returns the number of recent blocks whose headers have to be kept
+func (r *ChainReorganizer) MaxDepth() uint64 {
+	return r.maxDepth
+}
+
+// Reorg is called when the block following the forked check info does not extend it. It walks back the stored
+// headers below the forked block until one is still canonical on the node and rewinds every block above it for
+// both the cota entries and the metadata. The blocks are found in the kv tables rather than in check_infos, whose
+// cleaner may keep fewer rows than the max depth. A reorg deeper than the max depth returns biz.ErrReorgTooDeep.
+func (r *ChainReorganizer) Reorg(ctx context.Context, forked biz.CheckInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	headers, err := r.headerUsecase.LatestBlockHeaders(ctx, int(r.maxDepth)+1)
+	if err != nil {
+		return err
+	}
+	if len(headers) == 0 {
+		return fmt.Errorf("%w: no block headers stored", biz.ErrReorgTooDeep)
+	}
+	localHead := headers[0].BlockNumber
+	for _, header := range headers {
+		if header.BlockNumber >= forked.BlockNumber {
+			continue
+		}
+		if localHead-header.BlockNumber > r.maxDepth {
+			break
+		}
+		hash, err := r.client.Rpc.GetBlockHash(ctx, header.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if hash != nil && hash.String()[2:] == header.BlockHash {
+			r.logger.Infof(ctx, "reorg detected at %d, rollback blocks (%d, %d]", forked.BlockNumber, header.BlockNumber, localHead)
+			return r.kvPairUsecase.RewindKvPairs(ctx, biz.CheckInfo{BlockNumber: header.BlockNumber, BlockHash: header.BlockHash})
+		}
+	}
+	return fmt.Errorf("%w: no common ancestor within %d blocks below %d", biz.ErrReorgTooDeep, r.maxDepth, localHead)
+}
@@ -0,0 +1,103 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// forkedNode agrees with blockHash(n) below the fork and has other blocks from the fork on
+type forkedNode struct {
+	rpc.Client
+	fork uint64
+}
+
+func (n forkedNode) GetBlockHash(_ context.Context, blockNumber uint64) (*ckbTypes.Hash, error) {
+	hash := blockHash(blockNumber)
+	if blockNumber >= n.fork {
+		hash = ckbTypes.HexToHash(fmt.Sprintf("0xff%062x", blockNumber))
+	}
+	return &hash, nil
+}
+
+func TestChainReorganizer_Reorg(t *testing.T) {
+	tests := []struct {
+		name string
+		fork uint64
+		// keptCheckInfos is the number of latest check infos per type left by the cleaner
+		keptCheckInfos int
+		wantHead       uint64
+	}{
+		{
+			name:           "should roll back a two block fork",
+			fork:           5,
+			keptCheckInfos: 6,
+			wantHead:       4,
+		},
+		{
+			name:           "should roll back the blocks whose check infos were cleaned",
+			fork:           3,
+			keptCheckInfos: 1,
+			wantHead:       2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTestData(t)
+			ctx := context.Background()
+			log := logger.NewLogger(io.Discard, "", 0)
+			kvPairUsecase := biz.NewSyncKvPairUsecase(NewKvPairRepo(data, &config.App{}, log), log)
+			for blockNumber := uint64(1); blockNumber <= 6; blockNumber++ {
+				checkInfo := biz.CheckInfo{BlockNumber: blockNumber, BlockHash: blockHash(blockNumber).String()[2:]}
+				pairs := &biz.KvPair{Registers: []biz.RegisterCotaKvPair{{BlockNumber: blockNumber, LockHash: fmt.Sprintf("%064d", blockNumber)}}}
+				if err := kvPairUsecase.CreateKvPairs(ctx, checkInfo, pairs); err != nil {
+					t.Fatalf("CreateKvPairs(%d) error = %v", blockNumber, err)
+				}
+			}
+			if err := data.db.Where("block_number <= ?", 6-tt.keptCheckInfos).Delete(CheckInfo{}).Error; err != nil {
+				t.Fatalf("clean check infos error = %v", err)
+			}
+			reorganizer := NewChainReorganizer(&CkbNodeClient{Rpc: forkedNode{fork: tt.fork}}, kvPairUsecase,
+				biz.NewBlockHeaderUsecase(NewBlockHeaderRepo(data, log), log), &config.App{MaxReorgDepth: 10}, log)
+			forked := biz.CheckInfo{BlockNumber: 6, BlockHash: blockHash(6).String()[2:]}
+			if err := reorganizer.Reorg(ctx, forked); err != nil {
+				t.Fatalf("Reorg() error = %v", err)
+			}
+			var registered []uint64
+			if err := data.db.Model(RegisterCotaKvPair{}).Order("block_number").Pluck("block_number", &registered).Error; err != nil {
+				t.Fatalf("find registers error = %v", err)
+			}
+			var wantRegistered []uint64
+			for blockNumber := uint64(1); blockNumber <= tt.wantHead; blockNumber++ {
+				wantRegistered = append(wantRegistered, blockNumber)
+			}
+			if !reflect.DeepEqual(registered, wantRegistered) {
+				t.Errorf("registers of blocks %v, want %v", registered, wantRegistered)
+			}
+			for _, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+				var last CheckInfo
+				if err := data.db.Where("check_type = ?", checkType).Order("block_number desc").Limit(1).Find(&last).Error; err != nil {
+					t.Fatalf("find check info error = %v", err)
+				}
+				if last.BlockNumber != tt.wantHead || last.BlockHash != blockHash(tt.wantHead).String()[2:] {
+					t.Errorf("%s check info at %d %s, want %d", checkType, last.BlockNumber, last.BlockHash, tt.wantHead)
+				}
+			}
+			var header BlockHeader
+			if err := data.db.Order("block_number desc").Limit(1).Find(&header).Error; err != nil {
+				t.Fatalf("find header error = %v", err)
+			}
+			if header.BlockNumber != tt.wantHead {
+				t.Errorf("latest header at %d, want %d", header.BlockNumber, tt.wantHead)
+			}
+		})
+	}
+}
@@ -14,17 +14,18 @@ import (
 var _ biz.ClaimedCotaNftKvPairRepo = (*claimedCotaNftKvPairRepo)(nil)
 
 type ClaimedCotaNftKvPair struct {
-	ID          uint `gorm:"primaryKey"`
-	BlockNumber uint64
-	CotaId      string
-	CotaIdCRC   uint32
-	TokenIndex  uint32
-	OutPoint    string
-	OutPointCrc uint32
-	LockHash    string
-	LockHashCrc uint32
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID            uint `gorm:"primaryKey"`
+	BlockNumber   uint64
+	CotaId        string
+	CotaIdCRC     uint32
+	TokenIndex    uint32
+	OutPoint      string
+	OutPointCrc   uint32
+	LockHash      string
+	LockHashCrc   uint32
+	ScriptVersion uint8
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 type claimedCotaNftKvPairRepo struct {
@@ -66,12 +67,20 @@ func (rp claimedCotaNftKvPairRepo) generateV0ToV2ClaimedKvPair(blockNumber uint6
 	var holdCotaValueVec *smt.HoldCotaNFTValueVec = nil
 	var claimedCotaKeyVec *smt.ClaimCotaNFTKeyVec = nil
 	if entry.Version == 0 {
-		entries := smt.ClaimCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.ClaimCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		holdCotaKeyVec = entries.HoldKeys()
 		holdCotaValueVec = entries.HoldValues()
 		claimedCotaKeyVec = entries.ClaimKeys()
 	} else {
-		entries := smt.ClaimCotaNFTV2EntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.ClaimCotaNFTV2EntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		holdCotaKeyVec = entries.HoldKeys()
 		holdCotaValueVec = entries.HoldValues()
 		claimedCotaKeyVec = entries.ClaimKeys()
@@ -94,6 +103,7 @@ func (rp claimedCotaNftKvPairRepo) generateV0ToV2ClaimedKvPair(blockNumber uint6
 			Characteristic: hex.EncodeToString(value.Characteristic().RawData()),
 			LockHash:       lockHashStr,
 			LockHashCRC:    lockHashCRC32,
+			ScriptVersion:  entry.ScriptVersion,
 		})
 	}
 	for i := uint(0); i < claimedCotaKeyVec.Len(); i++ {
@@ -101,14 +111,15 @@ func (rp claimedCotaNftKvPairRepo) generateV0ToV2ClaimedKvPair(blockNumber uint6
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
 		claimedCotas = append(claimedCotas, biz.ClaimedCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.NftId().CotaId().RawData()),
-			CotaIdCRC:   crc32.ChecksumIEEE([]byte(cotaId)),
-			TokenIndex:  binary.BigEndian.Uint32(key.NftId().Index().RawData()),
-			OutPoint:    outpointStr,
-			OutPointCrc: crc32.ChecksumIEEE([]byte(outpointStr)),
-			LockHash:    lockHashStr,
-			LockHashCrc: lockHashCRC32,
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.NftId().CotaId().RawData()),
+			CotaIdCRC:     crc32.ChecksumIEEE([]byte(cotaId)),
+			TokenIndex:    binary.BigEndian.Uint32(key.NftId().Index().RawData()),
+			OutPoint:      outpointStr,
+			OutPointCrc:   crc32.ChecksumIEEE([]byte(outpointStr)),
+			LockHash:      lockHashStr,
+			LockHashCrc:   lockHashCRC32,
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	return
@@ -119,12 +130,20 @@ func (rp claimedCotaNftKvPairRepo) generateV0ToV2ClaimedUpdateKvPair(blockNumber
 	var holdCotaValueVec *smt.HoldCotaNFTValueVec = nil
 	var claimedCotaKeyVec *smt.ClaimCotaNFTKeyVec = nil
 	if entry.Version == 0 {
-		entries := smt.ClaimUpdateCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.ClaimUpdateCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		holdCotaKeyVec = entries.HoldKeys()
 		holdCotaValueVec = entries.HoldValues()
 		claimedCotaKeyVec = entries.ClaimKeys()
 	} else {
-		entries := smt.ClaimUpdateCotaNFTV2EntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.ClaimUpdateCotaNFTV2EntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		holdCotaKeyVec = entries.HoldKeys()
 		holdCotaValueVec = entries.HoldValues()
 		claimedCotaKeyVec = entries.ClaimKeys()
@@ -148,6 +167,7 @@ func (rp claimedCotaNftKvPairRepo) generateV0ToV2ClaimedUpdateKvPair(blockNumber
 			Characteristic: hex.EncodeToString(value.Characteristic().RawData()),
 			LockHash:       lockHashStr,
 			LockHashCRC:    lockHashCRC32,
+			ScriptVersion:  entry.ScriptVersion,
 		})
 	}
 	for i := uint(0); i < claimedCotaKeyVec.Len(); i++ {
@@ -155,14 +175,15 @@ func (rp claimedCotaNftKvPairRepo) generateV0ToV2ClaimedUpdateKvPair(blockNumber
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
 		claimedCotas = append(claimedCotas, biz.ClaimedCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.NftId().CotaId().RawData()),
-			CotaIdCRC:   crc32.ChecksumIEEE([]byte(cotaId)),
-			TokenIndex:  binary.BigEndian.Uint32(key.NftId().Index().RawData()),
-			OutPoint:    outpointStr,
-			OutPointCrc: crc32.ChecksumIEEE([]byte(outpointStr)),
-			LockHash:    lockHashStr,
-			LockHashCrc: lockHashCRC32,
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.NftId().CotaId().RawData()),
+			CotaIdCRC:     crc32.ChecksumIEEE([]byte(cotaId)),
+			TokenIndex:    binary.BigEndian.Uint32(key.NftId().Index().RawData()),
+			OutPoint:      outpointStr,
+			OutPointCrc:   crc32.ChecksumIEEE([]byte(outpointStr)),
+			LockHash:      lockHashStr,
+			LockHashCrc:   lockHashCRC32,
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	return
@@ -131,6 +131,22 @@ func (repo classInfoRepo) ParseClassInfo(blockNumber uint64, txIndex uint32, cla
 	if localizationStr == "{}" {
 		localizationStr = ""
 	}
+	err = checkColumnLengths(txIndex, []column{
+		{"version", classInfo.Version, 40},
+		{"name", classInfo.Name, 255},
+		{"symbol", classInfo.Symbol, 255},
+		{"description", classInfo.Description, 1000},
+		{"image", classInfo.Image, 500},
+		{"audio", classInfo.Audio, 500},
+		{"video", classInfo.Video, 500},
+		{"model", classInfo.Model, 500},
+		{"characteristic", characteristicStr, 1000},
+		{"properties", propertiesStr, 1000},
+		{"localization", localizationStr, 1000},
+	})
+	if err != nil {
+		return
+	}
 	class = biz.ClassInfo{
 		BlockNumber:    blockNumber,
 		CotaId:         classInfo.CotaId[2:],
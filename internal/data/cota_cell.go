@@ -0,0 +1,215 @@
+package data
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+	"gorm.io/gorm"
+)
+
+var _ biz.CotaCellRepo = (*cotaCellRepo)(nil)
+
+// cotaCellBatchSize bounds the number of out points in one IN clause
+const cotaCellBatchSize = 500
+
+type CotaCell struct {
+	ID                  uint `gorm:"primaryKey"`
+	TxHash              string
+	OutputIndex         uint32
+	LockHash            string
+	TypeHash            string
+	TypeCodeHash        string
+	TypeHashType        string
+	TypeArgs            string
+	Version             uint8
+	SmtRoot             string
+	BlockNumber         uint64
+	ConsumedBlockNumber uint64
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// CotaCellCoverage records that cota_cells is complete from FromBlockNumber on, which is at or before the deployment
+// of the cota scripts. Databases that started the sync later or before cota_cells existed have no coverage.
+type CotaCellCoverage struct {
+	ID              uint `gorm:"primaryKey"`
+	FromBlockNumber uint64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type cotaCellRepo struct {
+	data   *Data
+	logger *logger.Logger
+}
+
+func NewCotaCellRepo(data *Data, logger *logger.Logger) biz.CotaCellRepo {
+	return &cotaCellRepo{
+		data:   data,
+		logger: logger,
+	}
+}
+
+func (rp cotaCellRepo) FindCotaCells(ctx context.Context, outPoints []biz.CellOutPoint) ([]biz.CotaCell, error) {
+	var result []biz.CotaCell
+	for _, batch := range outPointBatches(outPoints) {
+		var cells []CotaCell
+		if err := rp.data.db.WithContext(ctx).Where("(tx_hash, output_index) IN ?", batch).Find(&cells).Error; err != nil {
+			return nil, err
+		}
+		for _, cell := range cells {
+			result = append(result, biz.CotaCell{
+				TxHash:       cell.TxHash,
+				OutputIndex:  cell.OutputIndex,
+				LockHash:     cell.LockHash,
+				TypeHash:     cell.TypeHash,
+				TypeCodeHash: cell.TypeCodeHash,
+				TypeHashType: cell.TypeHashType,
+				TypeArgs:     cell.TypeArgs,
+				Version:      cell.Version,
+				SmtRoot:      cell.SmtRoot,
+				BlockNumber:  cell.BlockNumber,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (rp cotaCellRepo) DeleteConsumedCotaCells(ctx context.Context, blockNumber uint64) error {
+	return rp.data.db.WithContext(ctx).Where("consumed_block_number > 0 and consumed_block_number < ?", blockNumber).Delete(CotaCell{}).Error
+}
+
+func (rp cotaCellRepo) FindCoverage(ctx context.Context) (uint64, bool, error) {
+	var coverage CotaCellCoverage
+	if err := rp.data.db.WithContext(ctx).Order("id").Limit(1).Find(&coverage).Error; err != nil {
+		return 0, false, err
+	}
+	return coverage.FromBlockNumber, coverage.ID != 0, nil
+}
+
+func (rp cotaCellRepo) CreateCoverage(ctx context.Context, fromBlockNumber uint64) error {
+	return rp.data.db.WithContext(ctx).Create(&CotaCellCoverage{FromBlockNumber: fromBlockNumber}).Error
+}
+
+// createCotaCells indexes the cota cells created by a block and marks the cells consumed by it
+func createCotaCells(ctx context.Context, tx *gorm.DB, blockNumber uint64, kvPair *biz.KvPair) error {
+	if kvPair.HasCotaCells() {
+		cells := make([]CotaCell, len(kvPair.CotaCells))
+		for i, cell := range kvPair.CotaCells {
+			cells[i] = CotaCell{
+				TxHash:       cell.TxHash,
+				OutputIndex:  cell.OutputIndex,
+				LockHash:     cell.LockHash,
+				TypeHash:     cell.TypeHash,
+				TypeCodeHash: cell.TypeCodeHash,
+				TypeHashType: cell.TypeHashType,
+				TypeArgs:     cell.TypeArgs,
+				Version:      cell.Version,
+				SmtRoot:      cell.SmtRoot,
+				BlockNumber:  cell.BlockNumber,
+			}
+		}
+		if err := tx.Model(CotaCell{}).WithContext(ctx).Create(cells).Error; err != nil {
+			return err
+		}
+	}
+	for _, batch := range outPointBatches(kvPair.ConsumedCells) {
+		if err := tx.Model(CotaCell{}).WithContext(ctx).Where("(tx_hash, output_index) IN ? and consumed_block_number = 0", batch).
+			Update("consumed_block_number", blockNumber).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreCotaCells removes the cells created by the block and revives the cells consumed by it
+func restoreCotaCells(ctx context.Context, tx *gorm.DB, blockNumber uint64) error {
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(CotaCell{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(CotaCell{}).WithContext(ctx).Where("consumed_block_number = ?", blockNumber).Update("consumed_block_number", 0).Error
+}
+
+func outPointBatches(outPoints []biz.CellOutPoint) [][][]any {
+	var batches [][][]any
+	for start := 0; start < len(outPoints); start += cotaCellBatchSize {
+		end := start + cotaCellBatchSize
+		if end > len(outPoints) {
+			end = len(outPoints)
+		}
+		batch := make([][]any, end-start)
+		for i, outPoint := range outPoints[start:end] {
+			batch[i] = []any{outPoint.TxHash, outPoint.Index}
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// blockCotaCells returns the cota cells created by the block and the out points of all inputs spent by it
+func blockCotaCells(block *ckbTypes.Block, cotaType SystemScriptList) ([]biz.CotaCell, []biz.CellOutPoint, error) {
+	var cells []biz.CotaCell
+	var consumed []biz.CellOutPoint
+	for txIndex, tx := range block.Transactions {
+		// the cellbase input does not spend a cell
+		if txIndex > 0 {
+			for _, input := range tx.Inputs {
+				consumed = append(consumed, biz.CellOutPoint{
+					TxHash: input.PreviousOutput.TxHash.String()[2:],
+					Index:  uint32(input.PreviousOutput.Index),
+				})
+			}
+		}
+		for i, output := range tx.Outputs {
+			if !cotaType.matchesCode(output.Type) {
+				continue
+			}
+			lockHash, err := output.Lock.Hash()
+			if err != nil {
+				return nil, nil, err
+			}
+			typeHash, err := output.Type.Hash()
+			if err != nil {
+				return nil, nil, err
+			}
+			cell := biz.CotaCell{
+				TxHash:       tx.Hash.String()[2:],
+				OutputIndex:  uint32(i),
+				LockHash:     lockHash.String()[2:],
+				TypeHash:     typeHash.String()[2:],
+				TypeCodeHash: output.Type.CodeHash.String()[2:],
+				TypeHashType: string(output.Type.HashType),
+				TypeArgs:     hex.EncodeToString(output.Type.Args),
+				BlockNumber:  block.Header.Number,
+			}
+			outputData := tx.OutputsData[i]
+			if len(outputData) > 0 {
+				cell.Version = outputData[0]
+			}
+			if len(outputData) >= 33 {
+				cell.SmtRoot = hex.EncodeToString(outputData[1:33])
+			}
+			cells = append(cells, cell)
+		}
+	}
+	return cells, consumed, nil
+}
+
+// cotaCellOutput rebuilds the part of the cell output the witness parser looks at
+func cotaCellOutput(cell biz.CotaCell) (*ckbTypes.CellOutput, error) {
+	args, err := hex.DecodeString(cell.TypeArgs)
+	if err != nil {
+		return nil, err
+	}
+	return &ckbTypes.CellOutput{
+		Type: &ckbTypes.Script{
+			CodeHash: ckbTypes.HexToHash(cell.TypeCodeHash),
+			HashType: ckbTypes.ScriptHashType(cell.TypeHashType),
+			Args:     args,
+		},
+	}, nil
+}
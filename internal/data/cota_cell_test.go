@@ -0,0 +1,186 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+	"gorm.io/gorm"
+)
+
+func testCotaCell(txHash string, blockNumber uint64) biz.CotaCell {
+	return biz.CotaCell{
+		TxHash:       txHash,
+		LockHash:     fmt.Sprintf("%064d", 1),
+		TypeHash:     fmt.Sprintf("%064d", 2),
+		TypeCodeHash: fmt.Sprintf("%064d", 3),
+		TypeHashType: string(ckbTypes.HashTypeType),
+		TypeArgs:     "ab",
+		SmtRoot:      fmt.Sprintf("%064d", 4),
+		BlockNumber:  blockNumber,
+	}
+}
+
+func Test_createCotaCells(t *testing.T) {
+	data := newTestData(t)
+	ctx := context.Background()
+	a, b, c := fmt.Sprintf("%064d", 10), fmt.Sprintf("%064d", 11), fmt.Sprintf("%064d", 12)
+	commit := func(blockNumber uint64, kvPair *biz.KvPair) func(tx *gorm.DB) error {
+		return func(tx *gorm.DB) error { return createCotaCells(ctx, tx, blockNumber, kvPair) }
+	}
+	restore := func(blockNumber uint64) func(tx *gorm.DB) error {
+		return func(tx *gorm.DB) error { return restoreCotaCells(ctx, tx, blockNumber) }
+	}
+	// the steps run in order, each one sees the cells left by the previous ones
+	tests := []struct {
+		name string
+		step func(tx *gorm.DB) error
+		want map[string]uint64
+	}{
+		{
+			name: "should index the cells created by a block",
+			step: commit(10, &biz.KvPair{CotaCells: []biz.CotaCell{testCotaCell(a, 10), testCotaCell(b, 10)}}),
+			want: map[string]uint64{a: 0, b: 0},
+		},
+		{
+			name: "should mark the cells consumed by a block",
+			step: commit(11, &biz.KvPair{
+				CotaCells:     []biz.CotaCell{testCotaCell(c, 11)},
+				ConsumedCells: []biz.CellOutPoint{{TxHash: a}, {TxHash: fmt.Sprintf("%064d", 99)}},
+			}),
+			want: map[string]uint64{a: 11, b: 0, c: 0},
+		},
+		{
+			name: "should not move the consumed block of a spent cell",
+			step: commit(12, &biz.KvPair{ConsumedCells: []biz.CellOutPoint{{TxHash: a}}}),
+			want: map[string]uint64{a: 11, b: 0, c: 0},
+		},
+		{
+			name: "should revive the consumed cells and drop the created ones on restore",
+			step: restore(11),
+			want: map[string]uint64{a: 0, b: 0},
+		},
+		{
+			name: "should drop the cells of the first block on restore",
+			step: restore(10),
+			want: map[string]uint64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := data.db.Transaction(tt.step); err != nil {
+				t.Fatalf("step error = %v", err)
+			}
+			var cells []CotaCell
+			if err := data.db.Find(&cells).Error; err != nil {
+				t.Fatalf("find cells error = %v", err)
+			}
+			got := make(map[string]uint64, len(cells))
+			for _, cell := range cells {
+				got[cell.TxHash] = cell.ConsumedBlockNumber
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("consumed block numbers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// indexedCells is a cota cell index holding cells, complete tells whether it has a coverage
+type indexedCells struct {
+	biz.CotaCellRepo
+	cells    []biz.CotaCell
+	complete bool
+}
+
+func (r indexedCells) FindCotaCells(_ context.Context, outPoints []biz.CellOutPoint) ([]biz.CotaCell, error) {
+	var result []biz.CotaCell
+	for _, cell := range r.cells {
+		for _, outPoint := range outPoints {
+			if cell.TxHash == outPoint.TxHash && cell.OutputIndex == outPoint.Index {
+				result = append(result, cell)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r indexedCells) FindCoverage(context.Context) (uint64, bool, error) {
+	return 0, r.complete, nil
+}
+
+// transactionNode answers the batch transaction requests with transactions holding one output of type script typ
+type transactionNode struct {
+	rpc.Client
+	typ     *ckbTypes.Script
+	batches *int
+}
+
+func (n transactionNode) BatchTransactions(_ context.Context, batch []ckbTypes.BatchTransactionItem) error {
+	*n.batches++
+	for i := range batch {
+		batch[i].Result = &ckbTypes.TransactionWithStatus{Transaction: &ckbTypes.Transaction{
+			Hash:    batch[i].Hash,
+			Outputs: []*ckbTypes.CellOutput{{Lock: &ckbTypes.Script{}, Type: n.typ}},
+		}}
+	}
+	return nil
+}
+
+func TestCotaWitnessArgsParser_resolveLocalInputs(t *testing.T) {
+	cotaType := &ckbTypes.Script{CodeHash: ckbTypes.HexToHash("0x03"), HashType: ckbTypes.HashTypeType, Args: []byte{0xab}}
+	indexed := ckbTypes.HexToHash("0x10")
+	unindexed := ckbTypes.HexToHash("0x11")
+	cell := testCotaCell(indexed.String()[2:], 5)
+	cell.TypeCodeHash = cotaType.CodeHash.String()[2:]
+	tests := []struct {
+		name        string
+		complete    bool
+		outPoint    ckbTypes.Hash
+		wantType    *ckbTypes.Script
+		wantBatches int
+	}{
+		{
+			name:     "should resolve an indexed cota cell without the node",
+			outPoint: indexed,
+			wantType: cotaType,
+		},
+		{
+			name:     "should take a cell missing from a complete index for a plain cell",
+			complete: true,
+			outPoint: unindexed,
+		},
+		{
+			name:        "should fetch a cell missing from an incomplete index from the node",
+			outPoint:    unindexed,
+			wantType:    cotaType,
+			wantBatches: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var batches int
+			repo := indexedCells{cells: []biz.CotaCell{cell}, complete: tt.complete}
+			parser := NewCotaWitnessArgsParser(&CkbNodeClient{Rpc: transactionNode{typ: cotaType, batches: &batches}},
+				biz.NewCotaCellUsecase(repo, logger.NewLogger(io.Discard, "", 0)),
+				&config.App{InputCacheSize: 10, InputResolution: config.LocalInputResolution})
+			output, err := parser.previousOutput(context.Background(), &ckbTypes.OutPoint{TxHash: tt.outPoint})
+			if err != nil {
+				t.Fatalf("previousOutput() error = %v", err)
+			}
+			if !reflect.DeepEqual(output.Type, tt.wantType) {
+				t.Errorf("previousOutput() type = %v, want %v", output.Type, tt.wantType)
+			}
+			if batches != tt.wantBatches {
+				t.Errorf("batch requests = %d, want %d", batches, tt.wantBatches)
+			}
+		})
+	}
+}
@@ -2,18 +2,40 @@ package data
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/blockchain"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
 	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
 )
 
 type CotaWitnessArgsParser struct {
-	client *CkbNodeClient
+	client          *CkbNodeClient
+	cache           *outputCache
+	cotaCellUsecase *biz.CotaCellUsecase
+	localInputs     bool
+	coverage        *indexCoverage
+}
+
+// indexCoverage caches whether the cota cell index is complete, the coverage is only recorded by the bootstrap of an
+// empty database before the sync starts
+type indexCoverage struct {
+	mu       sync.Mutex
+	known    bool
+	complete bool
 }
 
-func NewCotaWitnessArgsParser(client *CkbNodeClient) CotaWitnessArgsParser {
+func NewCotaWitnessArgsParser(client *CkbNodeClient, cotaCellUsecase *biz.CotaCellUsecase, appConf *config.App) CotaWitnessArgsParser {
 	return CotaWitnessArgsParser{
-		client: client,
+		client:          client,
+		cache:           newOutputCache(appConf.InputCacheSize),
+		cotaCellUsecase: cotaCellUsecase,
+		localInputs:     appConf.InputResolution == config.LocalInputResolution,
+		coverage:        &indexCoverage{},
 	}
 }
 
@@ -23,24 +45,85 @@ type cotaCell struct {
 	outputData []byte
 }
 
-func (c CotaWitnessArgsParser) Parse(tx *ckbTypes.Transaction, txIndex uint32, cotaType SystemScript) ([]biz.Entry, error) {
+func (c CotaWitnessArgsParser) Parse(ctx context.Context, tx *ckbTypes.Transaction, txIndex uint32, cotaType SystemScriptList) ([]biz.Entry, error) {
 	if !c.hasCotaCell(tx.Outputs, cotaType) {
 		return nil, nil
 	}
-	return c.cotaEntries(tx, txIndex, cotaType)
+	return c.cotaEntries(ctx, tx, txIndex, cotaType)
+}
+
+// ResolveInputs loads the previous outputs of all inputs of the cota transactions in txs into the cache
+// with a single batch request or a single cota_cells query, so that parsing the transactions afterwards
+// needs no further lookups
+func (c CotaWitnessArgsParser) ResolveInputs(ctx context.Context, txs []*ckbTypes.Transaction, cotaType SystemScriptList) error {
+	var candidates []*ckbTypes.Transaction
+	for _, tx := range txs {
+		if c.hasCotaCell(tx.Outputs, cotaType) {
+			candidates = append(candidates, tx)
+			// later transactions of the same block may spend these outputs
+			c.cache.addTransaction(tx)
+		}
+	}
+	var missing []*ckbTypes.OutPoint
+	for _, tx := range candidates {
+		for _, input := range tx.Inputs {
+			prevOutpoint := input.PreviousOutput
+			if _, ok := c.cache.get(prevOutpoint.TxHash, prevOutpoint.Index); ok {
+				metrics.InputCacheHits.Add(1)
+				continue
+			}
+			metrics.InputCacheMisses.Add(1)
+			missing = append(missing, prevOutpoint)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if c.localInputs {
+		return c.resolveLocalInputs(ctx, missing)
+	}
+	return fetchTransactions(ctx, c.client, c.cache, missing)
+}
+
+// fetchTransactions loads the transactions of the out points from the node into the cache with a single batch request
+func fetchTransactions(ctx context.Context, client *CkbNodeClient, cache *outputCache, outPoints []*ckbTypes.OutPoint) error {
+	requested := make(map[ckbTypes.Hash]bool)
+	var batch []ckbTypes.BatchTransactionItem
+	for _, prevOutpoint := range outPoints {
+		if requested[prevOutpoint.TxHash] {
+			continue
+		}
+		requested[prevOutpoint.TxHash] = true
+		batch = append(batch, ckbTypes.BatchTransactionItem{Hash: prevOutpoint.TxHash})
+	}
+	metrics.RpcCalls.Add("batch_get_transaction", 1)
+	metrics.RpcBatchItems.Add("get_transaction", int64(len(batch)))
+	if err := client.Rpc.BatchTransactions(ctx, batch); err != nil {
+		return err
+	}
+	for _, item := range batch {
+		if item.Error != nil {
+			return item.Error
+		}
+		if item.Result == nil || item.Result.Transaction == nil {
+			return fmt.Errorf("transaction %s not found", item.Hash.String())
+		}
+		cache.addTransaction(item.Result.Transaction)
+	}
+	return nil
 }
 
-func (c CotaWitnessArgsParser) isCotaCell(output *ckbTypes.CellOutput, cotaType SystemScript) bool {
+func (c CotaWitnessArgsParser) isCotaCell(output *ckbTypes.CellOutput, cotaType SystemScriptList) bool {
 	if output.Type == nil {
 		return false
 	}
-	return output.Type.CodeHash == cotaType.CodeHash && output.Type.HashType == cotaType.HashType
+	return cotaType.matchesCode(output.Type)
 }
 
 // inputs 中 cota cells 的个数一定与 outputs 中 cota cells 的个数相等
 // 批量注册多个 cota cell 的时候 input 里可能没有 cota cell
-func (c CotaWitnessArgsParser) cotaEntries(tx *ckbTypes.Transaction, txIndex uint32, cotaType SystemScript) ([]biz.Entry, error) {
-	inputCotaCellGroups, err := c.inputCotaCellGroups(tx.Inputs, cotaType)
+func (c CotaWitnessArgsParser) cotaEntries(ctx context.Context, tx *ckbTypes.Transaction, txIndex uint32, cotaType SystemScriptList) ([]biz.Entry, error) {
+	inputCotaCellGroups, err := c.inputCotaCellGroups(ctx, tx.Inputs, cotaType)
 	if err != nil {
 		return nil, err
 	}
@@ -52,8 +135,17 @@ func (c CotaWitnessArgsParser) cotaEntries(tx *ckbTypes.Transaction, txIndex uin
 	var cotaCellsIndex int
 	for typeHash, inputCotas := range inputCotaCellGroups {
 		outputGroupCotaCells := outputCotaCellGroups[typeHash]
+		if len(outputGroupCotaCells) == 0 {
+			return nil, biz.MalformedEntry(txIndex, fmt.Errorf("no output cota cell of type hash %s", typeHash))
+		}
 		firstCotaAtOutputGroup := outputGroupCotaCells[0]
 		firstCotaAtInputGroup := inputCotas[0]
+		if len(firstCotaAtOutputGroup.outputData) == 0 {
+			return nil, biz.MalformedEntry(txIndex, errors.New("cota cell without output data"))
+		}
+		if firstCotaAtInputGroup.index >= len(tx.Witnesses) {
+			return nil, biz.MalformedEntry(txIndex, fmt.Errorf("no witness at index %d", firstCotaAtInputGroup.index))
+		}
 
 		cotaCells[cotaCellsIndex] = cotaCell{
 			output:     firstCotaAtOutputGroup.output,
@@ -70,37 +162,43 @@ func (c CotaWitnessArgsParser) cotaEntries(tx *ckbTypes.Transaction, txIndex uin
 		if len(witness) == 0 {
 			continue
 		}
-		witnessArgs := blockchain.WitnessArgsFromSliceUnchecked(witness)
+		witnessArgs, err := blockchain.WitnessArgsFromSlice(witness, true)
+		if err != nil {
+			return nil, biz.MalformedEntry(txIndex, err)
+		}
+		scriptVersion := cotaType.versionOf(cotaCell.output.Type)
 		if witnessArgs.OutputType().IsSome() {
 			outputType, err := witnessArgs.OutputType().IntoBytes()
 			if err != nil {
-				return nil, err
+				return nil, biz.MalformedEntry(txIndex, err)
 			}
 			entries = append(entries, biz.Entry{
-				OutputType: outputType.RawData(),
-				LockScript: cotaCell.output.Lock,
-				TxIndex:    txIndex,
-				Version:    cotaCell.outputData[0],
+				OutputType:    outputType.RawData(),
+				LockScript:    cotaCell.output.Lock,
+				TxIndex:       txIndex,
+				Version:       cotaCell.outputData[0],
+				ScriptVersion: scriptVersion,
 			})
 		}
 		if witnessArgs.InputType().IsSome() {
 			inputType, err := witnessArgs.InputType().IntoBytes()
 			if err != nil {
-				return nil, err
+				return nil, biz.MalformedEntry(txIndex, err)
 			}
 			entries = append(entries, biz.Entry{
-				InputType:  inputType.RawData(),
-				LockScript: cotaCell.output.Lock,
-				TxIndex:    txIndex,
-				Version:    cotaCell.outputData[0],
+				InputType:     inputType.RawData(),
+				LockScript:    cotaCell.output.Lock,
+				TxIndex:       txIndex,
+				Version:       cotaCell.outputData[0],
+				ScriptVersion: scriptVersion,
 			})
 		}
 	}
 	return entries, nil
 }
 
-func (c CotaWitnessArgsParser) inputCotaCellGroups(inputs []*ckbTypes.CellInput, cotaType SystemScript) (map[string][]cotaCell, error) {
-	cotaCells, err := c.inputCotaCells(inputs, cotaType)
+func (c CotaWitnessArgsParser) inputCotaCellGroups(ctx context.Context, inputs []*ckbTypes.CellInput, cotaType SystemScriptList) (map[string][]cotaCell, error) {
+	cotaCells, err := c.inputCotaCells(ctx, inputs, cotaType)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +216,7 @@ func (c CotaWitnessArgsParser) inputCotaCellGroups(inputs []*ckbTypes.CellInput,
 	return group, nil
 }
 
-func (c CotaWitnessArgsParser) hasCotaCell(outputs []*ckbTypes.CellOutput, cotaType SystemScript) (result bool) {
+func (c CotaWitnessArgsParser) hasCotaCell(outputs []*ckbTypes.CellOutput, cotaType SystemScriptList) (result bool) {
 	for _, output := range outputs {
 		if result = c.isCotaCell(output, cotaType); result {
 			break
@@ -127,15 +225,13 @@ func (c CotaWitnessArgsParser) hasCotaCell(outputs []*ckbTypes.CellOutput, cotaT
 	return result
 }
 
-func (c CotaWitnessArgsParser) inputCotaCells(inputs []*ckbTypes.CellInput, cotaType SystemScript) ([]cotaCell, error) {
+func (c CotaWitnessArgsParser) inputCotaCells(ctx context.Context, inputs []*ckbTypes.CellInput, cotaType SystemScriptList) ([]cotaCell, error) {
 	var cotaCells []cotaCell
 	for i := 0; i < len(inputs); i++ {
-		prevOutpoint := inputs[i].PreviousOutput
-		prevTx, err := c.client.Rpc.GetTransaction(context.TODO(), prevOutpoint.TxHash)
+		prevCellOutput, err := c.previousOutput(ctx, inputs[i].PreviousOutput)
 		if err != nil {
 			return nil, err
 		}
-		prevCellOutput := prevTx.Transaction.Outputs[prevOutpoint.Index]
 		if c.isCotaCell(prevCellOutput, cotaType) {
 			cotaCells = append(cotaCells, cotaCell{
 				output: prevCellOutput,
@@ -146,7 +242,89 @@ func (c CotaWitnessArgsParser) inputCotaCells(inputs []*ckbTypes.CellInput, cota
 	return cotaCells, nil
 }
 
-func (c CotaWitnessArgsParser) outputCotaCells(outputs []*ckbTypes.CellOutput, outputsData [][]byte, cotaType SystemScript) ([]cotaCell, error) {
+// resolveLocalInputs looks the out points up in the cota cell index. When the index is complete the ones not found
+// are not cota cells and are cached as outputs without type script, otherwise they are fetched from the node, a cota
+// cell created before the index started would be missed.
+func (c CotaWitnessArgsParser) resolveLocalInputs(ctx context.Context, outPoints []*ckbTypes.OutPoint) error {
+	cellOutPoints := make([]biz.CellOutPoint, len(outPoints))
+	for i, outPoint := range outPoints {
+		cellOutPoints[i] = biz.CellOutPoint{TxHash: outPoint.TxHash.String()[2:], Index: uint32(outPoint.Index)}
+	}
+	metrics.LocalInputQueries.Add(1)
+	cells, err := c.cotaCellUsecase.FindCotaCells(ctx, cellOutPoints)
+	if err != nil {
+		return err
+	}
+	indexed := make(map[biz.CellOutPoint]bool, len(cells))
+	for _, cell := range cells {
+		output, err := cotaCellOutput(cell)
+		if err != nil {
+			return err
+		}
+		c.cache.add(ckbTypes.HexToHash(cell.TxHash), uint(cell.OutputIndex), output)
+		indexed[biz.CellOutPoint{TxHash: cell.TxHash, Index: cell.OutputIndex}] = true
+	}
+	var unindexed []*ckbTypes.OutPoint
+	for i, outPoint := range outPoints {
+		if !indexed[cellOutPoints[i]] {
+			unindexed = append(unindexed, outPoint)
+		}
+	}
+	if len(unindexed) == 0 {
+		return nil
+	}
+	complete, err := c.indexComplete(ctx)
+	if err != nil {
+		return err
+	}
+	if !complete {
+		return fetchTransactions(ctx, c.client, c.cache, unindexed)
+	}
+	for _, outPoint := range unindexed {
+		c.cache.add(outPoint.TxHash, outPoint.Index, &ckbTypes.CellOutput{})
+	}
+	return nil
+}
+
+func (c CotaWitnessArgsParser) indexComplete(ctx context.Context) (bool, error) {
+	c.coverage.mu.Lock()
+	defer c.coverage.mu.Unlock()
+	if !c.coverage.known {
+		complete, err := c.cotaCellUsecase.Complete(ctx)
+		if err != nil {
+			return false, err
+		}
+		c.coverage.known, c.coverage.complete = true, complete
+	}
+	return c.coverage.complete, nil
+}
+
+// previousOutput returns the output spent by an input, falling back to the node or the cota cell index
+// when it was not resolved beforehand
+func (c CotaWitnessArgsParser) previousOutput(ctx context.Context, prevOutpoint *ckbTypes.OutPoint) (*ckbTypes.CellOutput, error) {
+	if output, ok := c.cache.get(prevOutpoint.TxHash, prevOutpoint.Index); ok {
+		return output, nil
+	}
+	if c.localInputs {
+		if err := c.resolveLocalInputs(ctx, []*ckbTypes.OutPoint{prevOutpoint}); err != nil {
+			return nil, err
+		}
+		output, ok := c.cache.get(prevOutpoint.TxHash, prevOutpoint.Index)
+		if !ok {
+			return nil, fmt.Errorf("previous output %s-%d was evicted before use", prevOutpoint.TxHash.String(), prevOutpoint.Index)
+		}
+		return output, nil
+	}
+	metrics.RpcCalls.Add("get_transaction", 1)
+	prevTx, err := c.client.Rpc.GetTransaction(ctx, prevOutpoint.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.addTransaction(prevTx.Transaction)
+	return prevTx.Transaction.Outputs[prevOutpoint.Index], nil
+}
+
+func (c CotaWitnessArgsParser) outputCotaCells(outputs []*ckbTypes.CellOutput, outputsData [][]byte, cotaType SystemScriptList) ([]cotaCell, error) {
 	var cotaCells []cotaCell
 	for i := 0; i < len(outputs); i++ {
 		if c.isCotaCell(outputs[i], cotaType) {
@@ -160,7 +338,7 @@ func (c CotaWitnessArgsParser) outputCotaCells(outputs []*ckbTypes.CellOutput, o
 	return cotaCells, nil
 }
 
-func (c CotaWitnessArgsParser) outputCotaCellGroups(outputs []*ckbTypes.CellOutput, outputsData [][]byte, cotaType SystemScript) (map[string][]cotaCell, error) {
+func (c CotaWitnessArgsParser) outputCotaCellGroups(outputs []*ckbTypes.CellOutput, outputsData [][]byte, cotaType SystemScriptList) (map[string][]cotaCell, error) {
 	cotaCells, err := c.outputCotaCells(outputs, outputsData, cotaType)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,156 @@
+// Package cotaext holds the parts of cota missing from cota-smt-go: the root of the cota smt.
+package cotaext
+
+import "github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+
+const (
+	mergeNormal byte = 1
+	mergeZeros  byte = 2
+)
+
+// SmtLeaf is a leaf of a cota smt, a leaf with a zero value is absent from the tree
+type SmtLeaf struct {
+	Key   [32]byte
+	Value [32]byte
+}
+
+// mergeValue is a node of the tree, a node with a single non empty subtree is kept as the base node below the zero
+// subtrees like the MergeValue of sparse-merkle-tree 0.5
+type mergeValue struct {
+	value     [32]byte
+	zeroBits  [32]byte
+	zeroCount uint8
+	withZero  bool
+}
+
+func (m mergeValue) isZero() bool {
+	return !m.withZero && m.value == [32]byte{}
+}
+
+func (m mergeValue) hash() ([32]byte, error) {
+	if !m.withZero {
+		return m.value, nil
+	}
+	return blake256([]byte{mergeZeros}, m.value[:], m.zeroBits[:], []byte{m.zeroCount})
+}
+
+// SmtRoot returns the root of the sparse merkle tree of the leaves as computed by the cota scripts, hashing with the
+// ckb blake2b. A later leaf replaces an earlier one of the same key.
+func SmtRoot(leaves []SmtLeaf) ([32]byte, error) {
+	index := make(map[[32]byte]int)
+	var unique []SmtLeaf
+	for _, leaf := range leaves {
+		if i, ok := index[leaf.Key]; ok {
+			unique[i] = leaf
+			continue
+		}
+		index[leaf.Key] = len(unique)
+		unique = append(unique, leaf)
+	}
+	present := unique[:0]
+	for _, leaf := range unique {
+		if leaf.Value != ([32]byte{}) {
+			present = append(present, leaf)
+		}
+	}
+	root, err := subtree(255, present)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return root.hash()
+}
+
+// subtree merges the leaves sharing the key bits above height
+func subtree(height int, leaves []SmtLeaf) (mergeValue, error) {
+	if len(leaves) == 0 {
+		return mergeValue{}, nil
+	}
+	if height < 0 {
+		return mergeValue{value: leaves[0].Value}, nil
+	}
+	var left, right []SmtLeaf
+	for _, leaf := range leaves {
+		if bit(leaf.Key, height) {
+			right = append(right, leaf)
+		} else {
+			left = append(left, leaf)
+		}
+	}
+	lhs, err := subtree(height-1, left)
+	if err != nil {
+		return mergeValue{}, err
+	}
+	rhs, err := subtree(height-1, right)
+	if err != nil {
+		return mergeValue{}, err
+	}
+	return merge(uint8(height), parentPath(leaves[0].Key, height), lhs, rhs)
+}
+
+func merge(height uint8, nodeKey [32]byte, lhs, rhs mergeValue) (mergeValue, error) {
+	if lhs.isZero() && rhs.isZero() {
+		return mergeValue{}, nil
+	}
+	if lhs.isZero() {
+		return mergeWithZero(height, nodeKey, rhs, true)
+	}
+	if rhs.isZero() {
+		return mergeWithZero(height, nodeKey, lhs, false)
+	}
+	lhsHash, err := lhs.hash()
+	if err != nil {
+		return mergeValue{}, err
+	}
+	rhsHash, err := rhs.hash()
+	if err != nil {
+		return mergeValue{}, err
+	}
+	value, err := blake256([]byte{mergeNormal, height}, nodeKey[:], lhsHash[:], rhsHash[:])
+	return mergeValue{value: value}, err
+}
+
+// mergeWithZero merges the node with an empty sibling, setBit tells that the node is the right child
+func mergeWithZero(height uint8, nodeKey [32]byte, node mergeValue, setBit bool) (mergeValue, error) {
+	if !node.withZero {
+		baseNode, err := blake256([]byte{height}, nodeKey[:], node.value[:])
+		if err != nil {
+			return mergeValue{}, err
+		}
+		node = mergeValue{value: baseNode, withZero: true}
+	}
+	if setBit {
+		node.zeroBits[height/8] |= 1 << (height % 8)
+	}
+	node.zeroCount++
+	return node, nil
+}
+
+func bit(key [32]byte, i int) bool {
+	return key[i/8]>>(i%8)&1 == 1
+}
+
+// parentPath clears the key bits at and below height
+func parentPath(key [32]byte, height int) [32]byte {
+	var path [32]byte
+	if height == 255 {
+		return path
+	}
+	start := height + 1
+	copy(path[start/8:], key[start/8:])
+	path[start/8] &= 0xFF << (start % 8)
+	return path
+}
+
+func blake256(parts ...[]byte) ([32]byte, error) {
+	var data []byte
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	var hash [32]byte
+	sum, err := blake2b.Blake256(data)
+	if err != nil {
+		return hash, err
+	}
+	copy(hash[:], sum)
+	return hash, nil
+}
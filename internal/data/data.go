@@ -2,18 +2,19 @@ package data
 
 import (
 	"context"
-	"encoding/hex"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	mMsql "github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/google/wire"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 	"github.com/nervosnetwork/ckb-sdk-go/rpc"
-	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -22,7 +23,12 @@ import (
 var ProviderSet = wire.NewSet(NewData, NewDBMigration, NewCheckInfoRepo, NewRegisterCotaKvPairRepo,
 	NewDefineCotaNftKvPairRepo, NewHoldCotaNftKvPairRepo, NewWithdrawCotaNftKvPairRepo, NewClaimedCotaNftKvPairRepo,
 	NewKvPairRepo, NewSystemScripts, NewCkbNodeClient, NewBlockSyncer, NewMetadataSyncer, NewCotaWitnessArgsParser,
-	NewMintCotaKvPairRepo, NewTransferCotaKvPairRepo, NewIssuerInfoRepo, NewClassInfoRepo, NewInvalidDateRepo)
+	NewMintCotaKvPairRepo, NewTransferCotaKvPairRepo, NewIssuerInfoRepo, NewClassInfoRepo, NewInvalidDateRepo,
+	NewBlockHeaderRepo, NewChainReorganizer, NewUnconfirmedKvPairRepo, NewUnifiedSyncer,
+	NewCotaCellRepo, NewTipSubscriber, NewSparseScanner, NewBootstrapper,
+	NewSyncFenceRepo, NewResyncer, NewChainIdentityRepo, NewChainGuard,
+	NewDeadLetterRepo, NewRegistryParser, NewSmtRootRepo,
+	NewAuditRepo)
 
 type Data struct {
 	db *gorm.DB
@@ -61,30 +67,91 @@ func NewData(conf *config.Database, logger *logger.Logger) (*Data, func(), error
 }
 
 type CkbNodeClient struct {
-	Rpc  rpc.Client
-	Mode string
+	Rpc        BlockSource
+	Mode       string
+	HasIndexer bool
+	// Breaker guards the node calls, nil when the blocks are replayed from an archive
+	Breaker *CircuitBreaker
 }
 
 func NewCkbNodeClient(conf *config.CkbNode, logger *logger.Logger) (*CkbNodeClient, error) {
-	rpcURL := os.Getenv("RPC_URL")
-	if rpcURL == "" {
-		rpcURL = conf.RpcUrl
+	archiveDir := os.Getenv("ARCHIVE_DIR")
+	if archiveDir == "" {
+		archiveDir = conf.ArchiveDir
+	}
+	if conf.BlockSource == config.FileBlockSource {
+		source, err := newFileBlockSource(archiveDir)
+		if err != nil {
+			logger.Errorf(context.TODO(), "failed to open the block archive %s: %v", archiveDir, err)
+			return nil, err
+		}
+		return &CkbNodeClient{
+			Rpc:  source,
+			Mode: conf.Mode,
+		}, nil
 	}
 
-	client, err := rpc.Dial(rpcURL)
-	if err != nil {
-		logger.Errorf(context.TODO(), "failed to connect to the ckb node")
-		return nil, err
+	var rpcURLs []string
+	if rpcURL := os.Getenv("RPC_URL"); rpcURL != "" {
+		rpcURLs = strings.Split(rpcURL, ",")
+	} else if len(conf.RpcUrls) > 0 {
+		rpcURLs = conf.RpcUrls
+	} else {
+		rpcURLs = []string{conf.RpcUrl}
+	}
+
+	indexerURL := os.Getenv("INDEXER_URL")
+	if indexerURL == "" {
+		indexerURL = conf.IndexerUrl
+	}
+
+	endpoints := make([]*rpcEndpoint, len(rpcURLs))
+	for i, rpcURL := range rpcURLs {
+		var client rpc.Client
+		var err error
+		if indexerURL == "" {
+			client, err = rpc.Dial(rpcURL)
+		} else {
+			client, err = rpc.DialWithIndexer(rpcURL, indexerURL)
+		}
+		if err != nil {
+			logger.Errorf(context.TODO(), "failed to connect to the ckb node %s", rpcURL)
+			return nil, err
+		}
+		endpoints[i] = &rpcEndpoint{url: rpcURL, source: client}
+		if conf.MoleculeBlocks {
+			endpoints[i].source = moleculeBlockSource{Client: client}
+		}
+	}
+	source := endpoints[0].source
+	if len(endpoints) > 1 {
+		source = newEndpointPool(endpoints, conf.MaxTipLag, conf.HealthCheckInterval, logger)
+	}
+	policy := newPolicyBlockSource(source, conf, logger)
+	source = policy
+	var err error
+	if conf.Record {
+		if source, err = newRecordingBlockSource(source, archiveDir, conf.ArchiveFormat); err != nil {
+			logger.Errorf(context.TODO(), "failed to open the block archive %s: %v", archiveDir, err)
+			return nil, err
+		}
 	}
 	return &CkbNodeClient{
-		Rpc:  client,
-		Mode: conf.Mode,
+		Rpc:        source,
+		Mode:       conf.Mode,
+		HasIndexer: indexerURL != "",
+		Breaker:    policy.breaker,
 	}, nil
 }
 
+// ErrSchemaNotCurrent tells that migrations are missing or that one failed halfway
+var ErrSchemaNotCurrent = errors.New("database schema is not current")
+
 type DBMigration struct {
 	data   *Data
 	logger *logger.Logger
+	// sourceURL locates the migrations, relative to the working directory
+	sourceURL string
 }
 
 func (m *DBMigration) Up() error {
@@ -97,7 +164,7 @@ func (m *DBMigration) Up() error {
 	if err != nil {
 		return err
 	}
-	migration, err := migrate.NewWithDatabaseInstance("file://./internal/db/migrations", m.data.db.Migrator().CurrentDatabase(), driver)
+	migration, err := migrate.NewWithDatabaseInstance(m.sourceURL, m.data.db.Migrator().CurrentDatabase(), driver)
 	if err != nil {
 		return err
 	}
@@ -109,80 +176,52 @@ func (m *DBMigration) Up() error {
 	return nil
 }
 
-type SystemScriptOption func(o *SystemScripts)
-
-type SystemScript struct {
-	CodeHash ckbTypes.Hash
-	HashType ckbTypes.ScriptHashType
-	Args     []byte
-	OutPoint ckbTypes.OutPoint
-	DepType  ckbTypes.DepType
-}
-
-type SystemScripts struct {
-	CotaRegistryType SystemScript
-	CotaType         SystemScript
-}
-
-func NewSystemScripts(client *CkbNodeClient, logger *logger.Logger) SystemScripts {
-	chainInfo, err := client.Rpc.GetBlockchainInfo(context.Background())
+// Current fails with ErrSchemaNotCurrent unless the latest migration is applied, for the commands that must not
+// change the schema
+func (m *DBMigration) Current() error {
+	sqlDB, err := m.data.db.DB()
 	if err != nil {
-		logger.Fatalf(context.Background(), "RPC get_blockchain_info error")
+		m.logger.Errorf(context.TODO(), "failed get sql db: %v", err)
+		return err
 	}
-	return SystemScripts{
-		CotaRegistryType: cotaRegistryScript(chainInfo.Chain),
-		CotaType:         cotaTypeScript(chainInfo.Chain),
+	driver, err := mMsql.WithInstance(sqlDB, &mMsql.Config{})
+	if err != nil {
+		return err
 	}
-}
-
-func cotaRegistryScript(chain string) SystemScript {
-	if chain == "ckb" {
-		args, _ := hex.DecodeString("563631b49cee549f3585ab4dde5f9d590f507f1f")
-		return SystemScript{
-			CodeHash: ckbTypes.HexToHash("0x90ca618be6c15f5857d3cbd09f9f24ca6770af047ba9ee70989ec3b229419ac7"),
-			HashType: ckbTypes.HashTypeType,
-			Args:     args,
-			OutPoint: ckbTypes.OutPoint{
-				TxHash: ckbTypes.HexToHash("0x42a5b04df6ff0e2819ec6b814d33a028ed1593bc9e1cca463f679af555dce106"),
-				Index:  0,
-			},
-			DepType: ckbTypes.DepTypeDepGroup,
-		}
+	migration, err := migrate.NewWithDatabaseInstance(m.sourceURL, m.data.db.Migrator().CurrentDatabase(), driver)
+	if err != nil {
+		return err
 	}
-	args, _ := hex.DecodeString("f9910364e0ca81a0e074f3aa42fe78cfcc880da6")
-	return SystemScript{
-		CodeHash: ckbTypes.HexToHash("0x9302db6cc1344b81a5efee06962abcb40427ecfcbe69d471b01b2658ed948075"),
-		HashType: ckbTypes.HashTypeType,
-		Args:     args,
-		OutPoint: ckbTypes.OutPoint{
-			TxHash: ckbTypes.HexToHash("0x698f2a29021ebd741b4a38b4a5f8fa3686103ba66773e7549b204a67db015ba0"),
-			Index:  0,
-		},
-		DepType: ckbTypes.DepTypeDepGroup,
+	version, dirty, err := migration.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
 	}
-}
-
-func cotaTypeScript(chain string) SystemScript {
-	if chain == "ckb" {
-		return SystemScript{
-			CodeHash: ckbTypes.HexToHash("0x1122a4fb54697cf2e6e3a96c9d80fd398a936559b90954c6e88eb7ba0cf652df"),
-			HashType: ckbTypes.HashTypeType,
-			OutPoint: ckbTypes.OutPoint{
-				TxHash: ckbTypes.HexToHash("0x42a5b04df6ff0e2819ec6b814d33a028ed1593bc9e1cca463f679af555dce106"),
-				Index:  0,
-			},
-			DepType: ckbTypes.DepTypeDepGroup,
+	migrations, err := source.Open(m.sourceURL)
+	if err != nil {
+		return err
+	}
+	defer migrations.Close()
+	latest, err := migrations.First()
+	if err != nil {
+		return err
+	}
+	for {
+		next, err := migrations.Next(latest)
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return err
 		}
+		latest = next
 	}
-	return SystemScript{
-		CodeHash: ckbTypes.HexToHash("0x89cd8003a0eaf8e65e0c31525b7d1d5c1becefd2ea75bb4cff87810ae37764d8"),
-		HashType: ckbTypes.HashTypeType,
-		OutPoint: ckbTypes.OutPoint{
-			TxHash: ckbTypes.HexToHash("0x698f2a29021ebd741b4a38b4a5f8fa3686103ba66773e7549b204a67db015ba0"),
-			Index:  0,
-		},
-		DepType: ckbTypes.DepTypeDepGroup,
+	if dirty {
+		return fmt.Errorf("%w: migration %d failed halfway", ErrSchemaNotCurrent, version)
 	}
+	if version != latest {
+		return fmt.Errorf("%w: at migration %d, the latest is %d", ErrSchemaNotCurrent, version, latest)
+	}
+	return nil
 }
 
 func (m *DBMigration) Down() error {
@@ -204,7 +243,8 @@ func (m *DBMigration) Down() error {
 
 func NewDBMigration(data *Data, logger *logger.Logger) *DBMigration {
 	return &DBMigration{
-		data:   data,
-		logger: logger,
+		data:      data,
+		logger:    logger,
+		sourceURL: "file://./internal/db/migrations",
 	}
 }
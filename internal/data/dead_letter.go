@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+	"gorm.io/gorm"
+)
+
+var _ biz.DeadLetterRepo = (*deadLetterRepo)(nil)
+
+type DeadLetter struct {
+	ID          uint `gorm:"primaryKey"`
+	BlockNumber uint64
+	TxIndex     uint32
+	TxHash      string
+	CheckType   biz.CheckType
+	ErrorKind   string
+	Error       string
+	Witnesses   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type deadLetterRepo struct {
+	data   *Data
+	logger *logger.Logger
+}
+
+func NewDeadLetterRepo(data *Data, logger *logger.Logger) biz.DeadLetterRepo {
+	return &deadLetterRepo{
+		data:   data,
+		logger: logger,
+	}
+}
+
+func (rp deadLetterRepo) FindDeadLetterBlockRange(ctx context.Context) (from uint64, to uint64, found bool, err error) {
+	var blockRange struct {
+		From  uint64
+		To    uint64
+		Count int64
+	}
+	err = rp.data.db.WithContext(ctx).Model(DeadLetter{}).
+		Select("min(block_number) as `from`, max(block_number) as `to`, count(*) as count").Scan(&blockRange).Error
+	return blockRange.From, blockRange.To, blockRange.Count > 0, err
+}
+
+// createDeadLetters stores the dead letters of the check type, the sync of the other check type stores its own
+func createDeadLetters(ctx context.Context, tx *gorm.DB, checkType biz.CheckType, kvPair *biz.KvPair) error {
+	var deadLetters []DeadLetter
+	for _, deadLetter := range kvPair.DeadLetters {
+		if deadLetter.CheckType != checkType {
+			continue
+		}
+		deadLetters = append(deadLetters, DeadLetter{
+			BlockNumber: deadLetter.BlockNumber,
+			TxIndex:     deadLetter.TxIndex,
+			TxHash:      deadLetter.TxHash,
+			CheckType:   deadLetter.CheckType,
+			ErrorKind:   deadLetter.ErrorKind,
+			Error:       deadLetter.Error,
+			Witnesses:   deadLetter.Witnesses,
+		})
+	}
+	if len(deadLetters) == 0 {
+		return nil
+	}
+	return tx.Model(DeadLetter{}).WithContext(ctx).Create(deadLetters).Error
+}
+
+func restoreDeadLetters(ctx context.Context, tx *gorm.DB, blockNumber uint64, checkType biz.CheckType) error {
+	return tx.WithContext(ctx).Where("block_number = ? and check_type = ?", blockNumber, checkType).Delete(DeadLetter{}).Error
+}
+
+// entryPolicy applies app.entry_error_policy to the transactions whose entries fail to parse. The halt policy stops
+// the sync at the block, the quarantine policy stores the transaction as a dead letter and goes on without it.
+type entryPolicy struct {
+	quarantine bool
+	logger     *logger.Logger
+}
+
+func newEntryPolicy(appConf *config.App, logger *logger.Logger) entryPolicy {
+	return entryPolicy{
+		quarantine: appConf.EntryErrorPolicy == config.QuarantineEntryErrorPolicy,
+		logger:     logger,
+	}
+}
+
+// handle returns the dead letter of the transaction when err is a biz.EntryError that the policy quarantines,
+// any other error is returned as is
+func (p entryPolicy) handle(ctx context.Context, block *ckbTypes.Block, checkType biz.CheckType, err error) (biz.DeadLetter, error) {
+	var entryErr *biz.EntryError
+	if !p.quarantine || !errors.As(err, &entryErr) || int(entryErr.TxIndex) >= len(block.Transactions) {
+		return biz.DeadLetter{}, err
+	}
+	tx := block.Transactions[entryErr.TxIndex]
+	witnesses := make([]string, len(tx.Witnesses))
+	for i, witness := range tx.Witnesses {
+		witnesses[i] = "0x" + hex.EncodeToString(witness)
+	}
+	encoded, jsonErr := json.Marshal(witnesses)
+	if jsonErr != nil {
+		return biz.DeadLetter{}, jsonErr
+	}
+	p.logger.Errorf(ctx, "quarantined tx %s of block %d: %v", tx.Hash.String(), block.Header.Number, err)
+	metrics.DeadLetters.Add(checkType.String(), 1)
+	return biz.DeadLetter{
+		BlockNumber: block.Header.Number,
+		TxIndex:     entryErr.TxIndex,
+		TxHash:      tx.Hash.String()[2:],
+		CheckType:   checkType,
+		ErrorKind:   entryErr.Kind.Error(),
+		Error:       entryErr.Err.Error(),
+		Witnesses:   string(encoded),
+	}, nil
+}
+
+// recoverEntry turns a panic of the molecule accessors, which read the bytes the checked constructors left unverified,
+// into a malformed entry error of the transaction
+func recoverEntry(txIndex uint32, err *error) {
+	if r := recover(); r != nil {
+		*err = biz.MalformedEntry(txIndex, fmt.Errorf("panic: %v", r))
+	}
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func Test_entryPolicy_handle(t *testing.T) {
+	block := &ckbTypes.Block{
+		Header: &ckbTypes.Header{Number: 100},
+		Transactions: []*ckbTypes.Transaction{
+			{Hash: ckbTypes.HexToHash("0x01")},
+			{Hash: ckbTypes.HexToHash("0x02"), Witnesses: [][]byte{{0xab, 0xcd}, {}}},
+		},
+	}
+	// a define entry cut short after the action byte
+	_, malformed := defineCotaNftKvPairRepo{}.ParseDefineCotaEntries(100, biz.Entry{InputType: []byte{0x01, 0x02}, TxIndex: 1})
+	tests := []struct {
+		name           string
+		policy         string
+		err            error
+		wantDeadLetter biz.DeadLetter
+		wantErr        error
+	}{
+		{
+			name:    "should halt on a malformed entry by default",
+			err:     malformed,
+			wantErr: biz.ErrMalformedEntry,
+		},
+		{
+			name:   "should quarantine a malformed entry",
+			policy: config.QuarantineEntryErrorPolicy,
+			err:    malformed,
+			wantDeadLetter: biz.DeadLetter{
+				BlockNumber: 100,
+				TxIndex:     1,
+				TxHash:      "0000000000000000000000000000000000000000000000000000000000000002",
+				CheckType:   biz.SyncBlock,
+				ErrorKind:   biz.ErrMalformedEntry.Error(),
+				Witnesses:   `["0xabcd","0x"]`,
+			},
+		},
+		{
+			name:   "should quarantine an invalid entry",
+			policy: config.QuarantineEntryErrorPolicy,
+			err:    biz.InvalidEntry(0, errors.New("name too long")),
+			wantDeadLetter: biz.DeadLetter{
+				BlockNumber: 100,
+				TxHash:      "0000000000000000000000000000000000000000000000000000000000000001",
+				CheckType:   biz.SyncBlock,
+				ErrorKind:   biz.ErrInvalidEntry.Error(),
+				Error:       "name too long",
+				Witnesses:   "[]",
+			},
+		},
+		{
+			name:    "should not quarantine other errors",
+			policy:  config.QuarantineEntryErrorPolicy,
+			err:     errNodeDown,
+			wantErr: errNodeDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newEntryPolicy(&config.App{EntryErrorPolicy: tt.policy}, logger.NewLogger(io.Discard, "", 0))
+			got, err := policy.handle(context.Background(), block, biz.SyncBlock, tt.err)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("handle() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantDeadLetter.Error == "" {
+				got.Error = ""
+			}
+			if got != tt.wantDeadLetter {
+				t.Errorf("handle() = %+v, want %+v", got, tt.wantDeadLetter)
+			}
+		})
+	}
+}
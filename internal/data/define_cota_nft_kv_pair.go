@@ -14,32 +14,35 @@ import (
 var _ biz.DefineCotaNftKvPairRepo = (*defineCotaNftKvPairRepo)(nil)
 
 type DefineCotaNftKvPair struct {
-	ID          uint `gorm:"primaryKey"`
-	BlockNumber uint64
-	CotaId      string
-	Total       uint32
-	Issued      uint32
-	Configure   uint8
-	LockHash    string
-	LockHashCRC uint32
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID            uint `gorm:"primaryKey"`
+	BlockNumber   uint64
+	CotaId        string
+	Total         uint32
+	Issued        uint32
+	Configure     uint8
+	LockHash      string
+	LockHashCRC   uint32
+	ScriptVersion uint8
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 type DefineCotaNftKvPairVersion struct {
-	ID             uint `gorm:"primaryKey"`
-	OldBlockNumber uint64
-	BlockNumber    uint64
-	CotaId         string
-	Total          uint32
-	OldIssued      uint32
-	Issued         uint32
-	Configure      uint8
-	LockHash       string
-	ActionType     uint8 //	0-create 1-update 2-delete
-	TxIndex        uint32
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	ID               uint `gorm:"primaryKey"`
+	OldBlockNumber   uint64
+	BlockNumber      uint64
+	CotaId           string
+	Total            uint32
+	OldIssued        uint32
+	Issued           uint32
+	Configure        uint8
+	LockHash         string
+	OldScriptVersion uint8
+	ScriptVersion    uint8
+	ActionType       uint8 //	0-create 1-update 2-delete
+	TxIndex          uint32
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 type defineCotaNftKvPairRepo struct {
@@ -69,7 +72,11 @@ func (rp defineCotaNftKvPairRepo) DeleteDefineCotaNftKvPairs(ctx context.Context
 }
 
 func (rp defineCotaNftKvPairRepo) ParseDefineCotaEntries(blockNumber uint64, entry biz.Entry) (defineCotas []biz.DefineCotaNftKvPair, err error) {
-	entries := smt.DefineCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.DefineCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	defineCotaKeyVec := entries.DefineKeys()
 	defineCotaValueVec := entries.DefineValues()
 	lockHash, err := entry.LockScript.Hash()
@@ -82,14 +89,15 @@ func (rp defineCotaNftKvPairRepo) ParseDefineCotaEntries(blockNumber uint64, ent
 		key := defineCotaKeyVec.Get(i)
 		value := defineCotaValueVec.Get(i)
 		defineCotas = append(defineCotas, biz.DefineCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.CotaId().RawData()),
-			Total:       binary.BigEndian.Uint32(value.Total().RawData()),
-			Issued:      binary.BigEndian.Uint32(value.Issued().RawData()),
-			Configure:   value.Configure().AsSlice()[0],
-			LockHash:    lockHashStr,
-			LockHashCRC: lockHashCRC32,
-			TxIndex:     entry.TxIndex,
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.CotaId().RawData()),
+			Total:         binary.BigEndian.Uint32(value.Total().RawData()),
+			Issued:        binary.BigEndian.Uint32(value.Issued().RawData()),
+			Configure:     value.Configure().AsSlice()[0],
+			LockHash:      lockHashStr,
+			LockHashCRC:   lockHashCRC32,
+			TxIndex:       entry.TxIndex,
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	return
@@ -0,0 +1,255 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+const (
+	defaultMaxTipLag           = 20
+	defaultHealthCheckInterval = 10 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+)
+
+type rpcEndpoint struct {
+	url     string
+	source  BlockSource
+	tip     uint64
+	latency time.Duration
+	// err is the error of the last health check or call, cleared by the next success
+	err error
+}
+
+var _ BlockSource = (*endpointPool)(nil)
+
+// endpointPool sends every call to a sticky primary endpoint. An endpoint is healthy when its last health check or
+// call succeeded and its tip is at most maxTipLag blocks behind the highest tip of the pool. A failed call is retried
+// on the healthy endpoints with the lowest latency first, and the endpoint that answers becomes the primary. Endpoints
+// lagging behind are refused until they catch up.
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*rpcEndpoint
+	primary   *rpcEndpoint
+	maxTipLag uint64
+	logger    *logger.Logger
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newEndpointPool(endpoints []*rpcEndpoint, maxTipLag uint64, interval time.Duration, logger *logger.Logger) *endpointPool {
+	if maxTipLag == 0 {
+		maxTipLag = defaultMaxTipLag
+	}
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	p := &endpointPool{
+		endpoints: endpoints,
+		primary:   endpoints[0],
+		maxTipLag: maxTipLag,
+		logger:    logger,
+		stop:      make(chan struct{}),
+	}
+	p.checkHealth()
+	go p.healthCheckLoop(interval)
+	return p
+}
+
+func (p *endpointPool) healthCheckLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkHealth()
+		}
+	}
+}
+
+// checkHealth fetches the tip of every endpoint concurrently and records the tip and the latency
+func (p *endpointPool) checkHealth() {
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *rpcEndpoint) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			tip, err := e.source.GetTipBlockNumber(ctx)
+			latency := time.Since(start)
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if err != nil {
+				if e.err == nil {
+					p.logger.Errorf(ctx, "rpc endpoint %s health check error: %v", e.url, err)
+				}
+				e.err = err
+				return
+			}
+			e.tip, e.latency, e.err = tip, latency, nil
+		}(e)
+	}
+	wg.Wait()
+}
+
+// candidates lists the endpoints to try in order: the primary while healthy, the other healthy endpoints by latency,
+// then the failed endpoints which are not known to lag
+func (p *endpointPool) candidates() []*rpcEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var maxTip uint64
+	for _, e := range p.endpoints {
+		if e.tip > maxTip {
+			maxTip = e.tip
+		}
+	}
+	var healthy, failed []*rpcEndpoint
+	for _, e := range p.endpoints {
+		if e.tip+p.maxTipLag < maxTip {
+			continue
+		}
+		if e.err != nil {
+			failed = append(failed, e)
+		} else if e != p.primary {
+			healthy = append(healthy, e)
+		}
+	}
+	sort.SliceStable(healthy, func(i, j int) bool {
+		return healthy[i].latency < healthy[j].latency
+	})
+	if p.primary.err == nil && p.primary.tip+p.maxTipLag >= maxTip {
+		healthy = append([]*rpcEndpoint{p.primary}, healthy...)
+	}
+	return append(healthy, failed...)
+}
+
+// do runs call on the candidates until one succeeds, errors a node answers the same way, like a missing block, and
+// the cancellation of ctx are returned without failover
+func (p *endpointPool) do(ctx context.Context, call func(source BlockSource) error) error {
+	err := errors.New("no rpc endpoint within the max tip lag")
+	for _, e := range p.candidates() {
+		err = call(e.source)
+		if err == nil {
+			p.succeeded(ctx, e)
+			return nil
+		}
+		if errors.Is(err, rpc.NotFound) || ctx.Err() != nil {
+			return err
+		}
+		p.mu.Lock()
+		e.err = err
+		p.mu.Unlock()
+		p.logger.Errorf(ctx, "rpc endpoint %s error: %v", e.url, err)
+	}
+	return err
+}
+
+func (p *endpointPool) succeeded(ctx context.Context, e *rpcEndpoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.err = nil
+	if p.primary != e {
+		p.logger.Infof(ctx, "rpc endpoint failover from %s to %s", p.primary.url, e.url)
+		metrics.RpcFailovers.Add(1)
+		p.primary = e
+	}
+}
+
+func (p *endpointPool) GetBlockchainInfo(ctx context.Context) (info *ckbTypes.BlockchainInfo, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		info, err = source.GetBlockchainInfo(ctx)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) GetTipBlockNumber(ctx context.Context) (tip uint64, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		tip, err = source.GetTipBlockNumber(ctx)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) GetBlockByNumber(ctx context.Context, number uint64) (block *ckbTypes.Block, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		block, err = source.GetBlockByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) GetBlockHash(ctx context.Context, number uint64) (hash *ckbTypes.Hash, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		hash, err = source.GetBlockHash(ctx, number)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) GetHeader(ctx context.Context, hash ckbTypes.Hash) (header *ckbTypes.Header, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		header, err = source.GetHeader(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) GetHeaderByNumber(ctx context.Context, number uint64) (header *ckbTypes.Header, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		header, err = source.GetHeaderByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) GetTransaction(ctx context.Context, hash ckbTypes.Hash) (tx *ckbTypes.TransactionWithStatus, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		tx, err = source.GetTransaction(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) BatchTransactions(ctx context.Context, batch []ckbTypes.BatchTransactionItem) error {
+	return p.do(ctx, func(source BlockSource) error {
+		return source.BatchTransactions(ctx, batch)
+	})
+}
+
+func (p *endpointPool) GetTip(ctx context.Context) (tip *indexer.TipHeader, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		tip, err = source.GetTip(ctx)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (txs *indexer.Transactions, err error) {
+	err = p.do(ctx, func(source BlockSource) error {
+		txs, err = source.GetTransactions(ctx, searchKey, order, limit, afterCursor)
+		return err
+	})
+	return
+}
+
+func (p *endpointPool) Close() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		for _, e := range p.endpoints {
+			e.source.Close()
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+var errNodeDown = errors.New("connection refused")
+
+// poolNode is a node at a fixed tip which can be taken down
+type poolNode struct {
+	rpc.Client
+	mu     sync.Mutex
+	tip    uint64
+	delay  time.Duration
+	down   bool
+	served int
+}
+
+func (n *poolNode) setDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down = down
+}
+
+func (n *poolNode) GetTipBlockNumber(_ context.Context) (uint64, error) {
+	time.Sleep(n.delay)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.down {
+		return 0, errNodeDown
+	}
+	return n.tip, nil
+}
+
+func (n *poolNode) GetBlockByNumber(_ context.Context, blockNumber uint64) (*ckbTypes.Block, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.down {
+		return nil, errNodeDown
+	}
+	if blockNumber > n.tip {
+		return nil, rpc.NotFound
+	}
+	n.served++
+	return &ckbTypes.Block{Header: &ckbTypes.Header{Number: blockNumber}}, nil
+}
+
+func (n *poolNode) Close() {}
+
+func Test_endpointPool(t *testing.T) {
+	tests := []struct {
+		name string
+		tips []uint64
+		// delays slow down the health checks of the nodes
+		delays []time.Duration
+		// down takes nodes down after the first health check
+		down []int
+		// recover brings the nodes back up and checks the health after the first call
+		recover     bool
+		blockNumber uint64
+		wantServed  []int
+		wantErr     error
+	}{
+		{
+			name:        "should keep the healthy primary",
+			tips:        []uint64{100, 100},
+			blockNumber: 90,
+			wantServed:  []int{1, 0},
+		},
+		{
+			name:        "should fail over to the fastest endpoint when the primary fails",
+			tips:        []uint64{100, 100, 100},
+			delays:      []time.Duration{0, 0, 10 * time.Millisecond},
+			down:        []int{0},
+			blockNumber: 90,
+			wantServed:  []int{0, 1, 0},
+		},
+		{
+			name:        "should stay on the new primary after the old one recovers",
+			tips:        []uint64{100, 100},
+			down:        []int{0},
+			recover:     true,
+			blockNumber: 90,
+			wantServed:  []int{0, 2},
+		},
+		{
+			name:        "should refuse an endpoint lagging behind",
+			tips:        []uint64{100, 50},
+			down:        []int{0},
+			blockNumber: 40,
+			wantServed:  []int{0, 0},
+			wantErr:     errNodeDown,
+		},
+		{
+			name:        "should not fail over on a missing block",
+			tips:        []uint64{100, 110},
+			blockNumber: 105,
+			wantServed:  []int{0, 0},
+			wantErr:     rpc.NotFound,
+		},
+		{
+			name:        "should fail when every endpoint fails",
+			tips:        []uint64{100, 100},
+			down:        []int{0, 1},
+			blockNumber: 90,
+			wantServed:  []int{0, 0},
+			wantErr:     errNodeDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			nodes := make([]*poolNode, len(tt.tips))
+			endpoints := make([]*rpcEndpoint, len(tt.tips))
+			for i, tip := range tt.tips {
+				nodes[i] = &poolNode{tip: tip}
+				if i < len(tt.delays) {
+					nodes[i].delay = tt.delays[i]
+				}
+				endpoints[i] = &rpcEndpoint{url: string(rune('a' + i)), source: nodes[i]}
+			}
+			pool := newEndpointPool(endpoints, 20, time.Hour, logger.NewLogger(io.Discard, "", 0))
+			defer pool.Close()
+			for _, i := range tt.down {
+				nodes[i].setDown(true)
+			}
+			_, err := pool.GetBlockByNumber(ctx, tt.blockNumber)
+			if tt.recover {
+				for _, node := range nodes {
+					node.setDown(false)
+				}
+				pool.checkHealth()
+				_, err = pool.GetBlockByNumber(ctx, tt.blockNumber)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetBlockByNumber() error = %v, want %v", err, tt.wantErr)
+			}
+			for i, node := range nodes {
+				if node.served != tt.wantServed[i] {
+					t.Errorf("endpoint %d served %d calls, want %d", i, node.served, tt.wantServed[i])
+				}
+			}
+		})
+	}
+}
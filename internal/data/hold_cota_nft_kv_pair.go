@@ -23,6 +23,7 @@ type HoldCotaNftKvPair struct {
 	Characteristic string
 	LockHash       string
 	LockHashCRC    uint32
+	ScriptVersion  uint8
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 }
@@ -40,6 +41,8 @@ type HoldCotaNftKvPairVersion struct {
 	Characteristic    string
 	OldLockHash       string
 	LockHash          string
+	OldScriptVersion  uint8
+	ScriptVersion     uint8
 	ActionType        uint8 //	0-create 1-update 2-delete
 	TxIndex           uint32
 	CreatedAt         time.Time
@@ -73,7 +76,11 @@ func (rp holdCotaNftKvPairRepo) DeleteHoldCotaNftKvPairs(ctx context.Context, bl
 }
 
 func (rp holdCotaNftKvPairRepo) ParseHoldCotaEntries(blockNumber uint64, entry biz.Entry) (holdCotas []biz.HoldCotaNftKvPair, err error) {
-	entries := smt.UpdateCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.UpdateCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	holdCotaKeyVec := entries.HoldKeys()
 	holdCotaValueVec := entries.HoldNewValues()
 	lockHash, err := entry.LockScript.Hash()
@@ -96,6 +103,7 @@ func (rp holdCotaNftKvPairRepo) ParseHoldCotaEntries(blockNumber uint64, entry b
 			LockHashCRC:    lockHashCRC32,
 			TxIndex:        entry.TxIndex,
 			UpdatedAt:      time.Now().UTC(),
+			ScriptVersion:  entry.ScriptVersion,
 		})
 	}
 	return
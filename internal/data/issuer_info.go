@@ -95,6 +95,16 @@ func (repo issuerInfoRepo) ParseIssuerInfo(blockNumber uint64, txIndex uint32, l
 	if localizationStr == "{}" {
 		localizationStr = ""
 	}
+	err = checkColumnLengths(txIndex, []column{
+		{"version", issuerInfo.Version, 40},
+		{"name", issuerInfo.Name, 255},
+		{"avatar", issuerInfo.Avatar, 500},
+		{"description", issuerInfo.Description, 1000},
+		{"localization", localizationStr, 1000},
+	})
+	if err != nil {
+		return
+	}
 	issuer = biz.IssuerInfo{
 		BlockNumber:  blockNumber,
 		LockHash:     lockHashStr,
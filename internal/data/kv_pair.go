@@ -3,668 +3,1054 @@ package data
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"hash/crc32"
+	"sort"
+	"strings"
 	"time"
 )
 
 var _ biz.KvPairRepo = (*kvPairRepo)(nil)
 
 type kvPairRepo struct {
-	data   *Data
-	logger *logger.Logger
+	data      *Data
+	smtPolicy smtPolicy
+	logger    *logger.Logger
 }
 
-func NewKvPairRepo(data *Data, logger *logger.Logger) biz.KvPairRepo {
+func NewKvPairRepo(data *Data, appConf *config.App, logger *logger.Logger) biz.KvPairRepo {
 	return &kvPairRepo{
-		data:   data,
-		logger: logger,
+		data:      data,
+		smtPolicy: newSmtPolicy(appConf, logger),
+		logger:    logger,
 	}
 }
 
 func (rp kvPairRepo) CreateCotaEntryKvPairs(ctx context.Context, checkInfo biz.CheckInfo, kvPair *biz.KvPair) error {
-	return rp.data.db.Transaction(func(tx *gorm.DB) error {
-		// create register cotas
-		if kvPair.HasRegisters() {
-			registers := make([]RegisterCotaKvPair, len(kvPair.Registers))
-			for i, register := range kvPair.Registers {
-				registers[i] = RegisterCotaKvPair{
-					BlockNumber: register.BlockNumber,
-					LockHash:    register.LockHash,
-				}
-			}
-			if err := tx.Model(RegisterCotaKvPair{}).WithContext(ctx).Create(registers).Error; err != nil {
-				return err
-			}
+	if err := rp.data.db.Transaction(func(tx *gorm.DB) error {
+		if err := createCotaEntryKvPairs(ctx, tx, checkInfo, kvPair); err != nil {
+			return err
 		}
-		// create define cotas
-		if kvPair.HasDefineCotas() {
-			defineCotas := make([]DefineCotaNftKvPair, len(kvPair.DefineCotas))
-			for i, cota := range kvPair.DefineCotas {
-				defineCotas[i] = DefineCotaNftKvPair{
-					BlockNumber: cota.BlockNumber,
-					CotaId:      cota.CotaId,
-					Total:       cota.Total,
-					Issued:      cota.Issued,
-					Configure:   cota.Configure,
-					LockHash:    cota.LockHash,
-					LockHashCRC: cota.LockHashCRC,
-				}
-			}
-			if err := tx.Debug().Model(DefineCotaNftKvPair{}).WithContext(ctx).Create(defineCotas).Error; err != nil {
-				return err
-			}
-			defineCotaVersions := make([]DefineCotaNftKvPairVersion, len(kvPair.DefineCotas))
-			for i, define := range kvPair.DefineCotas {
-				defineCotaVersion := DefineCotaNftKvPairVersion{
-					BlockNumber: define.BlockNumber,
-					CotaId:      define.CotaId,
-					Total:       define.Total,
-					Issued:      define.Issued,
-					OldIssued:   define.Issued,
-					Configure:   define.Configure,
-					LockHash:    define.LockHash,
-					TxIndex:     define.TxIndex,
-					ActionType:  0,
-				}
-				defineCotaVersions[i] = defineCotaVersion
+		if err := rp.smtPolicy.verify(ctx, tx, checkInfo.BlockNumber); err != nil {
+			return err
+		}
+		return saveBlockHeader(ctx, tx, checkInfo)
+	}); err != nil {
+		return err
+	}
+	rp.smtPolicy.reportCommitted(ctx, rp.data.db, checkInfo.BlockNumber)
+	return nil
+}
+
+func createCotaEntryKvPairs(ctx context.Context, tx *gorm.DB, checkInfo biz.CheckInfo, kvPair *biz.KvPair) error {
+	if err := checkContinuity(ctx, tx, checkInfo); err != nil {
+		return err
+	}
+	// create register cotas
+	if kvPair.HasRegisters() {
+		registers := make([]RegisterCotaKvPair, len(kvPair.Registers))
+		for i, register := range kvPair.Registers {
+			registers[i] = RegisterCotaKvPair{
+				BlockNumber:   register.BlockNumber,
+				LockHash:      register.LockHash,
+				ScriptVersion: register.ScriptVersion,
 			}
-			// create define cotas versions
-			if err := tx.Model(DefineCotaNftKvPairVersion{}).WithContext(ctx).Create(defineCotaVersions).Error; err != nil {
-				return err
+		}
+		if err := tx.Model(RegisterCotaKvPair{}).WithContext(ctx).Create(registers).Error; err != nil {
+			return err
+		}
+	}
+	// record the registry updates
+	if err := createRegistryHistories(ctx, tx, kvPair); err != nil {
+		return err
+	}
+	// create define cotas
+	if kvPair.HasDefineCotas() {
+		defineCotas := make([]DefineCotaNftKvPair, len(kvPair.DefineCotas))
+		for i, cota := range kvPair.DefineCotas {
+			defineCotas[i] = DefineCotaNftKvPair{
+				BlockNumber:   cota.BlockNumber,
+				CotaId:        cota.CotaId,
+				Total:         cota.Total,
+				Issued:        cota.Issued,
+				Configure:     cota.Configure,
+				LockHash:      cota.LockHash,
+				LockHashCRC:   cota.LockHashCRC,
+				ScriptVersion: cota.ScriptVersion,
 			}
 		}
-		if kvPair.HasUpdatedDefineCotas() {
-			updatedDefineCotaVersions := make([]DefineCotaNftKvPairVersion, len(kvPair.UpdatedDefineCotas))
-			for i, define := range kvPair.UpdatedDefineCotas {
-				var defineCota DefineCotaNftKvPair
-				if err := tx.Model(DefineCotaNftKvPair{}).WithContext(ctx).Where("cota_id = ?", define.CotaId).First(&defineCota).Error; err != nil {
-					return err
-				}
-				defineCotaVersion := DefineCotaNftKvPairVersion{
-					OldBlockNumber: defineCota.BlockNumber,
-					BlockNumber:    define.BlockNumber,
-					CotaId:         define.CotaId,
-					Total:          define.Total,
-					Issued:         define.Issued,
-					OldIssued:      defineCota.Issued,
-					Configure:      define.Configure,
-					LockHash:       define.LockHash,
-					TxIndex:        define.TxIndex,
-					ActionType:     1,
-				}
-				updatedDefineCotaVersions[i] = defineCotaVersion
+		if err := tx.Debug().Model(DefineCotaNftKvPair{}).WithContext(ctx).Create(defineCotas).Error; err != nil {
+			return err
+		}
+		defineCotaVersions := make([]DefineCotaNftKvPairVersion, len(kvPair.DefineCotas))
+		for i, define := range kvPair.DefineCotas {
+			defineCotaVersion := DefineCotaNftKvPairVersion{
+				BlockNumber:      define.BlockNumber,
+				CotaId:           define.CotaId,
+				Total:            define.Total,
+				Issued:           define.Issued,
+				OldIssued:        define.Issued,
+				Configure:        define.Configure,
+				LockHash:         define.LockHash,
+				OldScriptVersion: define.ScriptVersion,
+				ScriptVersion:    define.ScriptVersion,
+				TxIndex:          define.TxIndex,
+				ActionType:       0,
 			}
-			// create updated define cotas versions
-			if err := tx.Model(DefineCotaNftKvPairVersion{}).WithContext(ctx).Create(updatedDefineCotaVersions).Error; err != nil {
+			defineCotaVersions[i] = defineCotaVersion
+		}
+		// create define cotas versions
+		if err := tx.Model(DefineCotaNftKvPairVersion{}).WithContext(ctx).Create(defineCotaVersions).Error; err != nil {
+			return err
+		}
+	}
+	if kvPair.HasUpdatedDefineCotas() {
+		updatedDefineCotaVersions := make([]DefineCotaNftKvPairVersion, len(kvPair.UpdatedDefineCotas))
+		for i, define := range kvPair.UpdatedDefineCotas {
+			var defineCota DefineCotaNftKvPair
+			if err := tx.Model(DefineCotaNftKvPair{}).WithContext(ctx).Where("cota_id = ?", define.CotaId).First(&defineCota).Error; err != nil {
 				return err
 			}
-			// update define cotas
-			updatedDefineCotas := make([]DefineCotaNftKvPair, len(kvPair.UpdatedDefineCotas))
-			for i, cota := range kvPair.UpdatedDefineCotas {
-				updatedDefineCotas[i] = DefineCotaNftKvPair{
-					BlockNumber: cota.BlockNumber,
-					CotaId:      cota.CotaId,
-					Total:       cota.Total,
-					Issued:      cota.Issued,
-					Configure:   cota.Configure,
-					LockHash:    cota.LockHash,
-					LockHashCRC: cota.LockHashCRC,
-					UpdatedAt:   cota.UpdatedAt,
-				}
-			}
-			if err := tx.Model(DefineCotaNftKvPair{}).WithContext(ctx).Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "cota_id"}},
-				DoUpdates: clause.AssignmentColumns([]string{"issued", "block_number", "updated_at"}),
-			}).Create(updatedDefineCotas).Error; err != nil {
-				return err
+			defineCotaVersion := DefineCotaNftKvPairVersion{
+				OldBlockNumber:   defineCota.BlockNumber,
+				BlockNumber:      define.BlockNumber,
+				CotaId:           define.CotaId,
+				Total:            define.Total,
+				Issued:           define.Issued,
+				OldIssued:        defineCota.Issued,
+				Configure:        define.Configure,
+				LockHash:         define.LockHash,
+				OldScriptVersion: defineCota.ScriptVersion,
+				ScriptVersion:    define.ScriptVersion,
+				TxIndex:          define.TxIndex,
+				ActionType:       1,
 			}
+			updatedDefineCotaVersions[i] = defineCotaVersion
 		}
-		if kvPair.HasWithdrawCotas() {
-			// create withdraw cotas
-			withdrawCotas := make([]WithdrawCotaNftKvPair, len(kvPair.WithdrawCotas))
-			for i, cota := range kvPair.WithdrawCotas {
-				withdrawCotas[i] = WithdrawCotaNftKvPair{
-					BlockNumber:          cota.BlockNumber,
-					CotaId:               cota.CotaId,
-					CotaIdCRC:            cota.CotaIdCRC,
-					TokenIndex:           cota.TokenIndex,
-					OutPoint:             cota.OutPoint,
-					OutPointCrc:          cota.OutPointCrc,
-					State:                cota.State,
-					Configure:            cota.Configure,
-					Characteristic:       cota.Characteristic,
-					ReceiverLockScriptId: cota.ReceiverLockScriptId,
-					LockHash:             cota.LockHash,
-					LockHashCrc:          cota.LockHashCrc,
-					Version:              cota.Version,
-				}
-			}
-			if err := tx.Model(WithdrawCotaNftKvPair{}).WithContext(ctx).Create(withdrawCotas).Error; err != nil {
-				return err
-			}
-			holdCotasSize := len(kvPair.WithdrawCotas)
-			removedHoldCotas := make([]biz.HoldCotaNftKvPair, holdCotasSize)
-			removedHoldCotaIds := make([]uint, holdCotasSize)
-			for i, withdrawCota := range kvPair.WithdrawCotas {
-				var holdCota biz.HoldCotaNftKvPair
-				if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Select("*").Where("cota_id = ? and token_index = ?", withdrawCota.CotaId, withdrawCota.TokenIndex).Find(&holdCota).Error; err != nil {
-					return err
-				}
-				// 上面把对象初始化出来了，所以需要通过具体值来判断是否存在
-				if holdCota.CotaId == "" {
-					continue
-				}
-				removedHoldCotas[i] = holdCota
-				removedHoldCotaIds[i] = holdCota.ID
-			}
-			if removedHoldCotas[0].CotaId != "" {
-				removedHoldCotaVersions := make([]HoldCotaNftKvPairVersion, holdCotasSize)
-				blockNumber := kvPair.WithdrawCotas[0].BlockNumber
-				for i, cota := range removedHoldCotas {
-					removedHoldCotaVersions[i] = HoldCotaNftKvPairVersion{
-						OldBlockNumber:    cota.BlockNumber,
-						BlockNumber:       blockNumber,
-						CotaId:            cota.CotaId,
-						TokenIndex:        cota.TokenIndex,
-						OldState:          cota.State,
-						Configure:         cota.Configure,
-						OldCharacteristic: cota.Characteristic,
-						OldLockHash:       cota.LockHash,
-						TxIndex:           cota.TxIndex,
-						ActionType:        2,
-					}
-				}
-				// create removed hold cota versions
-				if err := tx.Model(HoldCotaNftKvPairVersion{}).WithContext(ctx).Create(removedHoldCotaVersions).Error; err != nil {
-					return err
-				}
-				// remove those hold cotas that are equal with withdraw cotas
-				if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Delete(&removedHoldCotas, removedHoldCotaIds).Error; err != nil {
-					return err
-				}
+		// create updated define cotas versions
+		if err := tx.Model(DefineCotaNftKvPairVersion{}).WithContext(ctx).Create(updatedDefineCotaVersions).Error; err != nil {
+			return err
+		}
+		// update define cotas
+		updatedDefineCotas := make([]DefineCotaNftKvPair, len(kvPair.UpdatedDefineCotas))
+		for i, cota := range kvPair.UpdatedDefineCotas {
+			updatedDefineCotas[i] = DefineCotaNftKvPair{
+				BlockNumber:   cota.BlockNumber,
+				CotaId:        cota.CotaId,
+				Total:         cota.Total,
+				Issued:        cota.Issued,
+				Configure:     cota.Configure,
+				LockHash:      cota.LockHash,
+				LockHashCRC:   cota.LockHashCRC,
+				ScriptVersion: cota.ScriptVersion,
+				UpdatedAt:     cota.UpdatedAt,
 			}
 		}
-		if kvPair.HasHoldCotas() {
-			// create hold cotas
-			holdCotas := make([]HoldCotaNftKvPair, len(kvPair.HoldCotas))
-			for i, cota := range kvPair.HoldCotas {
-				holdCotas[i] = HoldCotaNftKvPair{
-					BlockNumber:    cota.BlockNumber,
-					CotaId:         cota.CotaId,
-					TokenIndex:     cota.TokenIndex,
-					State:          cota.State,
-					Configure:      cota.Configure,
-					Characteristic: cota.Characteristic,
-					LockHash:       cota.LockHash,
-					LockHashCRC:    cota.LockHashCRC,
-				}
+		if err := tx.Model(DefineCotaNftKvPair{}).WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cota_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"issued", "block_number", "script_version", "updated_at"}),
+		}).Create(updatedDefineCotas).Error; err != nil {
+			return err
+		}
+	}
+	if kvPair.HasWithdrawCotas() {
+		// create withdraw cotas
+		withdrawCotas := make([]WithdrawCotaNftKvPair, len(kvPair.WithdrawCotas))
+		for i, cota := range kvPair.WithdrawCotas {
+			withdrawCotas[i] = WithdrawCotaNftKvPair{
+				BlockNumber:          cota.BlockNumber,
+				CotaId:               cota.CotaId,
+				CotaIdCRC:            cota.CotaIdCRC,
+				TokenIndex:           cota.TokenIndex,
+				OutPoint:             cota.OutPoint,
+				OutPointCrc:          cota.OutPointCrc,
+				State:                cota.State,
+				Configure:            cota.Configure,
+				Characteristic:       cota.Characteristic,
+				ReceiverLockScriptId: cota.ReceiverLockScriptId,
+				LockHash:             cota.LockHash,
+				LockHashCrc:          cota.LockHashCrc,
+				Version:              cota.Version,
+				ScriptVersion:        cota.ScriptVersion,
 			}
-			if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Create(holdCotas).Error; err != nil {
+		}
+		if err := tx.Model(WithdrawCotaNftKvPair{}).WithContext(ctx).Create(withdrawCotas).Error; err != nil {
+			return err
+		}
+		holdCotasSize := len(kvPair.WithdrawCotas)
+		removedHoldCotas := make([]biz.HoldCotaNftKvPair, holdCotasSize)
+		removedHoldCotaIds := make([]uint, holdCotasSize)
+		for i, withdrawCota := range kvPair.WithdrawCotas {
+			var holdCota biz.HoldCotaNftKvPair
+			if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Select("*").Where("cota_id = ? and token_index = ?", withdrawCota.CotaId, withdrawCota.TokenIndex).Find(&holdCota).Error; err != nil {
 				return err
 			}
-			newHoldCotaVersions := make([]HoldCotaNftKvPairVersion, len(kvPair.HoldCotas))
-			for i, cota := range kvPair.HoldCotas {
-				newHoldCotaVersions[i] = HoldCotaNftKvPairVersion{
-					BlockNumber:    cota.BlockNumber,
-					CotaId:         cota.CotaId,
-					TokenIndex:     cota.TokenIndex,
-					State:          cota.State,
-					Configure:      cota.Configure,
-					Characteristic: cota.Characteristic,
-					LockHash:       cota.LockHash,
-					TxIndex:        cota.TxIndex,
-					ActionType:     0,
-				}
-			}
-			// create hold cota versions
-			if err := tx.Model(HoldCotaNftKvPairVersion{}).WithContext(ctx).Create(newHoldCotaVersions).Error; err != nil {
-				return err
+			// 上面把对象初始化出来了，所以需要通过具体值来判断是否存在
+			if holdCota.CotaId == "" {
+				continue
 			}
+			removedHoldCotas[i] = holdCota
+			removedHoldCotaIds[i] = holdCota.ID
 		}
-		if kvPair.HasUpdatedHoldCotas() {
-			updatedHoldCotaVersions := make([]HoldCotaNftKvPairVersion, len(kvPair.UpdatedHoldCotas))
-			for i, cota := range kvPair.UpdatedHoldCotas {
-				var oldHoldCota HoldCotaNftKvPair
-				if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Where("cota_id = ? and token_index = ?", cota.CotaId, cota.TokenIndex).First(&oldHoldCota).Error; err != nil {
-					return err
-				}
-				updatedHoldCotaVersions[i] = HoldCotaNftKvPairVersion{
-					OldBlockNumber:    oldHoldCota.BlockNumber,
-					BlockNumber:       cota.BlockNumber,
+		if removedHoldCotas[0].CotaId != "" {
+			removedHoldCotaVersions := make([]HoldCotaNftKvPairVersion, holdCotasSize)
+			blockNumber := kvPair.WithdrawCotas[0].BlockNumber
+			for i, cota := range removedHoldCotas {
+				removedHoldCotaVersions[i] = HoldCotaNftKvPairVersion{
+					OldBlockNumber:    cota.BlockNumber,
+					BlockNumber:       blockNumber,
 					CotaId:            cota.CotaId,
 					TokenIndex:        cota.TokenIndex,
-					OldState:          oldHoldCota.State,
-					State:             cota.State,
+					OldState:          cota.State,
 					Configure:         cota.Configure,
-					OldCharacteristic: oldHoldCota.Characteristic,
-					Characteristic:    cota.Characteristic,
-					OldLockHash:       oldHoldCota.LockHash,
-					LockHash:          cota.LockHash,
+					OldCharacteristic: cota.Characteristic,
+					OldLockHash:       cota.LockHash,
+					OldScriptVersion:  cota.ScriptVersion,
 					TxIndex:           cota.TxIndex,
-					ActionType:        1,
+					ActionType:        2,
 				}
 			}
-			// create updated hold cotas versions
-			if err := tx.Model(HoldCotaNftKvPairVersion{}).WithContext(ctx).Create(updatedHoldCotaVersions).Error; err != nil {
+			// create removed hold cota versions
+			if err := tx.Model(HoldCotaNftKvPairVersion{}).WithContext(ctx).Create(removedHoldCotaVersions).Error; err != nil {
 				return err
 			}
-			// update hold cotas
-			updatedHoldCotas := make([]HoldCotaNftKvPair, len(kvPair.UpdatedHoldCotas))
-			for i, cota := range kvPair.UpdatedHoldCotas {
-				updatedHoldCotas[i] = HoldCotaNftKvPair{
-					BlockNumber:    cota.BlockNumber,
-					CotaId:         cota.CotaId,
-					TokenIndex:     cota.TokenIndex,
-					State:          cota.State,
-					Configure:      cota.Configure,
-					Characteristic: cota.Characteristic,
-					LockHash:       cota.LockHash,
-					LockHashCRC:    cota.LockHashCRC,
-					UpdatedAt:      cota.UpdatedAt,
-				}
-			}
-			if err := tx.Debug().Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "cota_id"}, {Name: "token_index"}},
-				DoUpdates: clause.AssignmentColumns([]string{"block_number", "state", "characteristic", "lock_hash", "lock_hash_crc", "updated_at"}),
-			}).Create(updatedHoldCotas).Error; err != nil {
+			// remove those hold cotas that are equal with withdraw cotas
+			if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Delete(&removedHoldCotas, removedHoldCotaIds).Error; err != nil {
 				return err
 			}
 		}
-		if kvPair.HasClaimedCotas() {
-			// create claimed cotas
-			claimedCotas := make([]ClaimedCotaNftKvPair, len(kvPair.ClaimedCotas))
-			for i, cota := range kvPair.ClaimedCotas {
-				claimedCotas[i] = ClaimedCotaNftKvPair{
-					BlockNumber: cota.BlockNumber,
-					CotaId:      cota.CotaId,
-					CotaIdCRC:   cota.CotaIdCRC,
-					TokenIndex:  cota.TokenIndex,
-					OutPoint:    cota.OutPoint,
-					OutPointCrc: cota.OutPointCrc,
-					LockHash:    cota.LockHash,
-					LockHashCrc: cota.LockHashCrc,
-				}
+	}
+	if kvPair.HasHoldCotas() {
+		// create hold cotas
+		holdCotas := make([]HoldCotaNftKvPair, len(kvPair.HoldCotas))
+		for i, cota := range kvPair.HoldCotas {
+			holdCotas[i] = HoldCotaNftKvPair{
+				BlockNumber:    cota.BlockNumber,
+				CotaId:         cota.CotaId,
+				TokenIndex:     cota.TokenIndex,
+				State:          cota.State,
+				Configure:      cota.Configure,
+				Characteristic: cota.Characteristic,
+				LockHash:       cota.LockHash,
+				LockHashCRC:    cota.LockHashCRC,
+				ScriptVersion:  cota.ScriptVersion,
 			}
-			if err := tx.Model(ClaimedCotaNftKvPair{}).WithContext(ctx).Create(claimedCotas).Error; err != nil {
+		}
+		if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Create(holdCotas).Error; err != nil {
+			return err
+		}
+		newHoldCotaVersions := make([]HoldCotaNftKvPairVersion, len(kvPair.HoldCotas))
+		for i, cota := range kvPair.HoldCotas {
+			newHoldCotaVersions[i] = HoldCotaNftKvPairVersion{
+				BlockNumber:    cota.BlockNumber,
+				CotaId:         cota.CotaId,
+				TokenIndex:     cota.TokenIndex,
+				State:          cota.State,
+				Configure:      cota.Configure,
+				Characteristic: cota.Characteristic,
+				LockHash:       cota.LockHash,
+				ScriptVersion:  cota.ScriptVersion,
+				TxIndex:        cota.TxIndex,
+				ActionType:     0,
+			}
+		}
+		// create hold cota versions
+		if err := tx.Model(HoldCotaNftKvPairVersion{}).WithContext(ctx).Create(newHoldCotaVersions).Error; err != nil {
+			return err
+		}
+	}
+	if kvPair.HasUpdatedHoldCotas() {
+		updatedHoldCotaVersions := make([]HoldCotaNftKvPairVersion, len(kvPair.UpdatedHoldCotas))
+		for i, cota := range kvPair.UpdatedHoldCotas {
+			var oldHoldCota HoldCotaNftKvPair
+			if err := tx.Model(HoldCotaNftKvPair{}).WithContext(ctx).Where("cota_id = ? and token_index = ?", cota.CotaId, cota.TokenIndex).First(&oldHoldCota).Error; err != nil {
 				return err
 			}
+			updatedHoldCotaVersions[i] = HoldCotaNftKvPairVersion{
+				OldBlockNumber:    oldHoldCota.BlockNumber,
+				BlockNumber:       cota.BlockNumber,
+				CotaId:            cota.CotaId,
+				TokenIndex:        cota.TokenIndex,
+				OldState:          oldHoldCota.State,
+				State:             cota.State,
+				Configure:         cota.Configure,
+				OldCharacteristic: oldHoldCota.Characteristic,
+				Characteristic:    cota.Characteristic,
+				OldLockHash:       oldHoldCota.LockHash,
+				LockHash:          cota.LockHash,
+				OldScriptVersion:  oldHoldCota.ScriptVersion,
+				ScriptVersion:     cota.ScriptVersion,
+				TxIndex:           cota.TxIndex,
+				ActionType:        1,
+			}
 		}
-		// create check info
-		if err := tx.Debug().Model(CheckInfo{}).WithContext(ctx).Create(&CheckInfo{
-			BlockNumber: checkInfo.BlockNumber,
-			BlockHash:   checkInfo.BlockHash,
-			CheckType:   checkInfo.CheckType,
-		}).Error; err != nil {
+		// create updated hold cotas versions
+		if err := tx.Model(HoldCotaNftKvPairVersion{}).WithContext(ctx).Create(updatedHoldCotaVersions).Error; err != nil {
 			return err
 		}
-		return nil
-	})
+		// update hold cotas
+		updatedHoldCotas := make([]HoldCotaNftKvPair, len(kvPair.UpdatedHoldCotas))
+		for i, cota := range kvPair.UpdatedHoldCotas {
+			updatedHoldCotas[i] = HoldCotaNftKvPair{
+				BlockNumber:    cota.BlockNumber,
+				CotaId:         cota.CotaId,
+				TokenIndex:     cota.TokenIndex,
+				State:          cota.State,
+				Configure:      cota.Configure,
+				Characteristic: cota.Characteristic,
+				LockHash:       cota.LockHash,
+				LockHashCRC:    cota.LockHashCRC,
+				ScriptVersion:  cota.ScriptVersion,
+				UpdatedAt:      cota.UpdatedAt,
+			}
+		}
+		if err := tx.Debug().Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cota_id"}, {Name: "token_index"}},
+			DoUpdates: clause.AssignmentColumns([]string{"block_number", "state", "characteristic", "lock_hash", "lock_hash_crc", "script_version", "updated_at"}),
+		}).Create(updatedHoldCotas).Error; err != nil {
+			return err
+		}
+	}
+	if kvPair.HasClaimedCotas() {
+		// create claimed cotas
+		claimedCotas := make([]ClaimedCotaNftKvPair, len(kvPair.ClaimedCotas))
+		for i, cota := range kvPair.ClaimedCotas {
+			claimedCotas[i] = ClaimedCotaNftKvPair{
+				BlockNumber:   cota.BlockNumber,
+				CotaId:        cota.CotaId,
+				CotaIdCRC:     cota.CotaIdCRC,
+				TokenIndex:    cota.TokenIndex,
+				OutPoint:      cota.OutPoint,
+				OutPointCrc:   cota.OutPointCrc,
+				LockHash:      cota.LockHash,
+				LockHashCrc:   cota.LockHashCrc,
+				ScriptVersion: cota.ScriptVersion,
+			}
+		}
+		if err := tx.Model(ClaimedCotaNftKvPair{}).WithContext(ctx).Create(claimedCotas).Error; err != nil {
+			return err
+		}
+	}
+	// index the created cota cells and mark the consumed ones
+	if err := createCotaCells(ctx, tx, checkInfo.BlockNumber, kvPair); err != nil {
+		return err
+	}
+	// quarantine the transactions whose entries failed to parse
+	if err := createDeadLetters(ctx, tx, biz.SyncBlock, kvPair); err != nil {
+		return err
+	}
+	// keep the entries of unknown action types and versions
+	if err := createUnrecognizedEntries(ctx, tx, kvPair); err != nil {
+		return err
+	}
+	// create check info
+	if err := tx.Debug().Model(CheckInfo{}).WithContext(ctx).Create(&CheckInfo{
+		BlockNumber: checkInfo.BlockNumber,
+		BlockHash:   checkInfo.BlockHash,
+		CheckType:   checkInfo.CheckType,
+	}).Error; err != nil {
+		return err
+	}
+	return nil
 }
 
 func (rp kvPairRepo) RestoreCotaEntryKvPairs(ctx context.Context, blockNumber uint64) error {
 	return rp.data.db.Transaction(func(tx *gorm.DB) error {
-		// delete all register cotas by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(RegisterCotaKvPair{}).Error; err != nil {
+		if err := checkFence(ctx, tx); err != nil {
 			return err
 		}
-		// delete all new define cotas by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(DefineCotaNftKvPair{}).Error; err != nil {
+		return restoreCotaEntryKvPairs(ctx, tx, blockNumber)
+	})
+}
+
+func restoreCotaEntryKvPairs(ctx context.Context, tx *gorm.DB, blockNumber uint64) error {
+	// delete all register cotas by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(RegisterCotaKvPair{}).Error; err != nil {
+		return err
+	}
+	if err := restoreRegistryHistories(ctx, tx, blockNumber); err != nil {
+		return err
+	}
+	// delete all new define cotas by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(DefineCotaNftKvPair{}).Error; err != nil {
+		return err
+	}
+	// delete all create define cota versions by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 0).Delete(DefineCotaNftKvPairVersion{}).Error; err != nil {
+		return err
+	}
+	// 把需要回滚的 block 更新过的 define 恢复到更新前的状态，这里按 cota_id 分组取出回滚 block 下第一条
+	var updatedDefineCotaVersions []DefineCotaNftKvPairVersion
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("cota_id").Order("tx_index").Find(&updatedDefineCotaVersions).Error; err != nil {
+		return err
+	}
+	var updatedDefineCotas []DefineCotaNftKvPair
+	for _, version := range updatedDefineCotaVersions {
+		updatedDefineCotas = append(updatedDefineCotas, DefineCotaNftKvPair{
+			BlockNumber:   version.OldBlockNumber,
+			CotaId:        version.CotaId,
+			Total:         version.Total,
+			Issued:        version.OldIssued,
+			Configure:     version.Configure,
+			LockHash:      version.LockHash,
+			LockHashCRC:   crc32.ChecksumIEEE([]byte(version.LockHash)),
+			ScriptVersion: version.OldScriptVersion,
+			UpdatedAt:     time.Now().UTC(),
+		})
+	}
+	if len(updatedDefineCotas) > 0 {
+		if err := tx.Debug().WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cota_id"}},
+			UpdateAll: true,
+		}).Create(updatedDefineCotas).Error; err != nil {
 			return err
 		}
-		// delete all create define cota versions by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 0).Delete(DefineCotaNftKvPairVersion{}).Error; err != nil {
+	}
+	// delete all updated define versions by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Delete(DefineCotaNftKvPairVersion{}).Error; err != nil {
+		return err
+	}
+	// delete all withdraw cotas by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(WithdrawCotaNftKvPair{}).Error; err != nil {
+		return err
+	}
+	// delete all hold cotas by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(HoldCotaNftKvPair{}).Error; err != nil {
+		return err
+	}
+	// delete all created hold cota versions by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 0).Delete(HoldCotaNftKvPairVersion{}).Error; err != nil {
+		return err
+	}
+	// restore all deleted hold cotas by the block number
+	var deletedHoldCotaVersions []HoldCotaNftKvPairVersion
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 2).Group("cota_id, token_index").Order("tx_index").Find(&deletedHoldCotaVersions).Error; err != nil {
+		return err
+	}
+	var deletedHoldCotas []HoldCotaNftKvPair
+	for _, version := range deletedHoldCotaVersions {
+		deletedHoldCotas = append(deletedHoldCotas, HoldCotaNftKvPair{
+			BlockNumber:    version.OldBlockNumber,
+			CotaId:         version.CotaId,
+			TokenIndex:     version.TokenIndex,
+			State:          version.OldState,
+			Configure:      version.Configure,
+			Characteristic: version.OldCharacteristic,
+			LockHash:       version.OldLockHash,
+			LockHashCRC:    crc32.ChecksumIEEE([]byte(version.OldLockHash)),
+			ScriptVersion:  version.OldScriptVersion,
+		})
+	}
+	if len(deletedHoldCotas) > 0 {
+		if err := tx.WithContext(ctx).Create(deletedHoldCotas).Error; err != nil {
 			return err
 		}
-		// 把需要回滚的 block 更新过的 define 恢复到更新前的状态，这里按 cota_id 分组取出回滚 block 下第一条
-		var updatedDefineCotaVersions []DefineCotaNftKvPairVersion
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("cota_id").Order("tx_index").Find(&updatedDefineCotaVersions).Error; err != nil {
+	}
+	// delete all deleted hold cota versions by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 2).Delete(HoldCotaNftKvPairVersion{}).Error; err != nil {
+		return err
+	}
+	// restore all updated hold cotas by the block number
+	var updatedHoldCotaVersions []HoldCotaNftKvPairVersion
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("cota_id, token_index").Order("tx_index").Find(&updatedHoldCotaVersions).Error; err != nil {
+		return err
+	}
+	var updatedHoldCotas []HoldCotaNftKvPair
+	for _, version := range updatedHoldCotaVersions {
+		updatedHoldCotas = append(updatedHoldCotas, HoldCotaNftKvPair{
+			BlockNumber:    version.OldBlockNumber,
+			CotaId:         version.CotaId,
+			TokenIndex:     version.TokenIndex,
+			State:          version.OldState,
+			Configure:      version.Configure,
+			Characteristic: version.OldCharacteristic,
+			LockHash:       version.OldLockHash,
+			LockHashCRC:    crc32.ChecksumIEEE([]byte(version.OldLockHash)),
+			ScriptVersion:  version.OldScriptVersion,
+		})
+	}
+	if len(updatedHoldCotaVersions) > 0 {
+		if err := tx.WithContext(ctx).Create(updatedHoldCotas).Error; err != nil {
 			return err
 		}
-		var updatedDefineCotas []DefineCotaNftKvPair
-		for _, version := range updatedDefineCotaVersions {
-			updatedDefineCotas = append(updatedDefineCotas, DefineCotaNftKvPair{
-				BlockNumber: version.OldBlockNumber,
-				CotaId:      version.CotaId,
-				Total:       version.Total,
-				Issued:      version.OldIssued,
-				Configure:   version.Configure,
-				LockHash:    version.LockHash,
-				LockHashCRC: crc32.ChecksumIEEE([]byte(version.LockHash)),
-				UpdatedAt:   time.Now().UTC(),
-			})
+	}
+	// delete all updated hold cota versions by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Delete(HoldCotaNftKvPairVersion{}).Error; err != nil {
+		return err
+	}
+	// delete all claimed cotas by the block number
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(ClaimedCotaNftKvPair{}).Error; err != nil {
+		return err
+	}
+	// restore the cota cells created and consumed by the block
+	if err := restoreCotaCells(ctx, tx, blockNumber); err != nil {
+		return err
+	}
+	if err := restoreDeadLetters(ctx, tx, blockNumber, biz.SyncBlock); err != nil {
+		return err
+	}
+	if err := restoreUnrecognizedEntries(ctx, tx, blockNumber); err != nil {
+		return err
+	}
+	// delete check info
+	if err := tx.Debug().WithContext(ctx).Where("block_number = ? and check_type = ?", blockNumber, biz.SyncBlock).Delete(CheckInfo{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rp kvPairRepo) CreateMetadataKvPairs(ctx context.Context, checkInfo biz.CheckInfo, kvPair *biz.KvPair) error {
+	return rp.data.db.Transaction(func(tx *gorm.DB) error {
+		if err := createMetadataKvPairs(ctx, tx, checkInfo, kvPair); err != nil {
+			return err
 		}
-		if len(updatedDefineCotas) > 0 {
-			if err := tx.Debug().WithContext(ctx).Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "cota_id"}},
-				UpdateAll: true,
-			}).Create(updatedDefineCotas).Error; err != nil {
+		return saveBlockHeader(ctx, tx, checkInfo)
+	})
+}
+
+func createMetadataKvPairs(ctx context.Context, tx *gorm.DB, checkInfo biz.CheckInfo, kvPair *biz.KvPair) error {
+	if err := checkContinuity(ctx, tx, checkInfo); err != nil {
+		return err
+	}
+	if kvPair.HasIssuerInfos() {
+		// save issuer info versions
+		issuerInfoVersions := make([]IssuerInfoVersion, len(kvPair.IssuerInfos))
+		for i, info := range kvPair.IssuerInfos {
+			var oldInfo IssuerInfo
+			err := tx.Model(IssuerInfo{}).WithContext(ctx).Where("lock_hash = ?", info.LockHash).First(&oldInfo).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 				return err
 			}
+			if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+				issuerInfoVersions[i] = IssuerInfoVersion{
+					BlockNumber:  info.BlockNumber,
+					LockHash:     info.LockHash,
+					Version:      info.Version,
+					Name:         info.Name,
+					Avatar:       info.Avatar,
+					Description:  info.Description,
+					Localization: info.Localization,
+					ActionType:   0,
+					TxIndex:      info.TxIndex,
+				}
+			} else {
+				issuerInfoVersions[i] = IssuerInfoVersion{
+					OldBlockNumber:  oldInfo.BlockNumber,
+					BlockNumber:     info.BlockNumber,
+					LockHash:        info.LockHash,
+					OldVersion:      oldInfo.Version,
+					Version:         info.Version,
+					OldName:         oldInfo.Name,
+					Name:            info.Name,
+					OldAvatar:       oldInfo.Avatar,
+					Avatar:          info.Avatar,
+					OldDescription:  oldInfo.Description,
+					Description:     info.Description,
+					OldLocalization: oldInfo.Localization,
+					Localization:    info.Localization,
+					ActionType:      1,
+					TxIndex:         info.TxIndex,
+				}
+			}
 		}
-		// delete all updated define versions by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Delete(DefineCotaNftKvPairVersion{}).Error; err != nil {
+		if err := tx.Model(IssuerInfoVersion{}).WithContext(ctx).Create(issuerInfoVersions).Error; err != nil {
 			return err
 		}
-		// delete all withdraw cotas by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(WithdrawCotaNftKvPair{}).Error; err != nil {
-			return err
+		// upsert issuer info
+		issuerInfos := make([]IssuerInfo, len(kvPair.IssuerInfos))
+		for i, issuer := range kvPair.IssuerInfos {
+			issuerInfos[i] = IssuerInfo{
+				BlockNumber:  issuer.BlockNumber,
+				LockHash:     issuer.LockHash,
+				Version:      issuer.Version,
+				Name:         issuer.Name,
+				Avatar:       issuer.Avatar,
+				Description:  issuer.Description,
+				Localization: issuer.Localization,
+			}
 		}
-		// delete all hold cotas by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(HoldCotaNftKvPair{}).Error; err != nil {
+		if err := tx.Model(IssuerInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "lock_hash"}},
+			UpdateAll: true,
+		}).Create(issuerInfos).Error; err != nil {
 			return err
 		}
-		// delete all created hold cota versions by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 0).Delete(HoldCotaNftKvPairVersion{}).Error; err != nil {
-			return err
+	}
+	if kvPair.HasClassInfos() {
+		// save class info versions
+		classInfoVersions := make([]ClassInfoVersion, len(kvPair.ClassInfos))
+		for i, info := range kvPair.ClassInfos {
+			var oldInfo ClassInfo
+			err := tx.Model(ClassInfo{}).WithContext(ctx).Where("cota_id = ?", info.CotaId).First(&oldInfo).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+				classInfoVersions[i] = ClassInfoVersion{
+					BlockNumber:    info.BlockNumber,
+					CotaId:         info.CotaId,
+					Version:        info.Version,
+					Name:           info.Name,
+					Symbol:         info.Symbol,
+					Description:    info.Description,
+					Image:          info.Image,
+					Audio:          info.Audio,
+					Video:          info.Video,
+					Model:          info.Model,
+					Characteristic: info.Characteristic,
+					Properties:     info.Properties,
+					Localization:   info.Localization,
+					ActionType:     0,
+					TxIndex:        info.TxIndex,
+				}
+			} else {
+				classInfoVersions[i] = ClassInfoVersion{
+					OldBlockNumber:    oldInfo.BlockNumber,
+					BlockNumber:       info.BlockNumber,
+					CotaId:            info.CotaId,
+					OldVersion:        oldInfo.Version,
+					Version:           info.Version,
+					OldName:           oldInfo.Name,
+					Name:              info.Name,
+					OldSymbol:         oldInfo.Symbol,
+					Symbol:            info.Symbol,
+					OldDescription:    oldInfo.Description,
+					Description:       info.Description,
+					OldImage:          oldInfo.Image,
+					Image:             info.Image,
+					OldAudio:          oldInfo.Audio,
+					Audio:             info.Audio,
+					OldVideo:          oldInfo.Video,
+					Video:             info.Video,
+					OldModel:          oldInfo.Model,
+					Model:             info.Model,
+					OldCharacteristic: oldInfo.Characteristic,
+					Characteristic:    info.Characteristic,
+					OldProperties:     oldInfo.Properties,
+					Properties:        info.Properties,
+					OldLocalization:   oldInfo.Localization,
+					Localization:      info.Localization,
+					ActionType:        1,
+					TxIndex:           info.TxIndex,
+				}
+			}
 		}
-		// restore all deleted hold cotas by the block number
-		var deletedHoldCotaVersions []HoldCotaNftKvPairVersion
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 2).Group("cota_id, token_index").Order("tx_index").Find(&deletedHoldCotaVersions).Error; err != nil {
+
+		if err := tx.Model(ClassInfoVersion{}).WithContext(ctx).Create(classInfoVersions).Error; err != nil {
 			return err
 		}
-		var deletedHoldCotas []HoldCotaNftKvPair
-		for _, version := range deletedHoldCotaVersions {
-			deletedHoldCotas = append(deletedHoldCotas, HoldCotaNftKvPair{
-				BlockNumber:    version.OldBlockNumber,
-				CotaId:         version.CotaId,
-				TokenIndex:     version.TokenIndex,
-				State:          version.OldState,
-				Configure:      version.Configure,
-				Characteristic: version.OldCharacteristic,
-				LockHash:       version.OldLockHash,
-				LockHashCRC:    crc32.ChecksumIEEE([]byte(version.OldLockHash)),
-			})
-		}
-		if len(deletedHoldCotas) > 0 {
-			if err := tx.WithContext(ctx).Create(deletedHoldCotas).Error; err != nil {
-				return err
+		// upsert class info
+		classInfos := make([]ClassInfo, len(kvPair.ClassInfos))
+		for i, class := range kvPair.ClassInfos {
+			classInfos[i] = ClassInfo{
+				BlockNumber:    class.BlockNumber,
+				CotaId:         class.CotaId,
+				Version:        class.Version,
+				Name:           class.Name,
+				Symbol:         class.Symbol,
+				Description:    class.Description,
+				Image:          class.Image,
+				Audio:          class.Audio,
+				Video:          class.Video,
+				Model:          class.Model,
+				Characteristic: class.Characteristic,
+				Properties:     class.Properties,
+				Localization:   class.Localization,
 			}
 		}
-		// delete all deleted hold cota versions by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 2).Delete(HoldCotaNftKvPairVersion{}).Error; err != nil {
+		if err := tx.Model(ClassInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cota_id"}},
+			UpdateAll: true,
+		}).Create(classInfos).Error; err != nil {
 			return err
 		}
-		// restore all updated hold cotas by the block number
-		var updatedHoldCotaVersions []HoldCotaNftKvPairVersion
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("cota_id, token_index").Order("tx_index").Find(&updatedHoldCotaVersions).Error; err != nil {
+	}
+	// quarantine the transactions whose metadata failed to parse
+	if err := createDeadLetters(ctx, tx, biz.SyncMetadata, kvPair); err != nil {
+		return err
+	}
+	// create check info
+	if err := tx.Debug().Model(CheckInfo{}).WithContext(ctx).Create(&CheckInfo{
+		BlockNumber: checkInfo.BlockNumber,
+		BlockHash:   checkInfo.BlockHash,
+		CheckType:   checkInfo.CheckType,
+	}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rp kvPairRepo) RestoreMetadataKvPairs(ctx context.Context, blockNumber uint64) error {
+	return rp.data.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkFence(ctx, tx); err != nil {
 			return err
 		}
-		var updatedHoldCotas []HoldCotaNftKvPair
-		for _, version := range updatedHoldCotaVersions {
-			updatedHoldCotas = append(updatedHoldCotas, HoldCotaNftKvPair{
-				BlockNumber:    version.OldBlockNumber,
-				CotaId:         version.CotaId,
-				TokenIndex:     version.TokenIndex,
-				State:          version.OldState,
-				Configure:      version.Configure,
-				Characteristic: version.OldCharacteristic,
-				LockHash:       version.OldLockHash,
-				LockHashCRC:    crc32.ChecksumIEEE([]byte(version.OldLockHash)),
-			})
+		return restoreMetadataKvPairs(ctx, tx, blockNumber)
+	})
+}
+
+func restoreMetadataKvPairs(ctx context.Context, tx *gorm.DB, blockNumber uint64) error {
+	// 删掉所有新建的 issuer info
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(IssuerInfo{}).Error; err != nil {
+		return err
+	}
+	// 把需要回滚的 block 更新过的 issuerInfo 恢复到更新前的状态
+	var issuerInfoVersions []IssuerInfoVersion
+	if err := tx.Model(IssuerInfoVersion{}).WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("lock_hash").Order("tx_index").Find(&issuerInfoVersions).Error; err != nil {
+		return err
+	}
+	var updatedIssuerInfos []IssuerInfo
+	for _, version := range issuerInfoVersions {
+		updatedIssuerInfos = append(updatedIssuerInfos, IssuerInfo{
+			BlockNumber:  version.OldBlockNumber,
+			LockHash:     version.LockHash,
+			Version:      version.OldVersion,
+			Name:         version.OldName,
+			Avatar:       version.OldAvatar,
+			Description:  version.OldDescription,
+			Localization: version.OldLocalization,
+			UpdatedAt:    time.Now().UTC(),
+		})
+	}
+	if len(updatedIssuerInfos) > 0 {
+		if err := tx.Model(IssuerInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "lock_hash"}},
+			UpdateAll: true,
+		}).Create(updatedIssuerInfos).Error; err != nil {
+			return err
 		}
-		if len(updatedHoldCotaVersions) > 0 {
-			if err := tx.WithContext(ctx).Create(updatedHoldCotas).Error; err != nil {
-				return err
-			}
+	}
+	// delete the issuer info versions of the block, a re-applied block writes them again
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(IssuerInfoVersion{}).Error; err != nil {
+		return err
+	}
+	// delete all class info by the block number
+	if err := tx.Debug().WithContext(ctx).Where("block_number = ?", blockNumber).Delete(ClassInfo{}).Error; err != nil {
+		return err
+	}
+	var classInfoVersions []ClassInfoVersion
+	if err := tx.Model(ClassInfoVersion{}).WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("cota_id").Order("tx_index").Find(&classInfoVersions).Error; err != nil {
+		return err
+	}
+	var updatedClassInfos []ClassInfo
+	for _, version := range classInfoVersions {
+		updatedClassInfos = append(updatedClassInfos, ClassInfo{
+			BlockNumber:    version.OldBlockNumber,
+			CotaId:         version.CotaId,
+			Version:        version.OldVersion,
+			Name:           version.OldName,
+			Symbol:         version.OldSymbol,
+			Description:    version.OldDescription,
+			Image:          version.OldImage,
+			Audio:          version.OldAudio,
+			Video:          version.OldVideo,
+			Model:          version.OldModel,
+			Characteristic: version.OldCharacteristic,
+			Properties:     version.OldProperties,
+			Localization:   version.OldLocalization,
+			UpdatedAt:      time.Now().UTC(),
+		})
+	}
+	if len(updatedClassInfos) > 0 {
+		if err := tx.Debug().Model(ClassInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cota_id"}},
+			UpdateAll: true,
+		}).Create(updatedClassInfos).Error; err != nil {
+			return err
 		}
-		// delete all updated hold cota versions by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Delete(HoldCotaNftKvPairVersion{}).Error; err != nil {
+	}
+	// delete the class info versions of the block, a re-applied block writes them again
+	if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(ClassInfoVersion{}).Error; err != nil {
+		return err
+	}
+	if err := restoreDeadLetters(ctx, tx, blockNumber, biz.SyncMetadata); err != nil {
+		return err
+	}
+	// delete check info
+	if err := tx.Debug().WithContext(ctx).Where("block_number = ? and check_type = ?", blockNumber, biz.SyncMetadata).Delete(CheckInfo{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateKvPairs stores the cota entry and metadata kv pairs of a block and advances both check infos in one transaction.
+// A single row would do for unified mode alone, but both rows are kept so that sync_mode can be switched back to
+// separate without a migration: each separate service resumes from its own check type, and the restore, rewind,
+// cleaner and audit all work per check type. As the rows are pruned and rewound per check type they can drift apart,
+// the unified service catches the lagging one up before it commits here.
+func (rp kvPairRepo) CreateKvPairs(ctx context.Context, checkInfo biz.CheckInfo, kvPair *biz.KvPair) error {
+	if err := rp.data.db.Transaction(func(tx *gorm.DB) error {
+		checkInfo.CheckType = biz.SyncBlock
+		if err := createCotaEntryKvPairs(ctx, tx, checkInfo, kvPair); err != nil {
 			return err
 		}
-		// delete all claimed cotas by the block number
-		if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(ClaimedCotaNftKvPair{}).Error; err != nil {
+		if err := rp.smtPolicy.verify(ctx, tx, checkInfo.BlockNumber); err != nil {
 			return err
 		}
-		// delete check info
-		if err := tx.Debug().WithContext(ctx).Where("block_number = ? and check_type = ?", blockNumber, biz.SyncBlock).Delete(CheckInfo{}).Error; err != nil {
+		checkInfo.CheckType = biz.SyncMetadata
+		if err := createMetadataKvPairs(ctx, tx, checkInfo, kvPair); err != nil {
 			return err
 		}
-		return nil
-	})
+		return saveBlockHeader(ctx, tx, checkInfo)
+	}); err != nil {
+		return err
+	}
+	rp.smtPolicy.reportCommitted(ctx, rp.data.db, checkInfo.BlockNumber)
+	return nil
 }
 
-func (rp kvPairRepo) CreateMetadataKvPairs(ctx context.Context, checkInfo biz.CheckInfo, kvPair *biz.KvPair) error {
+// SkipBlocks advances the check infos over blocks without cota transactions, no kv pairs are written for them.
+// The header of the new check info is stored so that fork detection can walk back to it.
+func (rp kvPairRepo) SkipBlocks(ctx context.Context, last biz.CheckInfo, checkInfo biz.CheckInfo, checkTypes ...biz.CheckType) error {
 	return rp.data.db.Transaction(func(tx *gorm.DB) error {
-		if kvPair.HasIssuerInfos() {
-			// save issuer info versions
-			issuerInfoVersions := make([]IssuerInfoVersion, len(kvPair.IssuerInfos))
-			for i, info := range kvPair.IssuerInfos {
-				var oldInfo IssuerInfo
-				err := tx.Model(IssuerInfo{}).WithContext(ctx).Where("lock_hash = ?", info.LockHash).First(&oldInfo).Error
-				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-					return err
-				}
-				if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-					issuerInfoVersions[i] = IssuerInfoVersion{
-						BlockNumber:  info.BlockNumber,
-						LockHash:     info.LockHash,
-						Version:      info.Version,
-						Name:         info.Name,
-						Avatar:       info.Avatar,
-						Description:  info.Description,
-						Localization: info.Localization,
-						ActionType:   0,
-						TxIndex:      info.TxIndex,
-					}
-				} else {
-					issuerInfoVersions[i] = IssuerInfoVersion{
-						OldBlockNumber:  oldInfo.BlockNumber,
-						BlockNumber:     info.BlockNumber,
-						LockHash:        info.LockHash,
-						OldVersion:      oldInfo.Version,
-						Version:         info.Version,
-						OldName:         oldInfo.Name,
-						Name:            info.Name,
-						OldAvatar:       oldInfo.Avatar,
-						Avatar:          info.Avatar,
-						OldDescription:  oldInfo.Description,
-						Description:     info.Description,
-						OldLocalization: oldInfo.Localization,
-						Localization:    info.Localization,
-						ActionType:      1,
-						TxIndex:         info.TxIndex,
-					}
-				}
-			}
-			if err := tx.Model(IssuerInfoVersion{}).WithContext(ctx).Create(issuerInfoVersions).Error; err != nil {
+		if err := checkFence(ctx, tx); err != nil {
+			return err
+		}
+		for _, checkType := range checkTypes {
+			var lastCheckInfo CheckInfo
+			if err := tx.WithContext(ctx).Where("check_type = ?", checkType).Order("block_number desc").Limit(1).Find(&lastCheckInfo).Error; err != nil {
 				return err
 			}
-			// upsert issuer info
-			issuerInfos := make([]IssuerInfo, len(kvPair.IssuerInfos))
-			for i, issuer := range kvPair.IssuerInfos {
-				issuerInfos[i] = IssuerInfo{
-					BlockNumber:  issuer.BlockNumber,
-					LockHash:     issuer.LockHash,
-					Version:      issuer.Version,
-					Name:         issuer.Name,
-					Avatar:       issuer.Avatar,
-					Description:  issuer.Description,
-					Localization: issuer.Localization,
-				}
+			if lastCheckInfo.ID != 0 && (lastCheckInfo.BlockNumber != last.BlockNumber || lastCheckInfo.BlockHash != last.BlockHash) {
+				return biz.ErrCheckInfoConflict
 			}
-			if err := tx.Model(IssuerInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "lock_hash"}},
-				UpdateAll: true,
-			}).Create(issuerInfos).Error; err != nil {
+			if err := tx.Model(CheckInfo{}).WithContext(ctx).Create(&CheckInfo{
+				BlockNumber: checkInfo.BlockNumber,
+				BlockHash:   checkInfo.BlockHash,
+				CheckType:   checkType,
+			}).Error; err != nil {
 				return err
 			}
 		}
-		if kvPair.HasClassInfos() {
-			// save class info versions
-			classInfoVersions := make([]ClassInfoVersion, len(kvPair.ClassInfos))
-			for i, info := range kvPair.ClassInfos {
-				var oldInfo ClassInfo
-				err := tx.Model(ClassInfo{}).WithContext(ctx).Where("cota_id = ?", info.CotaId).First(&oldInfo).Error
-				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return saveBlockHeader(ctx, tx, checkInfo)
+	})
+}
+
+// kvPairTables are the tables written by the kv pair commits, all of them have a block_number column
+var kvPairTables = []string{
+	"register_cota_kv_pairs", "define_cota_nft_kv_pairs", "define_cota_nft_kv_pair_versions", "hold_cota_nft_kv_pairs",
+	"hold_cota_nft_kv_pair_versions", "withdraw_cota_nft_kv_pairs", "claimed_cota_nft_kv_pairs", "issuer_infos",
+	"issuer_info_versions", "class_infos", "class_info_versions", "cota_cells", "dead_letters",
+	"unrecognized_entries", "registry_histories",
+}
+
+// rewindBatchSize bounds the blocks undone by one transaction of a rewind
+const rewindBatchSize = 100
+
+// RewindKvPairs undoes every block above the ancestor for both check types, from the latest block down in
+// transactions of rewindBatchSize blocks. It does not rely on the check infos, which the cleaner only keeps for the
+// latest blocks, but on the block numbers found in the kv pair tables. The check infos of the head stay until the last
+// batch moves them to the ancestor, so an interrupted rewind is found again: a reorg by the forked head, a resync by
+// its fence.
+func (rp kvPairRepo) RewindKvPairs(ctx context.Context, ancestor biz.CheckInfo) error {
+	// below is the exclusive upper bound of the next batch, zero before the first one
+	var below uint64
+	for {
+		var blockNumbers []uint64
+		if err := rp.data.db.Transaction(func(tx *gorm.DB) error {
+			if err := checkFence(ctx, tx); err != nil {
+				return err
+			}
+			var err error
+			if blockNumbers, err = rewindBlocks(ctx, tx, ancestor.BlockNumber, below); err != nil {
+				return err
+			}
+			if len(blockNumbers) < rewindBatchSize {
+				if err := restoreBlocks(ctx, tx, blockNumbers); err != nil {
+					return err
+				}
+				return moveCheckInfos(ctx, tx, ancestor)
+			}
+			var heads []CheckInfo
+			for _, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+				var head CheckInfo
+				if err := tx.WithContext(ctx).Where("check_type = ?", checkType).Order("block_number desc").Limit(1).Find(&head).Error; err != nil {
 					return err
 				}
-				if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-					classInfoVersions[i] = ClassInfoVersion{
-						BlockNumber:    0,
-						CotaId:         info.CotaId,
-						Version:        info.Version,
-						Name:           info.Name,
-						Symbol:         info.Symbol,
-						Description:    info.Description,
-						Image:          info.Image,
-						Audio:          info.Audio,
-						Video:          info.Video,
-						Model:          info.Model,
-						Characteristic: info.Characteristic,
-						Properties:     info.Properties,
-						Localization:   info.Localization,
-						ActionType:     0,
-						TxIndex:        info.TxIndex,
-					}
-				} else {
-					classInfoVersions[i] = ClassInfoVersion{
-						OldBlockNumber:    oldInfo.BlockNumber,
-						BlockNumber:       info.BlockNumber,
-						CotaId:            info.CotaId,
-						OldVersion:        oldInfo.Version,
-						Version:           info.Version,
-						OldName:           oldInfo.Name,
-						Name:              info.Name,
-						OldSymbol:         oldInfo.Symbol,
-						Symbol:            info.Symbol,
-						OldDescription:    oldInfo.Description,
-						Description:       info.Description,
-						OldImage:          oldInfo.Image,
-						Image:             info.Image,
-						OldAudio:          oldInfo.Audio,
-						Audio:             info.Audio,
-						OldVideo:          oldInfo.Video,
-						Video:             info.Video,
-						OldModel:          oldInfo.Model,
-						Model:             info.Model,
-						OldCharacteristic: oldInfo.Characteristic,
-						Characteristic:    info.Characteristic,
-						OldProperties:     oldInfo.Properties,
-						Properties:        info.Properties,
-						OldLocalization:   oldInfo.Localization,
-						Localization:      info.Localization,
-						ActionType:        1,
-						TxIndex:           info.TxIndex,
-					}
+				if head.ID != 0 {
+					heads = append(heads, head)
 				}
 			}
-
-			if err := tx.Model(ClassInfoVersion{}).WithContext(ctx).Create(classInfoVersions).Error; err != nil {
+			if err := restoreBlocks(ctx, tx, blockNumbers); err != nil {
 				return err
 			}
-			// upsert class info
-			classInfos := make([]ClassInfo, len(kvPair.ClassInfos))
-			for i, class := range kvPair.ClassInfos {
-				classInfos[i] = ClassInfo{
-					BlockNumber:    class.BlockNumber,
-					CotaId:         class.CotaId,
-					Version:        class.Version,
-					Name:           class.Name,
-					Symbol:         class.Symbol,
-					Description:    class.Description,
-					Image:          class.Image,
-					Audio:          class.Audio,
-					Video:          class.Video,
-					Model:          class.Model,
-					Characteristic: class.Characteristic,
-					Properties:     class.Properties,
-					Localization:   class.Localization,
+			// the first batch removes the check infos of the head, they are kept until the rewind is done
+			for _, head := range heads {
+				var count int64
+				if err := tx.WithContext(ctx).Model(CheckInfo{}).Where("check_type = ? and block_number = ?", head.CheckType, head.BlockNumber).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					continue
+				}
+				if err := tx.Model(CheckInfo{}).WithContext(ctx).Create(&CheckInfo{
+					BlockNumber: head.BlockNumber,
+					BlockHash:   head.BlockHash,
+					CheckType:   head.CheckType,
+				}).Error; err != nil {
+					return err
 				}
 			}
-			if err := tx.Model(ClassInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "cota_id"}},
-				UpdateAll: true,
-			}).Create(classInfos).Error; err != nil {
-				return err
-			}
+			return nil
+		}); err != nil {
+			return err
 		}
-		// create check info
-		if err := tx.Debug().Model(CheckInfo{}).WithContext(ctx).Create(&CheckInfo{
-			BlockNumber: checkInfo.BlockNumber,
-			BlockHash:   checkInfo.BlockHash,
-			CheckType:   checkInfo.CheckType,
-		}).Error; err != nil {
+		if len(blockNumbers) < rewindBatchSize {
+			return nil
+		}
+		below = blockNumbers[len(blockNumbers)-1]
+		rp.logger.Infof(ctx, "rewound blocks down to %d of (%d, ...]", below, ancestor.BlockNumber)
+	}
+}
+
+// rewindBlocks returns the latest rewindBatchSize blocks above the ancestor and below the bound, zero for no bound,
+// that wrote to a kv pair table, in descending order
+func rewindBlocks(ctx context.Context, tx *gorm.DB, ancestor, below uint64) ([]uint64, error) {
+	blocks := make(map[uint64]bool)
+	scan := func(table, column string) error {
+		query := tx.WithContext(ctx).Table(table).Where(column+" > ?", ancestor)
+		if below > 0 {
+			query = query.Where(column+" < ?", below)
+		}
+		var blockNumbers []uint64
+		if err := query.Distinct().Order(column+" desc").Limit(rewindBatchSize).Pluck(column, &blockNumbers).Error; err != nil {
 			return err
 		}
+		for _, blockNumber := range blockNumbers {
+			blocks[blockNumber] = true
+		}
 		return nil
-	})
+	}
+	for _, table := range append([]string{"check_infos"}, kvPairTables...) {
+		if err := scan(table, "block_number"); err != nil {
+			return nil, err
+		}
+	}
+	if err := scan("cota_cells", "consumed_block_number"); err != nil {
+		return nil, err
+	}
+	blockNumbers := make([]uint64, 0, len(blocks))
+	for blockNumber := range blocks {
+		blockNumbers = append(blockNumbers, blockNumber)
+	}
+	// the versions have to be undone from the latest block down
+	sort.Slice(blockNumbers, func(i, j int) bool { return blockNumbers[i] > blockNumbers[j] })
+	if len(blockNumbers) > rewindBatchSize {
+		blockNumbers = blockNumbers[:rewindBatchSize]
+	}
+	return blockNumbers, nil
 }
 
-func (rp kvPairRepo) RestoreMetadataKvPairs(ctx context.Context, blockNumber uint64) error {
-	return rp.data.db.Transaction(func(tx *gorm.DB) error {
-		// 删掉所有新建的 issuer info
-		if err := tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(IssuerInfo{}).Error; err != nil {
-			return err
-		}
-		// 把需要回滚的 block 更新过的 issuerInfo 恢复到更新前的状态
-		var issuerInfoVersions []IssuerInfoVersion
-		if err := tx.Model(IssuerInfoVersion{}).WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("lock_hash").Order("tx_index").Find(&issuerInfoVersions).Error; err != nil {
-			return err
-		}
-		var updatedIssuerInfos []IssuerInfo
-		for _, version := range issuerInfoVersions {
-			updatedIssuerInfos = append(updatedIssuerInfos, IssuerInfo{
-				BlockNumber:  version.OldBlockNumber,
-				LockHash:     version.LockHash,
-				Version:      version.OldVersion,
-				Name:         version.OldName,
-				Avatar:       version.OldAvatar,
-				Description:  version.OldDescription,
-				Localization: version.OldLocalization,
-				UpdatedAt:    time.Now().UTC(),
-			})
-		}
-		if len(updatedIssuerInfos) > 0 {
-			if err := tx.Model(IssuerInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "lock_hash"}},
-				UpdateAll: true,
-			}).Create(updatedIssuerInfos).Error; err != nil {
-				return err
-			}
+func restoreBlocks(ctx context.Context, tx *gorm.DB, blockNumbers []uint64) error {
+	for _, blockNumber := range blockNumbers {
+		if err := restoreCotaEntryKvPairs(ctx, tx, blockNumber); err != nil {
+			return err
 		}
-		// delete all class info by the block number
-		if err := tx.Debug().WithContext(ctx).Where("block_number = ?", blockNumber).Delete(ClassInfo{}).Error; err != nil {
-			return err
-		}
-		var classInfoVersions []ClassInfoVersion
-		if err := tx.Model(ClassInfoVersion{}).WithContext(ctx).Where("block_number = ? and action_type = ?", blockNumber, 1).Group("cota_id").Order("tx_index").Find(&classInfoVersions).Error; err != nil {
-			return err
-		}
-		var updatedClassInfos []ClassInfo
-		for _, version := range classInfoVersions {
-			updatedClassInfos = append(updatedClassInfos, ClassInfo{
-				BlockNumber:    version.OldBlockNumber,
-				CotaId:         version.CotaId,
-				Version:        version.OldVersion,
-				Name:           version.OldName,
-				Symbol:         version.OldSymbol,
-				Description:    version.OldDescription,
-				Image:          version.OldImage,
-				Audio:          version.OldAudio,
-				Video:          version.OldVideo,
-				Model:          version.OldModel,
-				Characteristic: version.OldCharacteristic,
-				Properties:     version.OldProperties,
-				Localization:   version.OldLocalization,
-				UpdatedAt:      time.Now().UTC(),
-			})
-		}
-		if len(updatedClassInfos) > 0 {
-			if err := tx.Debug().Model(ClassInfo{}).WithContext(ctx).Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "cota_id"}},
-				UpdateAll: true,
-			}).Create(updatedClassInfos).Error; err != nil {
-				return err
-			}
+		if err := restoreMetadataKvPairs(ctx, tx, blockNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveCheckInfos drops the check infos and headers above the ancestor and makes it the head of both check types
+func moveCheckInfos(ctx context.Context, tx *gorm.DB, ancestor biz.CheckInfo) error {
+	if err := tx.WithContext(ctx).Where("block_number > ?", ancestor.BlockNumber).Delete(CheckInfo{}).Error; err != nil {
+		return err
+	}
+	if err := tx.WithContext(ctx).Where("block_number > ?", ancestor.BlockNumber).Delete(BlockHeader{}).Error; err != nil {
+		return err
+	}
+	// the check info of the ancestor may have been cleaned already
+	for _, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+		var last CheckInfo
+		if err := tx.WithContext(ctx).Where("check_type = ?", checkType).Order("block_number desc").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		if last.ID != 0 && last.BlockNumber == ancestor.BlockNumber {
+			continue
 		}
-		// delete check info
-		if err := tx.Debug().WithContext(ctx).Where("block_number = ? and check_type = ?", blockNumber, biz.SyncMetadata).Delete(CheckInfo{}).Error; err != nil {
+		if err := tx.Model(CheckInfo{}).WithContext(ctx).Create(&CheckInfo{
+			BlockNumber: ancestor.BlockNumber,
+			BlockHash:   ancestor.BlockHash,
+			CheckType:   checkType,
+		}).Error; err != nil {
 			return err
 		}
-		return nil
-	})
+	}
+	return saveBlockHeader(ctx, tx, ancestor)
+}
+
+// SnapshotKvPairs streams the rows of the blocks in [fromBlockNumber, toBlockNumber] table by table and only keeps
+// their hashes
+func (rp kvPairRepo) SnapshotKvPairs(ctx context.Context, fromBlockNumber, toBlockNumber uint64) (biz.KvPairSnapshot, error) {
+	snapshot := make(biz.KvPairSnapshot)
+	for _, table := range kvPairTables {
+		if err := snapshotTable(ctx, rp.data.db, table, fromBlockNumber, toBlockNumber, snapshot); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+func snapshotTable(ctx context.Context, db *gorm.DB, table string, fromBlockNumber, toBlockNumber uint64, snapshot biz.KvPairSnapshot) error {
+	rows, err := db.WithContext(ctx).Table(table).Where("block_number between ? and ?", fromBlockNumber, toBlockNumber).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := make(map[string]any)
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		snapshot.Add(table, canonicalRow(row))
+	}
+	return rows.Err()
+}
+
+// canonicalRow formats the columns of a row in name order, the bookkeeping columns are left out
+func canonicalRow(row map[string]any) string {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		switch column {
+		case "id", "created_at", "updated_at":
+			continue
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	var sb strings.Builder
+	for i, column := range columns {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		value := row[column]
+		if bytes, ok := value.([]byte); ok {
+			value = string(bytes)
+		}
+		fmt.Fprintf(&sb, "%s=%v", column, value)
+	}
+	return sb.String()
+}
+
+// checkContinuity makes sure the block to be committed directly follows the last committed block of the same check type
+// and that no resync fenced the syncer off
+func checkContinuity(ctx context.Context, tx *gorm.DB, checkInfo biz.CheckInfo) error {
+	if err := checkFence(ctx, tx); err != nil {
+		return err
+	}
+	var last CheckInfo
+	if err := tx.WithContext(ctx).Where("check_type = ?", checkInfo.CheckType).Order("block_number desc").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	if last.ID != 0 && last.BlockNumber+1 != checkInfo.BlockNumber {
+		return biz.ErrCheckInfoConflict
+	}
+	return nil
 }
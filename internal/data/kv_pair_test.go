@@ -0,0 +1,227 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+func Test_snapshotDiff(t *testing.T) {
+	row := func(blockNumber uint64, state string) string {
+		return canonicalRow(map[string]any{
+			"id":           uint64(1),
+			"block_number": blockNumber,
+			"state":        []byte(state),
+			"created_at":   time.Now(),
+			"updated_at":   time.Now(),
+		})
+	}
+	snapshot := func(rows map[string][]string) biz.KvPairSnapshot {
+		s := make(biz.KvPairSnapshot)
+		for table, tableRows := range rows {
+			for _, row := range tableRows {
+				s.Add(table, row)
+			}
+		}
+		return s
+	}
+	tests := []struct {
+		name   string
+		before biz.KvPairSnapshot
+		after  biz.KvPairSnapshot
+		want   []biz.TableChange
+	}{
+		{
+			name:   "should ignore the bookkeeping columns",
+			before: snapshot(map[string][]string{"hold_cota_nft_kv_pairs": {row(10, "00")}}),
+			after:  snapshot(map[string][]string{"hold_cota_nft_kv_pairs": {row(10, "00")}}),
+		},
+		{
+			name:   "should count the changed rows",
+			before: snapshot(map[string][]string{"hold_cota_nft_kv_pairs": {row(10, "00"), row(11, "00")}}),
+			after:  snapshot(map[string][]string{"hold_cota_nft_kv_pairs": {row(10, "00"), row(11, "01")}}),
+			want:   []biz.TableChange{{Table: "hold_cota_nft_kv_pairs", Added: 1, Removed: 1}},
+		},
+		{
+			name:   "should count duplicated rows",
+			before: snapshot(map[string][]string{"withdraw_cota_nft_kv_pairs": {row(10, "00")}}),
+			after:  snapshot(map[string][]string{"withdraw_cota_nft_kv_pairs": {row(10, "00"), row(10, "00")}, "class_infos": {row(12, "")}}),
+			want: []biz.TableChange{
+				{Table: "class_infos", Added: 1},
+				{Table: "withdraw_cota_nft_kv_pairs", Added: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := biz.DiffKvPairSnapshots(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffKvPairSnapshots() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKvPairRepo_RewindKvPairs(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     uint64
+		ancestor uint64
+	}{
+		{
+			name:     "should rewind the blocks of one batch",
+			head:     30,
+			ancestor: 20,
+		},
+		{
+			name:     "should rewind the blocks of several batches down from the head",
+			head:     2*rewindBatchSize + 30,
+			ancestor: 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTestData(t)
+			ctx := context.Background()
+			log := logger.NewLogger(io.Discard, "", 0)
+			repo := NewKvPairRepo(data, &config.App{}, log)
+			lockHash := fmt.Sprintf("%064d", 1)
+			cotaId := fmt.Sprintf("%040d", 2)
+			for blockNumber := uint64(1); blockNumber <= tt.head; blockNumber++ {
+				// every block raises the issued count of one definition, so each one depends on the version of the one before
+				pairs := &biz.KvPair{Registers: []biz.RegisterCotaKvPair{{BlockNumber: blockNumber, LockHash: fmt.Sprintf("%064d", blockNumber)}}}
+				define := biz.DefineCotaNftKvPair{BlockNumber: blockNumber, CotaId: cotaId, Total: 1000, Issued: uint32(blockNumber), LockHash: lockHash}
+				if blockNumber == 1 {
+					pairs.DefineCotas = []biz.DefineCotaNftKvPair{define}
+				} else {
+					pairs.UpdatedDefineCotas = []biz.DefineCotaNftKvPair{define}
+				}
+				checkInfo := biz.CheckInfo{BlockNumber: blockNumber, BlockHash: blockHash(blockNumber).String()[2:]}
+				if err := repo.CreateKvPairs(ctx, checkInfo, pairs); err != nil {
+					t.Fatalf("CreateKvPairs(%d) error = %v", blockNumber, err)
+				}
+			}
+			ancestor := biz.CheckInfo{BlockNumber: tt.ancestor, BlockHash: blockHash(tt.ancestor).String()[2:]}
+			if err := repo.RewindKvPairs(ctx, ancestor); err != nil {
+				t.Fatalf("RewindKvPairs() error = %v", err)
+			}
+			var registers int64
+			if err := data.db.Model(RegisterCotaKvPair{}).Count(&registers).Error; err != nil {
+				t.Fatal(err)
+			}
+			if registers != int64(tt.ancestor) {
+				t.Errorf("registers = %d, want %d", registers, tt.ancestor)
+			}
+			var define DefineCotaNftKvPair
+			if err := data.db.Where("cota_id = ?", cotaId).First(&define).Error; err != nil {
+				t.Fatal(err)
+			}
+			if define.Issued != uint32(tt.ancestor) || define.BlockNumber != tt.ancestor {
+				t.Errorf("define issued %d at block %d, want %d", define.Issued, define.BlockNumber, tt.ancestor)
+			}
+			for _, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+				var last CheckInfo
+				if err := data.db.Where("check_type = ?", checkType).Order("block_number desc").Limit(1).Find(&last).Error; err != nil {
+					t.Fatal(err)
+				}
+				if last.BlockNumber != tt.ancestor || last.BlockHash != ancestor.BlockHash {
+					t.Errorf("%s check info at %d, want %d", checkType, last.BlockNumber, tt.ancestor)
+				}
+			}
+		})
+	}
+}
+
+func TestKvPairRepo_SnapshotKvPairs(t *testing.T) {
+	data := newTestData(t)
+	ctx := context.Background()
+	repo := NewKvPairRepo(data, &config.App{}, logger.NewLogger(io.Discard, "", 0))
+	for blockNumber := uint64(1); blockNumber <= 3; blockNumber++ {
+		checkInfo := biz.CheckInfo{BlockNumber: blockNumber, BlockHash: blockHash(blockNumber).String()[2:]}
+		pairs := &biz.KvPair{Registers: []biz.RegisterCotaKvPair{{BlockNumber: blockNumber, LockHash: fmt.Sprintf("%064d", blockNumber)}}}
+		if err := repo.CreateKvPairs(ctx, checkInfo, pairs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := repo.SnapshotKvPairs(ctx, 2, 3)
+	if err != nil {
+		t.Fatalf("SnapshotKvPairs() error = %v", err)
+	}
+	if err := data.db.Model(RegisterCotaKvPair{}).Where("block_number = ?", 3).Update("lock_hash", fmt.Sprintf("%064d", 9)).Error; err != nil {
+		t.Fatal(err)
+	}
+	after, err := repo.SnapshotKvPairs(ctx, 2, 3)
+	if err != nil {
+		t.Fatalf("SnapshotKvPairs() error = %v", err)
+	}
+	want := []biz.TableChange{{Table: "register_cota_kv_pairs", Added: 1, Removed: 1}}
+	if got := biz.DiffKvPairSnapshots(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffKvPairSnapshots() = %v, want %v", got, want)
+	}
+}
+
+func TestKvPairRepo_RewindKvPairs_metadataVersions(t *testing.T) {
+	data := newTestData(t)
+	ctx := context.Background()
+	repo := NewKvPairRepo(data, &config.App{}, logger.NewLogger(io.Discard, "", 0))
+	lockHash := fmt.Sprintf("%064d", 1)
+	cotaId := fmt.Sprintf("%040d", 2)
+	// the issuer and the class are created at block 1 and updated by two transactions of block 3
+	blocks := map[uint64]*biz.KvPair{
+		1: {
+			IssuerInfos: []biz.IssuerInfo{{BlockNumber: 1, LockHash: lockHash, Version: "0", Name: "issuer"}},
+			ClassInfos:  []biz.ClassInfo{{BlockNumber: 1, CotaId: cotaId, Version: "0", Name: "class"}},
+		},
+		2: {},
+		3: {
+			IssuerInfos: []biz.IssuerInfo{{BlockNumber: 3, LockHash: lockHash, Version: "0", Name: "renamed issuer", TxIndex: 1}},
+			ClassInfos: []biz.ClassInfo{
+				{BlockNumber: 3, CotaId: cotaId, Version: "0", Name: "renamed class", TxIndex: 1},
+				{BlockNumber: 3, CotaId: fmt.Sprintf("%040d", 3), Version: "0", Name: "new class", TxIndex: 2},
+			},
+		},
+	}
+	apply := func(from, to uint64) {
+		for blockNumber := from; blockNumber <= to; blockNumber++ {
+			checkInfo := biz.CheckInfo{BlockNumber: blockNumber, BlockHash: blockHash(blockNumber).String()[2:]}
+			if err := repo.CreateKvPairs(ctx, checkInfo, blocks[blockNumber]); err != nil {
+				t.Fatalf("CreateKvPairs(%d) error = %v", blockNumber, err)
+			}
+		}
+	}
+	versions := func() biz.KvPairSnapshot {
+		s := make(biz.KvPairSnapshot)
+		for _, table := range []string{"issuer_info_versions", "class_info_versions", "issuer_infos", "class_infos"} {
+			var rows []map[string]any
+			if err := data.db.Table(table).Find(&rows).Error; err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				s.Add(table, canonicalRow(row))
+			}
+		}
+		return s
+	}
+	apply(1, 3)
+	before := versions()
+	if err := repo.RewindKvPairs(ctx, biz.CheckInfo{BlockNumber: 1, BlockHash: blockHash(1).String()[2:]}); err != nil {
+		t.Fatalf("RewindKvPairs() error = %v", err)
+	}
+	var rewound int64
+	if err := data.db.Model(ClassInfoVersion{}).Where("block_number > ?", 1).Count(&rewound).Error; err != nil {
+		t.Fatal(err)
+	}
+	if rewound != 0 {
+		t.Errorf("class info versions above the ancestor = %d, want 0", rewound)
+	}
+	apply(2, 3)
+	if changes := biz.DiffKvPairSnapshots(before, versions()); len(changes) > 0 {
+		t.Errorf("metadata changed by rewinding and re-applying: %v", changes)
+	}
+}
@@ -2,8 +2,13 @@ package data
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
 
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
 )
 
@@ -12,43 +17,97 @@ type MetadataSyncer struct {
 	cotaWitnessArgsParser CotaWitnessArgsParser
 	issuerInfoUsecase     *biz.IssuerInfoUsecase
 	classInfoUsecase      *biz.ClassInfoUsecase
+	policy                entryPolicy
 }
 
 func NewMetadataSyncer(
 	kvPairUsecase *biz.SyncKvPairUsecase, cotaWitnessArgsParser CotaWitnessArgsParser, issuerInfoUsecase *biz.IssuerInfoUsecase,
-	classInfoUsecase *biz.ClassInfoUsecase) MetadataSyncer {
+	classInfoUsecase *biz.ClassInfoUsecase, appConf *config.App, logger *logger.Logger) MetadataSyncer {
 	return MetadataSyncer{
 		kvPairUsecase:         kvPairUsecase,
 		cotaWitnessArgsParser: cotaWitnessArgsParser,
 		issuerInfoUsecase:     issuerInfoUsecase,
 		classInfoUsecase:      classInfoUsecase,
+		policy:                newEntryPolicy(appConf, logger),
 	}
 }
 
 func (bp MetadataSyncer) Sync(ctx context.Context, block *ckbTypes.Block, checkInfo biz.CheckInfo, systemScripts SystemScripts) error {
+	pairs, err := bp.KvPairs(ctx, block, systemScripts)
+	if err != nil {
+		return err
+	}
+	err = bp.kvPairUsecase.CreateMetadataKvPairs(ctx, checkInfo, &pairs)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// KvPairs parses the issuer and class metadata of the block without storing them
+func (bp MetadataSyncer) KvPairs(ctx context.Context, block *ckbTypes.Block, systemScripts SystemScripts) (biz.KvPair, error) {
+	systemScripts = systemScripts.At(block.Header.Number)
+	if err := bp.cotaWitnessArgsParser.ResolveInputs(ctx, block.Transactions, systemScripts.CotaType); err != nil {
+		return biz.KvPair{}, err
+	}
 	var entryVec []biz.Entry
+	var deadLetters []biz.DeadLetter
 	for index, tx := range block.Transactions {
-		entries, err := bp.cotaWitnessArgsParser.Parse(tx, uint32(index), systemScripts.CotaType)
-		if err != nil && err.Error() == "No data" {
+		entries, err := bp.cotaWitnessArgsParser.Parse(ctx, tx, uint32(index), systemScripts.CotaType)
+		if err != nil {
+			deadLetter, err := bp.policy.handle(ctx, block, biz.SyncMetadata, err)
+			if err != nil {
+				return biz.KvPair{}, err
+			}
+			deadLetters = append(deadLetters, deadLetter)
 			continue
-		} else if err != nil {
-			return err
 		}
 		entryVec = append(entryVec, entries...)
 	}
-	pairs, err := bp.parseMetadata(block.Header.Number, entryVec)
-	err = bp.kvPairUsecase.CreateMetadataKvPairs(ctx, checkInfo, &pairs)
+	pairs, err := bp.parseMetadata(ctx, block, entryVec)
 	if err != nil {
-		return err
+		return biz.KvPair{}, err
 	}
-	return nil
+	pairs.DeadLetters = append(deadLetters, pairs.DeadLetters...)
+	return pairs, nil
+}
+
+// ParsedKvPairs parses the issuer and class metadata from the entries already extracted by BlockSyncer.Parse
+func (bp MetadataSyncer) ParsedKvPairs(ctx context.Context, parsedBlock ParsedBlock) (biz.KvPair, error) {
+	return bp.parseMetadata(ctx, parsedBlock.Block, parsedBlock.Entries)
 }
 
 func (bp MetadataSyncer) Rollback(ctx context.Context, blockNumber uint64) error {
 	return bp.kvPairUsecase.RestoreMetadataKvPairs(ctx, blockNumber)
 }
 
-func (bp MetadataSyncer) parseMetadata(blockNumber uint64, entries []biz.Entry) (biz.KvPair, error) {
+// parseMetadata parses the metadata of the entries transaction by transaction, a transaction with an invalid entry is
+// handled by the entry error policy as a whole
+func (bp MetadataSyncer) parseMetadata(ctx context.Context, block *ckbTypes.Block, entries []biz.Entry) (biz.KvPair, error) {
+	var kvPair biz.KvPair
+	for start := 0; start < len(entries); {
+		end := start + 1
+		for end < len(entries) && entries[end].TxIndex == entries[start].TxIndex {
+			end++
+		}
+		txPairs, err := bp.parseTxMetadata(block.Header.Number, entries[start:end])
+		start = end
+		if err != nil {
+			deadLetter, err := bp.policy.handle(ctx, block, biz.SyncMetadata, err)
+			if err != nil {
+				return kvPair, err
+			}
+			kvPair.DeadLetters = append(kvPair.DeadLetters, deadLetter)
+			continue
+		}
+		kvPair.IssuerInfos = append(kvPair.IssuerInfos, txPairs.IssuerInfos...)
+		kvPair.ClassInfos = append(kvPair.ClassInfos, txPairs.ClassInfos...)
+	}
+	return kvPair, nil
+}
+
+// parseTxMetadata skips the metadata that cannot be decoded, only invalid entries fail
+func (bp MetadataSyncer) parseTxMetadata(blockNumber uint64, entries []biz.Entry) (biz.KvPair, error) {
 	var kvPair biz.KvPair
 	for _, entry := range entries {
 		// Parse Issuer/Class Metadata
@@ -60,18 +119,41 @@ func (bp MetadataSyncer) parseMetadata(blockNumber uint64, entries []biz.Entry)
 			switch ctMeta.Metadata.Type {
 			case "issuer":
 				issuerInfo, err := bp.issuerInfoUsecase.ParseMetadata(blockNumber, entry.TxIndex, entry.LockScript, ctMeta.Metadata.Data)
-				if err != nil {
+				if errors.Is(err, biz.ErrInvalidEntry) {
 					return kvPair, err
 				}
+				if err != nil {
+					continue
+				}
 				kvPair.IssuerInfos = append(kvPair.IssuerInfos, issuerInfo)
 			case "cota":
 				classInfo, err := bp.classInfoUsecase.ParseMetadata(blockNumber, entry.TxIndex, ctMeta.Metadata.Data)
-				if err != nil {
+				if errors.Is(err, biz.ErrInvalidEntry) {
 					return kvPair, err
 				}
+				if err != nil {
+					continue
+				}
 				kvPair.ClassInfos = append(kvPair.ClassInfos, classInfo)
 			}
 		}
 	}
 	return kvPair, nil
 }
+
+// column is a metadata field and the varchar length of its column
+type column struct {
+	name   string
+	value  string
+	length int
+}
+
+// checkColumnLengths fails with an invalid entry when a field does not fit its column, the insert would fail otherwise
+func checkColumnLengths(txIndex uint32, columns []column) error {
+	for _, c := range columns {
+		if n := utf8.RuneCountInString(c.value); n > c.length {
+			return biz.InvalidEntry(txIndex, fmt.Errorf("%s of %d characters exceeds %d", c.name, n, c.length))
+		}
+	}
+	return nil
+}
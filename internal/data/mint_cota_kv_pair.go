@@ -27,7 +27,11 @@ func (rp mintCotaKvPairRepo) ParseMintCotaEntries(blockNumber uint64, entry biz.
 }
 
 func generateDefineWithdrawV1KvPairs(blockNumber uint64, entry biz.Entry, rp mintCotaKvPairRepo) (updatedDefineCotas []biz.DefineCotaNftKvPair, withdrawCotas []biz.WithdrawCotaNftKvPair, err error) {
-	entries := smt.MintCotaNFTV1EntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.MintCotaNFTV1EntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	defineCotaKeyVec := entries.DefineKeys()
 	defineCotaValueVec := entries.DefineNewValues()
 	lockHash, err := entry.LockScript.Hash()
@@ -40,14 +44,15 @@ func generateDefineWithdrawV1KvPairs(blockNumber uint64, entry biz.Entry, rp min
 		key := defineCotaKeyVec.Get(i)
 		value := defineCotaValueVec.Get(i)
 		updatedDefineCotas = append(updatedDefineCotas, biz.DefineCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.CotaId().RawData()),
-			Total:       binary.BigEndian.Uint32(value.Total().RawData()),
-			Issued:      binary.BigEndian.Uint32(value.Issued().RawData()),
-			Configure:   value.Configure().AsSlice()[0],
-			LockHash:    lockHashStr,
-			LockHashCRC: lockHashCRC32,
-			UpdatedAt:   time.Now(),
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.CotaId().RawData()),
+			Total:         binary.BigEndian.Uint32(value.Total().RawData()),
+			Issued:        binary.BigEndian.Uint32(value.Issued().RawData()),
+			Configure:     value.Configure().AsSlice()[0],
+			LockHash:      lockHashStr,
+			LockHashCRC:   lockHashCRC32,
+			UpdatedAt:     time.Now(),
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	withdrawKeyVec := entries.WithdrawalKeys()
@@ -57,7 +62,11 @@ func generateDefineWithdrawV1KvPairs(blockNumber uint64, entry biz.Entry, rp min
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -81,13 +90,18 @@ func generateDefineWithdrawV1KvPairs(blockNumber uint64, entry biz.Entry, rp min
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
 }
 
 func generateDefineWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp mintCotaKvPairRepo) (updatedDefineCotas []biz.DefineCotaNftKvPair, withdrawCotas []biz.WithdrawCotaNftKvPair, err error) {
-	entries := smt.MintCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.MintCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	defineCotaKeyVec := entries.DefineKeys()
 	defineCotaValueVec := entries.DefineNewValues()
 	lockHash, err := entry.LockScript.Hash()
@@ -100,14 +114,15 @@ func generateDefineWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp min
 		key := defineCotaKeyVec.Get(i)
 		value := defineCotaValueVec.Get(i)
 		updatedDefineCotas = append(updatedDefineCotas, biz.DefineCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.CotaId().RawData()),
-			Total:       binary.BigEndian.Uint32(value.Total().RawData()),
-			Issued:      binary.BigEndian.Uint32(value.Issued().RawData()),
-			Configure:   value.Configure().AsSlice()[0],
-			LockHash:    lockHashStr,
-			LockHashCRC: lockHashCRC32,
-			UpdatedAt:   time.Now().UTC(),
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.CotaId().RawData()),
+			Total:         binary.BigEndian.Uint32(value.Total().RawData()),
+			Issued:        binary.BigEndian.Uint32(value.Issued().RawData()),
+			Configure:     value.Configure().AsSlice()[0],
+			LockHash:      lockHashStr,
+			LockHashCRC:   lockHashCRC32,
+			UpdatedAt:     time.Now().UTC(),
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	withdrawKeyVec := entries.WithdrawalKeys()
@@ -117,7 +132,11 @@ func generateDefineWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp min
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.CotaId().RawData())
 		outpointStr := hex.EncodeToString(value.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -141,6 +160,7 @@ func generateDefineWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp min
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
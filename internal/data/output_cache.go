@@ -0,0 +1,74 @@
+package data
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+const defaultInputCacheSize = 100000
+
+type outputCacheItem struct {
+	key    string
+	output *ckbTypes.CellOutput
+}
+
+// outputCache is a concurrency safe LRU cache of cell outputs keyed by out point
+type outputCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+func newOutputCache(capacity int) *outputCache {
+	if capacity < 1 {
+		capacity = defaultInputCacheSize
+	}
+	return &outputCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func outPointKey(txHash ckbTypes.Hash, index uint) string {
+	return fmt.Sprintf("%s-%d", txHash.String(), index)
+}
+
+func (c *outputCache) get(txHash ckbTypes.Hash, index uint) (*ckbTypes.CellOutput, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.items[outPointKey(txHash, index)]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*outputCacheItem).output, true
+}
+
+func (c *outputCache) add(txHash ckbTypes.Hash, index uint, output *ckbTypes.CellOutput) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := outPointKey(txHash, index)
+	if element, ok := c.items[key]; ok {
+		element.Value.(*outputCacheItem).output = output
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.order.PushFront(&outputCacheItem{key: key, output: output})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*outputCacheItem).key)
+	}
+}
+
+// addTransaction caches every output of the transaction
+func (c *outputCache) addTransaction(tx *ckbTypes.Transaction) {
+	for i, output := range tx.Outputs {
+		c.add(tx.Hash, uint(i), output)
+	}
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"testing"
+
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func Test_outputCache(t *testing.T) {
+	txHash1 := ckbTypes.HexToHash("0x42a5b04df6ff0e2819ec6b814d33a028ed1593bc9e1cca463f679af555dce106")
+	txHash2 := ckbTypes.HexToHash("0x698f2a29021ebd741b4a38b4a5f8fa3686103ba66773e7549b204a67db015ba0")
+	type op struct {
+		add      bool
+		outPoint ckbTypes.OutPoint
+	}
+	tests := []struct {
+		name    string
+		ops     []op
+		get     ckbTypes.OutPoint
+		wantHit bool
+	}{
+		{
+			name:    "should hit the cached out point",
+			ops:     []op{{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash1, Index: 0}}},
+			get:     ckbTypes.OutPoint{TxHash: txHash1, Index: 0},
+			wantHit: true,
+		},
+		{
+			name:    "should miss another index of the same transaction",
+			ops:     []op{{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash1, Index: 0}}},
+			get:     ckbTypes.OutPoint{TxHash: txHash1, Index: 1},
+			wantHit: false,
+		},
+		{
+			name: "should evict the least recently used out point",
+			ops: []op{
+				{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash1, Index: 0}},
+				{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash2, Index: 0}},
+				{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash2, Index: 1}},
+			},
+			get:     ckbTypes.OutPoint{TxHash: txHash1, Index: 0},
+			wantHit: false,
+		},
+		{
+			name: "should keep the recently read out point",
+			ops: []op{
+				{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash1, Index: 0}},
+				{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash2, Index: 0}},
+				{add: false, outPoint: ckbTypes.OutPoint{TxHash: txHash1, Index: 0}},
+				{add: true, outPoint: ckbTypes.OutPoint{TxHash: txHash2, Index: 1}},
+			},
+			get:     ckbTypes.OutPoint{TxHash: txHash1, Index: 0},
+			wantHit: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newOutputCache(2)
+			for _, o := range tt.ops {
+				if o.add {
+					c.add(o.outPoint.TxHash, o.outPoint.Index, &ckbTypes.CellOutput{Capacity: 100})
+				} else {
+					c.get(o.outPoint.TxHash, o.outPoint.Index)
+				}
+			}
+			if _, hit := c.get(tt.get.TxHash, tt.get.Index); hit != tt.wantHit {
+				t.Errorf("get() hit = %v, want %v", hit, tt.wantHit)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"gorm.io/gorm"
+)
+
+type RegistryHistory struct {
+	ID            uint `gorm:"primaryKey"`
+	BlockNumber   uint64
+	TxIndex       uint32
+	TxHash        string
+	OutputIndex   uint32
+	SmtRoot       string
+	RegisterCount uint32
+	ScriptVersion uint8
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func createRegistryHistories(ctx context.Context, tx *gorm.DB, kvPair *biz.KvPair) error {
+	if len(kvPair.RegistryHistories) == 0 {
+		return nil
+	}
+	histories := make([]RegistryHistory, len(kvPair.RegistryHistories))
+	for i, history := range kvPair.RegistryHistories {
+		histories[i] = RegistryHistory{
+			BlockNumber:   history.BlockNumber,
+			TxIndex:       history.TxIndex,
+			TxHash:        history.TxHash,
+			OutputIndex:   history.OutputIndex,
+			SmtRoot:       history.SmtRoot,
+			RegisterCount: history.RegisterCount,
+			ScriptVersion: history.ScriptVersion,
+		}
+	}
+	return tx.Model(RegistryHistory{}).WithContext(ctx).Create(histories).Error
+}
+
+func restoreRegistryHistories(ctx context.Context, tx *gorm.DB, blockNumber uint64) error {
+	return tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(RegistryHistory{}).Error
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/blockchain"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
+	"github.com/nervina-labs/cota-smt-go/smt"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// RegistryParser extracts the registrations of the registry cell updates. The registry entries are read from the
+// witness of the registry cell input only, the registry cell is not in the cota cell index, so its inputs are always
+// resolved with the node.
+type RegistryParser struct {
+	client *CkbNodeClient
+	cache  *outputCache
+}
+
+func NewRegistryParser(client *CkbNodeClient, appConf *config.App) RegistryParser {
+	return RegistryParser{
+		client: client,
+		cache:  newOutputCache(appConf.InputCacheSize),
+	}
+}
+
+// ResolveInputs loads the previous outputs of the inputs of the registry transactions in txs into the cache with a
+// single batch request
+func (p RegistryParser) ResolveInputs(ctx context.Context, txs []*ckbTypes.Transaction, registryType SystemScriptList) error {
+	var candidates []*ckbTypes.Transaction
+	for _, tx := range txs {
+		if registryOutputIndex(tx.Outputs, registryType) >= 0 {
+			candidates = append(candidates, tx)
+			// later transactions of the same block may spend the new registry cell
+			p.cache.addTransaction(tx)
+		}
+	}
+	var missing []*ckbTypes.OutPoint
+	for _, tx := range candidates {
+		for _, input := range tx.Inputs {
+			prevOutpoint := input.PreviousOutput
+			if _, ok := p.cache.get(prevOutpoint.TxHash, prevOutpoint.Index); ok {
+				metrics.InputCacheHits.Add(1)
+				continue
+			}
+			metrics.InputCacheMisses.Add(1)
+			missing = append(missing, prevOutpoint)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fetchTransactions(ctx, p.client, p.cache, missing)
+}
+
+// Parse returns the registrations of the transaction and the registry history of the update. A transaction creating
+// the registry cell without spending one, or whose registry input carries no input type, registers nothing.
+func (p RegistryParser) Parse(ctx context.Context, blockNumber uint64, tx *ckbTypes.Transaction, txIndex uint32, registryType SystemScriptList) ([]biz.RegisterCotaKvPair, *biz.RegistryHistory, error) {
+	outputIndex := registryOutputIndex(tx.Outputs, registryType)
+	if outputIndex < 0 {
+		return nil, nil, nil
+	}
+	inputIndex, err := p.registryInputIndex(ctx, tx.Inputs, registryType)
+	if err != nil {
+		return nil, nil, err
+	}
+	if inputIndex < 0 || inputIndex >= len(tx.Witnesses) || len(tx.Witnesses[inputIndex]) == 0 {
+		return nil, nil, nil
+	}
+	witnessArgs, err := blockchain.WitnessArgsFromSlice(tx.Witnesses[inputIndex], true)
+	if err != nil {
+		return nil, nil, biz.MalformedEntry(txIndex, err)
+	}
+	if witnessArgs.InputType().IsNone() {
+		return nil, nil, nil
+	}
+	inputType, err := witnessArgs.InputType().IntoBytes()
+	if err != nil {
+		return nil, nil, biz.MalformedEntry(txIndex, err)
+	}
+	registryEntries, err := smt.CotaNFTRegistryEntriesFromSlice(inputType.RawData(), true)
+	if err != nil {
+		return nil, nil, biz.MalformedEntry(txIndex, err)
+	}
+	if outputIndex >= len(tx.OutputsData) || len(tx.OutputsData[outputIndex]) < 33 {
+		return nil, nil, biz.MalformedEntry(txIndex, fmt.Errorf("registry cell at output %d without smt root", outputIndex))
+	}
+	scriptVersion := registryType.versionOf(tx.Outputs[outputIndex].Type)
+	registryVec := registryEntries.Registries()
+	registers := make([]biz.RegisterCotaKvPair, registryVec.Len())
+	for i := uint(0); i < registryVec.Len(); i++ {
+		registers[i] = biz.RegisterCotaKvPair{
+			BlockNumber:   blockNumber,
+			LockHash:      hex.EncodeToString(registryVec.Get(i).LockHash().RawData()),
+			ScriptVersion: scriptVersion,
+		}
+	}
+	history := &biz.RegistryHistory{
+		BlockNumber:   blockNumber,
+		TxIndex:       txIndex,
+		TxHash:        tx.Hash.String()[2:],
+		OutputIndex:   uint32(outputIndex),
+		SmtRoot:       hex.EncodeToString(tx.OutputsData[outputIndex][1:33]),
+		RegisterCount: uint32(len(registers)),
+		ScriptVersion: scriptVersion,
+	}
+	return registers, history, nil
+}
+
+// registryInputIndex returns the index of the input spending the registry cell, its witness is the one of the registry
+// type script group
+func (p RegistryParser) registryInputIndex(ctx context.Context, inputs []*ckbTypes.CellInput, registryType SystemScriptList) (int, error) {
+	for i, input := range inputs {
+		output, err := p.previousOutput(ctx, input.PreviousOutput)
+		if err != nil {
+			return -1, err
+		}
+		if isRegistryCell(output, registryType) {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func (p RegistryParser) previousOutput(ctx context.Context, prevOutpoint *ckbTypes.OutPoint) (*ckbTypes.CellOutput, error) {
+	if output, ok := p.cache.get(prevOutpoint.TxHash, prevOutpoint.Index); ok {
+		return output, nil
+	}
+	metrics.RpcCalls.Add("get_transaction", 1)
+	prevTx, err := p.client.Rpc.GetTransaction(ctx, prevOutpoint.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.addTransaction(prevTx.Transaction)
+	return prevTx.Transaction.Outputs[prevOutpoint.Index], nil
+}
+
+func isRegistryCell(output *ckbTypes.CellOutput, registryType SystemScriptList) bool {
+	if output.Type == nil {
+		return false
+	}
+	return registryType.matches(output.Type)
+}
+
+// registryOutputIndex returns the index of the first registry cell in outputs or -1
+func registryOutputIndex(outputs []*ckbTypes.CellOutput, registryType SystemScriptList) int {
+	for i, output := range outputs {
+		if isRegistryCell(output, registryType) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func molNumber(n int) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(n))
+	return b
+}
+
+func molFixvec(items ...[]byte) []byte {
+	return append(molNumber(len(items)), bytes.Join(items, nil)...)
+}
+
+func molBytes(b []byte) []byte {
+	return append(molNumber(len(b)), b...)
+}
+
+// molTable encodes a table or a dynamic vector of the parts
+func molTable(parts ...[]byte) []byte {
+	header := 4 * (len(parts) + 1)
+	offset := header
+	out := []byte{}
+	for _, part := range parts {
+		out = append(out, molNumber(offset)...)
+		offset += len(part)
+	}
+	return append(append(molNumber(offset), out...), bytes.Join(parts, nil)...)
+}
+
+func TestRegistryParser_Parse(t *testing.T) {
+	registry := SystemScript{CodeHash: ckbTypes.HexToHash("0x01"), HashType: ckbTypes.HashTypeType, Args: []byte{0xab}, Version: 1}
+	registryType := &ckbTypes.Script{CodeHash: registry.CodeHash, HashType: registry.HashType, Args: registry.Args}
+	prevTxHash := ckbTypes.HexToHash("0x02")
+	txHash := ckbTypes.HexToHash("0x03")
+	lockHash := bytes.Repeat([]byte{0x11}, 32)
+	root := bytes.Repeat([]byte{0x22}, 32)
+	// witnessArgs encodes a witness with the registry entries of the lock hashes as input type
+	witnessArgs := func(lockHashes ...[]byte) []byte {
+		registries := make([][]byte, len(lockHashes))
+		for i, hash := range lockHashes {
+			registries[i] = append(append([]byte{}, hash...), make([]byte, 32)...)
+		}
+		entries := molTable(molFixvec(registries...), molBytes(nil))
+		return molTable(nil, molBytes(entries), nil)
+	}
+	tx := func(witnesses ...[]byte) *ckbTypes.Transaction {
+		return &ckbTypes.Transaction{
+			Hash: txHash,
+			Inputs: []*ckbTypes.CellInput{
+				{PreviousOutput: &ckbTypes.OutPoint{TxHash: prevTxHash, Index: 0}},
+				{PreviousOutput: &ckbTypes.OutPoint{TxHash: prevTxHash, Index: 1}},
+			},
+			Outputs:     []*ckbTypes.CellOutput{{Lock: &ckbTypes.Script{}}, {Lock: &ckbTypes.Script{}, Type: registryType}},
+			OutputsData: [][]byte{nil, append([]byte{0x00}, root...)},
+			Witnesses:   witnesses,
+		}
+	}
+	tests := []struct {
+		name          string
+		tx            *ckbTypes.Transaction
+		wantRegisters []biz.RegisterCotaKvPair
+		wantHistory   *biz.RegistryHistory
+		wantErr       error
+	}{
+		{
+			name: "should parse the witness of the registry input",
+			tx:   tx(nil, witnessArgs(lockHash)),
+			wantRegisters: []biz.RegisterCotaKvPair{{
+				BlockNumber:   100,
+				LockHash:      "1111111111111111111111111111111111111111111111111111111111111111",
+				ScriptVersion: 1,
+			}},
+			wantHistory: &biz.RegistryHistory{
+				BlockNumber:   100,
+				TxIndex:       2,
+				TxHash:        "0000000000000000000000000000000000000000000000000000000000000003",
+				OutputIndex:   1,
+				SmtRoot:       "2222222222222222222222222222222222222222222222222222222222222222",
+				RegisterCount: 1,
+				ScriptVersion: 1,
+			},
+		},
+		{
+			name: "should ignore the registry entries in the witness of another input",
+			tx:   tx(witnessArgs(lockHash)),
+		},
+		{
+			name:    "should refuse a malformed witness of the registry input",
+			tx:      tx(nil, []byte{0x01}),
+			wantErr: biz.ErrMalformedEntry,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewRegistryParser(nil, &config.App{InputCacheSize: 10})
+			p.cache.add(prevTxHash, 0, &ckbTypes.CellOutput{Lock: &ckbTypes.Script{}})
+			p.cache.add(prevTxHash, 1, &ckbTypes.CellOutput{Lock: &ckbTypes.Script{}, Type: registryType})
+			registers, history, err := p.Parse(context.Background(), 100, tt.tx, 2, SystemScriptList{registry})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(registers) != len(tt.wantRegisters) {
+				t.Fatalf("Parse() registers = %+v, want %+v", registers, tt.wantRegisters)
+			}
+			for i := range registers {
+				if registers[i] != tt.wantRegisters[i] {
+					t.Errorf("Parse() registers[%d] = %+v, want %+v", i, registers[i], tt.wantRegisters[i])
+				}
+			}
+			if (history == nil) != (tt.wantHistory == nil) || (history != nil && *history != *tt.wantHistory) {
+				t.Errorf("Parse() history = %+v, want %+v", history, tt.wantHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+const (
+	// ResyncFenceName names the fence held while a resync rewrites the kv pair tables
+	ResyncFenceName = "resync"
+	// resyncParseWorkers is used when parse_workers is not configured
+	resyncParseWorkers = 4
+)
+
+// ErrResyncTooLarge is returned for a resync re-applying more blocks than allowed
+var ErrResyncTooLarge = errors.New("resync re-applies too many blocks")
+
+// ResyncSummary describes a finished resync, Changes counts the rows written by the blocks in [From, To] that differ
+type ResyncSummary struct {
+	From    uint64
+	To      uint64
+	Head    uint64
+	Changes []biz.TableChange
+}
+
+// Resyncer re-processes synced blocks with the current parsers, e.g. after a parser fix. The version tables record the
+// changes in block order only, so every block from the start of the range up to the synced head is undone and then
+// parsed and committed again, while a fence keeps the live syncer from committing in between.
+type Resyncer struct {
+	client           *CkbNodeClient
+	systemScripts    SystemScripts
+	blockSyncer      BlockSyncer
+	unifiedSyncer    UnifiedSyncer
+	kvPairUsecase    *biz.SyncKvPairUsecase
+	checkInfoUsecase *biz.CheckInfoUsecase
+	fenceUsecase     *biz.SyncFenceUsecase
+	logger           *logger.Logger
+	workers          int
+}
+
+func NewResyncer(client *CkbNodeClient, systemScripts SystemScripts, blockSyncer BlockSyncer, unifiedSyncer UnifiedSyncer, kvPairUsecase *biz.SyncKvPairUsecase,
+	checkInfoUsecase *biz.CheckInfoUsecase, fenceUsecase *biz.SyncFenceUsecase, appConf *config.App, logger *logger.Logger) *Resyncer {
+	workers := appConf.ParseWorkers
+	if workers <= 0 {
+		workers = resyncParseWorkers
+	}
+	return &Resyncer{
+		client:           client,
+		systemScripts:    systemScripts,
+		blockSyncer:      blockSyncer,
+		unifiedSyncer:    unifiedSyncer,
+		kvPairUsecase:    kvPairUsecase,
+		checkInfoUsecase: checkInfoUsecase,
+		fenceUsecase:     fenceUsecase,
+		logger:           logger,
+		workers:          workers,
+	}
+}
+
+// Plan checks a resync of [from, to] without changing anything and returns its summary without changes. Every block
+// from from up to the synced head is re-applied, with maxBlocks above zero a resync of more blocks is refused.
+func (r *Resyncer) Plan(ctx context.Context, from, to, maxBlocks uint64) (ResyncSummary, error) {
+	summary := ResyncSummary{From: from, To: to}
+	if from == 0 || from > to {
+		return summary, fmt.Errorf("invalid block range [%d, %d]", from, to)
+	}
+	head, err := r.syncedHead(ctx)
+	if err != nil {
+		return summary, err
+	}
+	summary.Head = head
+	if to > head {
+		return summary, fmt.Errorf("block %d is above the synced head %d", to, head)
+	}
+	if maxBlocks > 0 && head-from+1 > maxBlocks {
+		return summary, fmt.Errorf("%w: blocks [%d, %d] are %d blocks, the limit is %d", ErrResyncTooLarge, from, head, head-from+1, maxBlocks)
+	}
+	return summary, nil
+}
+
+// Resync rewinds the synced data to the block before from and re-applies the blocks up to the lower check info,
+// the summary compares the rows of the blocks in [from, to] before and after. See Plan for maxBlocks.
+func (r *Resyncer) Resync(ctx context.Context, from, to, maxBlocks uint64) (ResyncSummary, error) {
+	summary, err := r.Plan(ctx, from, to, maxBlocks)
+	if err != nil {
+		return summary, err
+	}
+	head := summary.Head
+
+	token, err := r.fenceUsecase.Acquire(ctx, ResyncFenceName)
+	if err != nil {
+		return summary, err
+	}
+	defer func() {
+		if err := r.fenceUsecase.Release(context.Background(), ResyncFenceName, token); err != nil {
+			r.logger.Errorf(ctx, "release %s fence error: %v", ResyncFenceName, err)
+		}
+	}()
+	ctx = biz.WithFenceToken(ctx, token)
+
+	before, err := r.kvPairUsecase.SnapshotKvPairs(ctx, from, to)
+	if err != nil {
+		return summary, err
+	}
+	ancestorHash, err := r.client.Rpc.GetBlockHash(ctx, from-1)
+	if err != nil {
+		return summary, err
+	}
+	ancestor := biz.CheckInfo{BlockNumber: from - 1, BlockHash: ancestorHash.String()[2:]}
+	if err = r.kvPairUsecase.RewindKvPairs(ctx, ancestor); err != nil {
+		return summary, err
+	}
+	r.logger.Infof(ctx, "resync rewound blocks (%d, %d]", ancestor.BlockNumber, head)
+	parentHash := ancestor.BlockHash
+	for blockNumber := from; blockNumber <= head; blockNumber++ {
+		block, err := r.client.Rpc.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			return summary, err
+		}
+		if block.Header.ParentHash.String()[2:] != parentHash {
+			return summary, fmt.Errorf("block %d does not extend the resynced chain, the live syncer continues from block %d", blockNumber, blockNumber-1)
+		}
+		parsedBlock, err := r.blockSyncer.Parse(ctx, block, r.systemScripts, r.workers)
+		if err != nil {
+			return summary, err
+		}
+		parentHash = block.Header.Hash.String()[2:]
+		if err = r.unifiedSyncer.Save(ctx, parsedBlock, biz.CheckInfo{BlockNumber: blockNumber, BlockHash: parentHash}); err != nil {
+			return summary, err
+		}
+		if (blockNumber-from)%1000 == 999 {
+			r.logger.Infof(ctx, "resync re-applied blocks up to %d of %d", blockNumber, head)
+		}
+	}
+	after, err := r.kvPairUsecase.SnapshotKvPairs(ctx, from, to)
+	if err != nil {
+		return summary, err
+	}
+	summary.Changes = biz.DiffKvPairSnapshots(before, after)
+	return summary, nil
+}
+
+// ReleaseFence removes the fence of a resync that did not finish
+func (r *Resyncer) ReleaseFence(ctx context.Context) error {
+	return r.fenceUsecase.ForceRelease(ctx, ResyncFenceName)
+}
+
+// syncedHead returns the highest block synced for both check types
+func (r *Resyncer) syncedHead(ctx context.Context) (uint64, error) {
+	var head uint64
+	for i, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+		checkInfo := biz.CheckInfo{CheckType: checkType}
+		if err := r.checkInfoUsecase.LastCheckInfo(ctx, &checkInfo); err != nil {
+			return 0, err
+		}
+		if i == 0 || checkInfo.BlockNumber < head {
+			head = checkInfo.BlockNumber
+		}
+	}
+	return head, nil
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// lastCheckInfos returns the last block number of each check type
+type lastCheckInfos struct {
+	biz.CheckInfoRepo
+	blockNumbers map[biz.CheckType]uint64
+}
+
+func (r lastCheckInfos) FindLastCheckInfo(_ context.Context, info *biz.CheckInfo) error {
+	info.BlockNumber = r.blockNumbers[info.CheckType]
+	return nil
+}
+
+func TestResyncer_Plan(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      uint64
+		to        uint64
+		maxBlocks uint64
+		wantHead  uint64
+		wantErr   bool
+		wantLimit bool
+	}{
+		{
+			name:      "should re-apply the blocks up to the lower check info",
+			from:      91,
+			to:        95,
+			maxBlocks: 10,
+			wantHead:  100,
+		},
+		{
+			name:      "should refuse more blocks up to the head than the limit",
+			from:      90,
+			to:        90,
+			maxBlocks: 10,
+			wantHead:  100,
+			wantErr:   true,
+			wantLimit: true,
+		},
+		{
+			name:     "should not limit the blocks without a limit",
+			from:     1,
+			to:       1,
+			wantHead: 100,
+		},
+		{
+			name:     "should refuse a range above the synced head",
+			from:     95,
+			to:       101,
+			wantHead: 100,
+			wantErr:  true,
+		},
+		{
+			name:    "should refuse an empty range",
+			from:    95,
+			to:      94,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(io.Discard, "", 0)
+			checkInfos := lastCheckInfos{blockNumbers: map[biz.CheckType]uint64{biz.SyncBlock: 104, biz.SyncMetadata: 100}}
+			r := NewResyncer(nil, SystemScripts{}, BlockSyncer{}, UnifiedSyncer{}, nil, biz.NewCheckInfoUsecase(checkInfos, log), nil, &config.App{}, log)
+			got, err := r.Plan(context.Background(), tt.from, tt.to, tt.maxBlocks)
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrResyncTooLarge) != tt.wantLimit {
+				t.Fatalf("Plan() error = %v, wantErr %v, want limit %v", err, tt.wantErr, tt.wantLimit)
+			}
+			if got.Head != tt.wantHead {
+				t.Errorf("Plan() head = %d, want %d", got.Head, tt.wantHead)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+const (
+	defaultRpcTimeout       = 30 * time.Second
+	defaultRpcRetries       = 3
+	defaultRpcRetryDelay    = 500 * time.Millisecond
+	defaultRpcMaxRetryDelay = 10 * time.Second
+	defaultBreakerFailures  = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling the node while the circuit breaker is open
+var ErrCircuitOpen = errors.New("rpc circuit breaker is open")
+
+// CircuitBreaker opens after a number of consecutive failed calls and rejects the calls until the cooldown is over.
+// Then a single probe call is let through, which closes the breaker on success and opens it again on failure.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+	logger    *logger.Logger
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, logger *logger.Logger) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, logger: logger}
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *CircuitBreaker) succeeded(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.threshold {
+		b.logger.Info(ctx, "rpc circuit breaker closed")
+		metrics.RpcBreakerOpen.Set(0)
+	}
+	b.failures, b.probing = 0, false
+}
+
+func (b *CircuitBreaker) failed(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures < b.threshold {
+		return
+	}
+	if b.failures == b.threshold || b.probing {
+		b.logger.Errorf(ctx, "rpc circuit breaker opened for %v after %d failed calls", b.cooldown, b.failures)
+		metrics.RpcBreakerTrips.Add(1)
+		metrics.RpcBreakerOpen.Set(1)
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+	b.probing = false
+}
+
+// cancelled ends a call cancelled by its caller, which tells nothing about the node. A cancelled probe lets the next
+// call probe again.
+func (b *CircuitBreaker) cancelled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Remaining returns how long the breaker stays open, the sync loops pause for that long instead of spinning on
+// rejected calls. A nil breaker is never open.
+func (b *CircuitBreaker) Remaining() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return 0
+	}
+	if remaining := time.Until(b.openUntil); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+var _ BlockSource = (*policyBlockSource)(nil)
+
+// policyBlockSource bounds every node call by a timeout and retries the failed calls with an exponential backoff
+// and jitter. The calls are guarded by a circuit breaker so that a node outage pauses the syncer.
+type policyBlockSource struct {
+	source        BlockSource
+	timeout       time.Duration
+	retries       int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	breaker       *CircuitBreaker
+	logger        *logger.Logger
+}
+
+func newPolicyBlockSource(source BlockSource, conf *config.CkbNode, logger *logger.Logger) *policyBlockSource {
+	s := &policyBlockSource{
+		source:        source,
+		timeout:       conf.RpcTimeout,
+		retries:       conf.RpcRetries,
+		retryDelay:    conf.RpcRetryDelay,
+		maxRetryDelay: conf.RpcMaxRetryDelay,
+		logger:        logger,
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultRpcTimeout
+	}
+	if s.retries == 0 {
+		s.retries = defaultRpcRetries
+	} else if s.retries < 0 {
+		s.retries = 0
+	}
+	if s.retryDelay <= 0 {
+		s.retryDelay = defaultRpcRetryDelay
+	}
+	if s.maxRetryDelay <= 0 {
+		s.maxRetryDelay = defaultRpcMaxRetryDelay
+	}
+	threshold, cooldown := conf.BreakerFailures, conf.BreakerCooldown
+	if threshold <= 0 {
+		threshold = defaultBreakerFailures
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	s.breaker = newCircuitBreaker(threshold, cooldown, logger)
+	return s
+}
+
+// backoff returns the delay before the retry following attempt, half of it is random
+func (s *policyBlockSource) backoff(attempt int) time.Duration {
+	delay := s.maxRetryDelay
+	if attempt < 30 && s.retryDelay<<attempt < s.maxRetryDelay {
+		delay = s.retryDelay << attempt
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// do runs call with a timeout, retrying on failure. A missing block or transaction is an answer of the node and the
+// cancellation of ctx ends the call, neither is retried nor counts as a failure.
+func (s *policyBlockSource) do(ctx context.Context, method string, call func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if !s.breaker.allow() {
+			metrics.RpcRejected.Add(method, 1)
+			return ErrCircuitOpen
+		}
+		callCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		err = call(callCtx)
+		timedOut := errors.Is(callCtx.Err(), context.DeadlineExceeded)
+		cancel()
+		if err == nil || errors.Is(err, rpc.NotFound) {
+			s.breaker.succeeded(ctx)
+			return err
+		}
+		if ctx.Err() != nil {
+			s.breaker.cancelled()
+			return err
+		}
+		if timedOut {
+			metrics.RpcTimeouts.Add(method, 1)
+		}
+		metrics.RpcErrors.Add(method, 1)
+		s.breaker.failed(ctx)
+		if attempt >= s.retries {
+			return err
+		}
+		delay := s.backoff(attempt)
+		s.logger.Errorf(ctx, "%s rpc error, retrying in %v: %v", method, delay, err)
+		metrics.RpcRetries.Add(method, 1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *policyBlockSource) GetBlockchainInfo(ctx context.Context) (info *ckbTypes.BlockchainInfo, err error) {
+	err = s.do(ctx, "get_blockchain_info", func(ctx context.Context) error {
+		info, err = s.source.GetBlockchainInfo(ctx)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) GetTipBlockNumber(ctx context.Context) (tip uint64, err error) {
+	err = s.do(ctx, "get_tip_block_number", func(ctx context.Context) error {
+		tip, err = s.source.GetTipBlockNumber(ctx)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) GetBlockByNumber(ctx context.Context, number uint64) (block *ckbTypes.Block, err error) {
+	err = s.do(ctx, "get_block_by_number", func(ctx context.Context) error {
+		block, err = s.source.GetBlockByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) GetBlockHash(ctx context.Context, number uint64) (hash *ckbTypes.Hash, err error) {
+	err = s.do(ctx, "get_block_hash", func(ctx context.Context) error {
+		hash, err = s.source.GetBlockHash(ctx, number)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) GetHeader(ctx context.Context, hash ckbTypes.Hash) (header *ckbTypes.Header, err error) {
+	err = s.do(ctx, "get_header", func(ctx context.Context) error {
+		header, err = s.source.GetHeader(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) GetHeaderByNumber(ctx context.Context, number uint64) (header *ckbTypes.Header, err error) {
+	err = s.do(ctx, "get_header_by_number", func(ctx context.Context) error {
+		header, err = s.source.GetHeaderByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) GetTransaction(ctx context.Context, hash ckbTypes.Hash) (tx *ckbTypes.TransactionWithStatus, err error) {
+	err = s.do(ctx, "get_transaction", func(ctx context.Context) error {
+		tx, err = s.source.GetTransaction(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) BatchTransactions(ctx context.Context, batch []ckbTypes.BatchTransactionItem) error {
+	return s.do(ctx, "batch_get_transaction", func(ctx context.Context) error {
+		return s.source.BatchTransactions(ctx, batch)
+	})
+}
+
+func (s *policyBlockSource) GetTip(ctx context.Context) (tip *indexer.TipHeader, err error) {
+	err = s.do(ctx, "get_indexer_tip", func(ctx context.Context) error {
+		tip, err = s.source.GetTip(ctx)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (txs *indexer.Transactions, err error) {
+	err = s.do(ctx, "get_transactions", func(ctx context.Context) error {
+		txs, err = s.source.GetTransactions(ctx, searchKey, order, limit, afterCursor)
+		return err
+	})
+	return
+}
+
+func (s *policyBlockSource) Close() {
+	s.source.Close()
+}
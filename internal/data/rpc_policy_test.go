@@ -0,0 +1,162 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+)
+
+// flakyNode fails the first calls of the tip with err, the call cancelAt is cancelled by its caller
+type flakyNode struct {
+	rpc.Client
+	failures int
+	err      error
+	calls    int
+	cancelAt int
+	cancel   context.CancelFunc
+}
+
+func (n *flakyNode) GetTipBlockNumber(ctx context.Context) (uint64, error) {
+	n.calls++
+	if n.calls == n.cancelAt {
+		n.cancel()
+		return 0, ctx.Err()
+	}
+	if n.calls <= n.failures {
+		return 0, n.err
+	}
+	return 100, nil
+}
+
+func (n *flakyNode) Close() {}
+
+func Test_policyBlockSource(t *testing.T) {
+	conf := &config.CkbNode{
+		RpcRetries:       2,
+		RpcRetryDelay:    time.Millisecond,
+		RpcMaxRetryDelay: 2 * time.Millisecond,
+		BreakerFailures:  4,
+		BreakerCooldown:  20 * time.Millisecond,
+	}
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		// rounds is the number of calls made through the policy, the last one is checked
+		rounds    int
+		wait      time.Duration
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "should retry a failed call",
+			failures:  2,
+			err:       errNodeDown,
+			rounds:    1,
+			wantCalls: 3,
+		},
+		{
+			name:      "should give up after the retries",
+			failures:  3,
+			err:       errNodeDown,
+			rounds:    1,
+			wantCalls: 3,
+			wantErr:   errNodeDown,
+		},
+		{
+			name:      "should not retry a missing block",
+			failures:  1,
+			err:       rpc.NotFound,
+			rounds:    1,
+			wantCalls: 1,
+			wantErr:   rpc.NotFound,
+		},
+		{
+			name:      "should reject the calls while the breaker is open",
+			failures:  5,
+			err:       errNodeDown,
+			rounds:    2,
+			wantCalls: 4,
+			wantErr:   ErrCircuitOpen,
+		},
+		{
+			name:      "should close the breaker after a successful probe",
+			failures:  4,
+			err:       errNodeDown,
+			rounds:    3,
+			wait:      30 * time.Millisecond,
+			wantCalls: 5,
+		},
+		{
+			name:      "should open the breaker again after a failed probe",
+			failures:  5,
+			err:       errNodeDown,
+			rounds:    3,
+			wait:      30 * time.Millisecond,
+			wantCalls: 5,
+			wantErr:   ErrCircuitOpen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &flakyNode{failures: tt.failures, err: tt.err}
+			source := newPolicyBlockSource(node, conf, logger.NewLogger(io.Discard, "", 0))
+			var err error
+			for round := 0; round < tt.rounds; round++ {
+				if round > 0 {
+					time.Sleep(tt.wait)
+				}
+				_, err = source.GetTipBlockNumber(context.Background())
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetTipBlockNumber() error = %v, want %v", err, tt.wantErr)
+			}
+			if node.calls != tt.wantCalls {
+				t.Errorf("node called %d times, want %d", node.calls, tt.wantCalls)
+			}
+			if open := source.breaker.Remaining() > 0; open != errors.Is(tt.wantErr, ErrCircuitOpen) {
+				t.Errorf("breaker open = %v", open)
+			}
+		})
+	}
+}
+
+func Test_policyBlockSource_cancelledProbe(t *testing.T) {
+	conf := &config.CkbNode{
+		RpcRetries:       2,
+		RpcRetryDelay:    time.Millisecond,
+		RpcMaxRetryDelay: 2 * time.Millisecond,
+		BreakerFailures:  4,
+		BreakerCooldown:  20 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// the calls 1 to 4 fail and open the breaker, the caller cancels the probe, call 5
+	node := &flakyNode{failures: 5, err: errNodeDown, cancelAt: 5, cancel: cancel}
+	source := newPolicyBlockSource(node, conf, logger.NewLogger(io.Discard, "", 0))
+	for round := 0; round < 2; round++ {
+		source.GetTipBlockNumber(context.Background())
+	}
+	if source.breaker.Remaining() == 0 {
+		t.Fatalf("breaker closed after %d failed calls", node.calls)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := source.GetTipBlockNumber(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetTipBlockNumber() error = %v, want %v", err, context.Canceled)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := source.GetTipBlockNumber(context.Background()); err != nil {
+		t.Fatalf("GetTipBlockNumber() error = %v after the cancelled probe", err)
+	}
+	if node.calls != 6 {
+		t.Errorf("node called %d times, want 6", node.calls)
+	}
+	if remaining := source.breaker.Remaining(); remaining > 0 {
+		t.Errorf("breaker open for %v after the probe", remaining)
+	}
+}
@@ -0,0 +1,381 @@
+package data
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/blockchain"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/cotaext"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
+	"github.com/nervina-labs/cota-smt-go/smt"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"gorm.io/gorm"
+)
+
+var _ biz.SmtRootRepo = (*smtRootRepo)(nil)
+
+// the smt types leading the leaf keys of the cota smt
+var (
+	defineSmtType   = []byte{0x81, 0x00}
+	holdSmtType     = []byte{0x81, 0x01}
+	withdrawSmtType = []byte{0x81, 0x02}
+	claimSmtType    = []byte{0x81, 0x03}
+)
+
+type smtRootRepo struct {
+	data   *Data
+	logger *logger.Logger
+}
+
+func NewSmtRootRepo(data *Data, logger *logger.Logger) biz.SmtRootRepo {
+	return &smtRootRepo{
+		data:   data,
+		logger: logger,
+	}
+}
+
+func (rp smtRootRepo) CheckSmtRoots(ctx context.Context, lockHashes []string) ([]biz.SmtRootCheck, error) {
+	query := rp.data.db.WithContext(ctx).Where("consumed_block_number = 0")
+	if len(lockHashes) > 0 {
+		query = query.Where("lock_hash IN ?", lockHashes)
+	}
+	var cells []CotaCell
+	if err := query.Order("block_number, id").Find(&cells).Error; err != nil {
+		return nil, err
+	}
+	return checkSmtRoots(ctx, rp.data.db, cells)
+}
+
+// smtPolicy checks the smt roots of the cota cells left live by a block before the block is committed
+type smtPolicy struct {
+	report bool
+	halt   bool
+	logger *logger.Logger
+}
+
+func newSmtPolicy(appConf *config.App, logger *logger.Logger) smtPolicy {
+	return smtPolicy{
+		report: appConf.SmtVerification == config.ReportSmtVerification || appConf.SmtVerification == config.HaltSmtVerification,
+		halt:   appConf.SmtVerification == config.HaltSmtVerification,
+		logger: logger,
+	}
+}
+
+// verify checks the roots inside the commit transaction under the halt verification, a mismatch fails the commit
+func (p smtPolicy) verify(ctx context.Context, tx *gorm.DB, blockNumber uint64) error {
+	if !p.halt {
+		return nil
+	}
+	return p.check(ctx, tx, blockNumber)
+}
+
+// reportCommitted checks the roots of a committed block under the report verification. It runs after the commit so
+// that rebuilding the smts does not hold the locks of the commit transaction, its errors are only logged as the block
+// stays committed.
+func (p smtPolicy) reportCommitted(ctx context.Context, db *gorm.DB, blockNumber uint64) {
+	if !p.report || p.halt {
+		return
+	}
+	if err := p.check(ctx, db, blockNumber); err != nil {
+		p.logger.Errorf(ctx, "check smt roots of block %d error: %v", blockNumber, err)
+	}
+}
+
+// check logs and counts the mismatching roots, under the halt verification the first mismatch is returned
+func (p smtPolicy) check(ctx context.Context, tx *gorm.DB, blockNumber uint64) error {
+	var cells []CotaCell
+	if err := tx.WithContext(ctx).Where("block_number = ? and consumed_block_number = 0", blockNumber).Order("id").Find(&cells).Error; err != nil {
+		return err
+	}
+	checks, err := checkSmtRoots(ctx, tx, cells)
+	if err != nil {
+		return err
+	}
+	for _, check := range checks {
+		if check.Matches() {
+			metrics.SmtRootChecks.Add("match", 1)
+			continue
+		}
+		metrics.SmtRootChecks.Add("mismatch", 1)
+		p.logger.Errorf(ctx, "smt root mismatch at block %d, lock hash %s: cell root %s, rebuilt root %s from %d leaves",
+			blockNumber, check.LockHash, check.CellRoot, check.Root, check.Leaves)
+		if p.halt {
+			return fmt.Errorf("%w at block %d, lock hash %s", biz.ErrSmtRootMismatch, blockNumber, check.LockHash)
+		}
+	}
+	return nil
+}
+
+func checkSmtRoots(ctx context.Context, tx *gorm.DB, cells []CotaCell) ([]biz.SmtRootCheck, error) {
+	checks := make([]biz.SmtRootCheck, len(cells))
+	for i, cell := range cells {
+		leaves, err := smtLeaves(ctx, tx, cell.LockHash)
+		if err != nil {
+			return nil, err
+		}
+		checks[i] = biz.SmtRootCheck{
+			LockHash:    cell.LockHash,
+			BlockNumber: cell.BlockNumber,
+			CellRoot:    cell.SmtRoot,
+			Leaves:      len(leaves),
+		}
+		root, err := cotaext.SmtRoot(leaves)
+		if err != nil {
+			return nil, err
+		}
+		checks[i].Root = hex.EncodeToString(root[:])
+	}
+	return checks, nil
+}
+
+// smtLeaves rebuilds the leaves of the smt of the lock from its define, hold, withdraw and claimed pairs
+func smtLeaves(ctx context.Context, tx *gorm.DB, lockHash string) ([]cotaext.SmtLeaf, error) {
+	var leaves []cotaext.SmtLeaf
+	var defines []DefineCotaNftKvPair
+	if err := tx.WithContext(ctx).Where("lock_hash = ?", lockHash).Find(&defines).Error; err != nil {
+		return nil, err
+	}
+	for _, define := range defines {
+		leaf, err := defineLeaf(define)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	var holds []HoldCotaNftKvPair
+	if err := tx.WithContext(ctx).Where("lock_hash = ?", lockHash).Find(&holds).Error; err != nil {
+		return nil, err
+	}
+	for _, hold := range holds {
+		leaf, err := holdLeaf(hold)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	var withdrawals []WithdrawCotaNftKvPair
+	if err := tx.WithContext(ctx).Where("lock_hash = ?", lockHash).Find(&withdrawals).Error; err != nil {
+		return nil, err
+	}
+	receiverLocks, err := receiverLockScripts(ctx, tx, withdrawals)
+	if err != nil {
+		return nil, err
+	}
+	for _, withdrawal := range withdrawals {
+		receiverLock, ok := receiverLocks[withdrawal.ReceiverLockScriptId]
+		if !ok {
+			return nil, fmt.Errorf("receiver lock script %d of the withdrawal of %s/%d not found", withdrawal.ReceiverLockScriptId,
+				withdrawal.CotaId, withdrawal.TokenIndex)
+		}
+		leaf, err := withdrawLeaf(withdrawal, receiverLock)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	var claims []ClaimedCotaNftKvPair
+	if err := tx.WithContext(ctx).Where("lock_hash = ?", lockHash).Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	for _, claim := range claims {
+		leaf, err := claimedLeaf(claim)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, nil
+}
+
+// receiverLockScripts loads the receiver lock scripts of the withdrawals in one query
+func receiverLockScripts(ctx context.Context, tx *gorm.DB, withdrawals []WithdrawCotaNftKvPair) (map[uint]Script, error) {
+	if len(withdrawals) == 0 {
+		return nil, nil
+	}
+	seen := make(map[uint]bool, len(withdrawals))
+	var ids []uint
+	for _, withdrawal := range withdrawals {
+		if !seen[withdrawal.ReceiverLockScriptId] {
+			seen[withdrawal.ReceiverLockScriptId] = true
+			ids = append(ids, withdrawal.ReceiverLockScriptId)
+		}
+	}
+	var scripts []Script
+	if err := tx.WithContext(ctx).Where("id IN ?", ids).Find(&scripts).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]Script, len(scripts))
+	for _, script := range scripts {
+		result[script.ID] = script
+	}
+	return result, nil
+}
+
+// defineLeaf is keyed by the smt type and the cota id, the value holds total, issued and configure
+func defineLeaf(define DefineCotaNftKvPair) (leaf cotaext.SmtLeaf, err error) {
+	cotaId, err := hex.DecodeString(define.CotaId)
+	if err != nil || len(cotaId) != 20 {
+		err = fmt.Errorf("invalid cota id %q", define.CotaId)
+		return
+	}
+	key := smt.NewDefineCotaNFTIdBuilder().
+		SmtType(*smt.Uint16FromSliceUnchecked(defineSmtType)).
+		CotaId(*smt.CotaIdFromSliceUnchecked(cotaId)).
+		Build()
+	value := smt.NewDefineCotaNFTValueBuilder().
+		Total(*smt.Uint32FromSliceUnchecked(bigEndianUint32(define.Total))).
+		Issued(*smt.Uint32FromSliceUnchecked(bigEndianUint32(define.Issued))).
+		Configure(smt.NewByte(define.Configure)).
+		Build()
+	copy(leaf.Key[:], key.AsSlice())
+	leaf.Value = paddedValue(value.AsSlice())
+	return
+}
+
+// holdLeaf is keyed by the nft id, the value holds the nft info
+func holdLeaf(hold HoldCotaNftKvPair) (leaf cotaext.SmtLeaf, err error) {
+	nftId, err := cotaNFTId(holdSmtType, hold.CotaId, hold.TokenIndex)
+	if err != nil {
+		return
+	}
+	nftInfo, err := cotaNFTInfo(hold.Configure, hold.State, hold.Characteristic)
+	if err != nil {
+		return
+	}
+	copy(leaf.Key[:], nftId.AsSlice())
+	leaf.Value = paddedValue(nftInfo.AsSlice())
+	return
+}
+
+// withdrawLeaf is keyed by the nft id before version 1 and by the hash of the nft id and the out point since, the
+// value is the hash of the nft info and the receiver lock, before version 1 with the out point
+func withdrawLeaf(withdrawal WithdrawCotaNftKvPair, receiverLock Script) (leaf cotaext.SmtLeaf, err error) {
+	nftId, err := cotaNFTId(withdrawSmtType, withdrawal.CotaId, withdrawal.TokenIndex)
+	if err != nil {
+		return
+	}
+	nftInfo, err := cotaNFTInfo(withdrawal.Configure, withdrawal.State, withdrawal.Characteristic)
+	if err != nil {
+		return
+	}
+	outPoint, err := hex.DecodeString(withdrawal.OutPoint)
+	if err != nil {
+		return
+	}
+	toLock, err := scriptBytes(receiverLock)
+	if err != nil {
+		return
+	}
+	var key, value []byte
+	if withdrawal.Version == 0 {
+		valueV0 := smt.NewWithdrawalCotaNFTValueBuilder().
+			NftInfo(nftInfo).
+			ToLock(toLock).
+			OutPoint(*smt.OutPointSliceFromSliceUnchecked(outPoint)).
+			Build()
+		key, value = nftId.AsSlice(), valueV0.AsSlice()
+	} else {
+		keyV1 := smt.NewWithdrawalCotaNFTKeyV1Builder().
+			NftId(nftId).
+			OutPoint(*smt.OutPointSliceFromSliceUnchecked(outPoint)).
+			Build()
+		if key, err = blake2b.Blake256(keyV1.AsSlice()); err != nil {
+			return
+		}
+		valueV1 := smt.NewWithdrawalCotaNFTValueV1Builder().
+			NftInfo(nftInfo).
+			ToLock(toLock).
+			Build()
+		value = valueV1.AsSlice()
+	}
+	copy(leaf.Key[:], key)
+	hash, err := blake2b.Blake256(value)
+	copy(leaf.Value[:], hash)
+	return
+}
+
+// claimedLeaf is keyed by the hash of the nft id and the out point of the withdrawal, the value is all ones
+func claimedLeaf(claim ClaimedCotaNftKvPair) (leaf cotaext.SmtLeaf, err error) {
+	nftId, err := cotaNFTId(claimSmtType, claim.CotaId, claim.TokenIndex)
+	if err != nil {
+		return
+	}
+	outPoint, err := hex.DecodeString(claim.OutPoint)
+	if err != nil {
+		return
+	}
+	key := smt.NewClaimCotaNFTKeyBuilder().
+		NftId(nftId).
+		OutPoint(*smt.OutPointSliceFromSliceUnchecked(outPoint)).
+		Build()
+	hash, err := blake2b.Blake256(key.AsSlice())
+	if err != nil {
+		return
+	}
+	copy(leaf.Key[:], hash)
+	for i := range leaf.Value {
+		leaf.Value[i] = 0xFF
+	}
+	return
+}
+
+func cotaNFTId(smtType []byte, cotaIdHex string, tokenIndex uint32) (smt.CotaNFTId, error) {
+	cotaId, err := hex.DecodeString(cotaIdHex)
+	if err != nil || len(cotaId) != 20 {
+		return smt.CotaNFTId{}, fmt.Errorf("invalid cota id %q", cotaIdHex)
+	}
+	return smt.NewCotaNFTIdBuilder().
+		SmtType(*smt.Uint16FromSliceUnchecked(smtType)).
+		CotaId(*smt.CotaIdFromSliceUnchecked(cotaId)).
+		Index(*smt.Uint32FromSliceUnchecked(bigEndianUint32(tokenIndex))).
+		Build(), nil
+}
+
+func cotaNFTInfo(configure, state uint8, characteristicHex string) (smt.CotaNFTInfo, error) {
+	characteristic, err := hex.DecodeString(characteristicHex)
+	if err != nil || len(characteristic) != 20 {
+		return smt.CotaNFTInfo{}, fmt.Errorf("invalid characteristic %q", characteristicHex)
+	}
+	return smt.NewCotaNFTInfoBuilder().
+		Configure(smt.NewByte(configure)).
+		State(smt.NewByte(state)).
+		Characteristic(*smt.CharacteristicFromSliceUnchecked(characteristic)).
+		Build(), nil
+}
+
+// scriptBytes returns the molecule encoded script as the Bytes of a withdrawal value
+func scriptBytes(script Script) (smt.Bytes, error) {
+	codeHash, err := hex.DecodeString(script.CodeHash)
+	if err != nil || len(codeHash) != 32 {
+		return smt.Bytes{}, fmt.Errorf("invalid code hash %q", script.CodeHash)
+	}
+	args, err := hex.DecodeString(script.Args)
+	if err != nil {
+		return smt.Bytes{}, err
+	}
+	encoded := blockchain.NewScriptBuilder().
+		CodeHash(*blockchain.Byte32FromSliceUnchecked(codeHash)).
+		HashType(blockchain.NewByte(byte(script.HashType))).
+		Args(encodeBytes(args)).
+		Build()
+	toLock := encodeBytes(encoded.AsSlice())
+	return *smt.BytesFromSliceUnchecked(toLock.AsSlice()), nil
+}
+
+func bigEndianUint32(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
+
+// paddedValue places a leaf value shorter than 32 bytes at the start and sets the last byte, so that no value is zero
+func paddedValue(b []byte) (value [32]byte) {
+	copy(value[:], b)
+	value[31] = 0xFF
+	return
+}
@@ -0,0 +1,282 @@
+package data
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data/cotaext"
+)
+
+func TestSmtRoot(t *testing.T) {
+	leaf := func(key, value byte) cotaext.SmtLeaf {
+		var l cotaext.SmtLeaf
+		l.Key[0], l.Key[31] = key, key
+		l.Value[31] = value
+		return l
+	}
+	root := func(leaves ...cotaext.SmtLeaf) [32]byte {
+		r, err := cotaext.SmtRoot(leaves)
+		if err != nil {
+			t.Fatalf("SmtRoot() error = %v", err)
+		}
+		return r
+	}
+	tests := []struct {
+		name      string
+		leaves    []cotaext.SmtLeaf
+		want      [32]byte
+		wantEqual bool
+	}{
+		{
+			name:      "empty tree has the zero root",
+			leaves:    nil,
+			want:      [32]byte{},
+			wantEqual: true,
+		},
+		{
+			name:      "zero value equals an absent leaf",
+			leaves:    []cotaext.SmtLeaf{leaf(1, 1), leaf(2, 0)},
+			want:      root(leaf(1, 1)),
+			wantEqual: true,
+		},
+		{
+			name:      "leaf order does not matter",
+			leaves:    []cotaext.SmtLeaf{leaf(3, 3), leaf(1, 1), leaf(2, 2)},
+			want:      root(leaf(1, 1), leaf(2, 2), leaf(3, 3)),
+			wantEqual: true,
+		},
+		{
+			name:      "later duplicate key wins",
+			leaves:    []cotaext.SmtLeaf{leaf(1, 1), leaf(2, 2), leaf(1, 5)},
+			want:      root(leaf(2, 2), leaf(1, 5)),
+			wantEqual: true,
+		},
+		{
+			name:      "different values give different roots",
+			leaves:    []cotaext.SmtLeaf{leaf(1, 1), leaf(2, 2)},
+			want:      root(leaf(1, 1), leaf(2, 3)),
+			wantEqual: false,
+		},
+		{
+			name:      "single leaf is not the zero root",
+			leaves:    []cotaext.SmtLeaf{leaf(1, 1)},
+			want:      [32]byte{},
+			wantEqual: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cotaext.SmtRoot(tt.leaves)
+			if err != nil {
+				t.Fatalf("SmtRoot() error = %v", err)
+			}
+			if (got == tt.want) != tt.wantEqual {
+				t.Errorf("SmtRoot() = %x, want equal %v to %x", got, tt.wantEqual, tt.want)
+			}
+		})
+	}
+}
+
+// smtRootFixture holds the indexed pairs of a lock and the smt root of its live cota cell, taken from a chain
+type smtRootFixture struct {
+	LockHash string `json:"lock_hash"`
+	SmtRoot  string `json:"smt_root"`
+	Defines  []struct {
+		CotaId    string `json:"cota_id"`
+		Total     uint32 `json:"total"`
+		Issued    uint32 `json:"issued"`
+		Configure uint8  `json:"configure"`
+	} `json:"defines"`
+	Holds []struct {
+		CotaId         string `json:"cota_id"`
+		TokenIndex     uint32 `json:"token_index"`
+		State          uint8  `json:"state"`
+		Configure      uint8  `json:"configure"`
+		Characteristic string `json:"characteristic"`
+	} `json:"holds"`
+	Withdrawals []struct {
+		CotaId         string `json:"cota_id"`
+		TokenIndex     uint32 `json:"token_index"`
+		OutPoint       string `json:"out_point"`
+		State          uint8  `json:"state"`
+		Configure      uint8  `json:"configure"`
+		Characteristic string `json:"characteristic"`
+		Version        uint8  `json:"version"`
+		ReceiverLock   struct {
+			CodeHash string `json:"code_hash"`
+			HashType int64  `json:"hash_type"`
+			Args     string `json:"args"`
+		} `json:"receiver_lock"`
+	} `json:"withdrawals"`
+	Claims []struct {
+		CotaId     string `json:"cota_id"`
+		TokenIndex uint32 `json:"token_index"`
+		OutPoint   string `json:"out_point"`
+	} `json:"claims"`
+}
+
+func (f smtRootFixture) leaves() ([]cotaext.SmtLeaf, error) {
+	var leaves []cotaext.SmtLeaf
+	add := func(leaf cotaext.SmtLeaf, err error) error {
+		leaves = append(leaves, leaf)
+		return err
+	}
+	for _, d := range f.Defines {
+		if err := add(defineLeaf(DefineCotaNftKvPair{CotaId: d.CotaId, Total: d.Total, Issued: d.Issued, Configure: d.Configure})); err != nil {
+			return nil, err
+		}
+	}
+	for _, h := range f.Holds {
+		if err := add(holdLeaf(HoldCotaNftKvPair{CotaId: h.CotaId, TokenIndex: h.TokenIndex, State: h.State,
+			Configure: h.Configure, Characteristic: h.Characteristic})); err != nil {
+			return nil, err
+		}
+	}
+	for _, w := range f.Withdrawals {
+		withdrawal := WithdrawCotaNftKvPair{CotaId: w.CotaId, TokenIndex: w.TokenIndex, OutPoint: w.OutPoint, State: w.State,
+			Configure: w.Configure, Characteristic: w.Characteristic, Version: w.Version}
+		receiverLock := Script{CodeHash: w.ReceiverLock.CodeHash, HashType: w.ReceiverLock.HashType, Args: w.ReceiverLock.Args}
+		if err := add(withdrawLeaf(withdrawal, receiverLock)); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range f.Claims {
+		if err := add(claimedLeaf(ClaimedCotaNftKvPair{CotaId: c.CotaId, TokenIndex: c.TokenIndex, OutPoint: c.OutPoint})); err != nil {
+			return nil, err
+		}
+	}
+	return leaves, nil
+}
+
+// TestSmtRoot_golden rebuilds the roots of the locks in testdata/smt_roots, each file is a smtRootFixture exported
+// from a database synced from the cota deployment together with the smt root of the live cota cell of the lock
+func TestSmtRoot_golden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "smt_roots", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("no golden smt roots in testdata/smt_roots, smt_verification halt stays rejected")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fixture smtRootFixture
+			if err := json.Unmarshal(raw, &fixture); err != nil {
+				t.Fatalf("decode %s error = %v", path, err)
+			}
+			leaves, err := fixture.leaves()
+			if err != nil {
+				t.Fatalf("leaves() error = %v", err)
+			}
+			root, err := cotaext.SmtRoot(leaves)
+			if err != nil {
+				t.Fatalf("SmtRoot() error = %v", err)
+			}
+			if got := hex.EncodeToString(root[:]); got != fixture.SmtRoot {
+				t.Errorf("root of lock %s = %s, want %s", fixture.LockHash, got, fixture.SmtRoot)
+			}
+		})
+	}
+}
+
+func Test_checkSmtRoots(t *testing.T) {
+	data := newTestData(t)
+	ctx := context.Background()
+	lockHash := fmt.Sprintf("%064d", 1)
+	cotaId := fmt.Sprintf("%040d", 2)
+	characteristic := fmt.Sprintf("%040d", 3)
+	receivers := []Script{
+		{CodeHash: fmt.Sprintf("%064d", 4), HashType: 1, Args: "aa"},
+		{CodeHash: fmt.Sprintf("%064d", 5), HashType: 0, Args: "bb"},
+	}
+	if err := data.db.Create(&receivers).Error; err != nil {
+		t.Fatal(err)
+	}
+	define := DefineCotaNftKvPair{BlockNumber: 1, CotaId: cotaId, Total: 10, Issued: 4, LockHash: lockHash}
+	hold := HoldCotaNftKvPair{BlockNumber: 1, CotaId: cotaId, TokenIndex: 0, Characteristic: characteristic, LockHash: lockHash}
+	withdrawals := []WithdrawCotaNftKvPair{
+		{BlockNumber: 1, CotaId: cotaId, TokenIndex: 1, OutPoint: fmt.Sprintf("%048d", 6), Characteristic: characteristic,
+			ReceiverLockScriptId: receivers[0].ID, LockHash: lockHash, Version: 0},
+		{BlockNumber: 1, CotaId: cotaId, TokenIndex: 2, OutPoint: fmt.Sprintf("%048d", 7), Characteristic: characteristic,
+			ReceiverLockScriptId: receivers[1].ID, LockHash: lockHash, Version: 1},
+		{BlockNumber: 1, CotaId: cotaId, TokenIndex: 3, OutPoint: fmt.Sprintf("%048d", 8), Characteristic: characteristic,
+			ReceiverLockScriptId: receivers[0].ID, LockHash: lockHash, Version: 1},
+	}
+	claim := ClaimedCotaNftKvPair{BlockNumber: 1, CotaId: cotaId, TokenIndex: 9, OutPoint: fmt.Sprintf("%048d", 9), LockHash: lockHash}
+	for _, row := range []any{&define, &hold, &withdrawals, &claim} {
+		if err := data.db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	var want []cotaext.SmtLeaf
+	appendLeaf := func(leaf cotaext.SmtLeaf, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, leaf)
+	}
+	appendLeaf(defineLeaf(define))
+	appendLeaf(holdLeaf(hold))
+	appendLeaf(withdrawLeaf(withdrawals[0], receivers[0]))
+	appendLeaf(withdrawLeaf(withdrawals[1], receivers[1]))
+	appendLeaf(withdrawLeaf(withdrawals[2], receivers[0]))
+	appendLeaf(claimedLeaf(claim))
+	wantRoot, err := cotaext.SmtRoot(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		cellRoot    string
+		deleteLock  bool
+		wantMatches bool
+		wantErr     bool
+	}{
+		{
+			name:        "should match the root of the define, hold, withdraw and claim leaves",
+			cellRoot:    hex.EncodeToString(wantRoot[:]),
+			wantMatches: true,
+		},
+		{
+			name:     "should report another cell root as a mismatch",
+			cellRoot: fmt.Sprintf("%064d", 0),
+		},
+		{
+			name:       "should fail when a receiver lock script is missing",
+			cellRoot:   hex.EncodeToString(wantRoot[:]),
+			deleteLock: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.deleteLock {
+				if err := data.db.Delete(&receivers[1]).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			checks, err := checkSmtRoots(ctx, data.db, []CotaCell{{LockHash: lockHash, SmtRoot: tt.cellRoot, BlockNumber: 1}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkSmtRoots() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(checks) != 1 || checks[0].Leaves != len(want) {
+				t.Fatalf("checkSmtRoots() = %+v, want one verified check of %d leaves", checks, len(want))
+			}
+			if checks[0].Matches() != tt.wantMatches {
+				t.Errorf("Matches() = %v, want %v, root %s", checks[0].Matches(), tt.wantMatches, checks[0].Root)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package data
+
+import (
+	"context"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// SparseScanner lets the sync services jump over the blocks without cota transactions. It asks the indexer for the
+// next block with a transaction touching the given scripts and advances the check infos to the block before it.
+// Only blocks deeper than the max reorg depth are skipped, the recent blocks are still synced one by one so that
+// the stored headers near the tip stay dense for fork detection.
+type SparseScanner struct {
+	client        *CkbNodeClient
+	kvPairUsecase *biz.SyncKvPairUsecase
+	logger        *logger.Logger
+	safeDepth     uint64
+	enabled       bool
+}
+
+func NewSparseScanner(client *CkbNodeClient, kvPairUsecase *biz.SyncKvPairUsecase, reorganizer *ChainReorganizer, appConf *config.App, logger *logger.Logger) *SparseScanner {
+	enabled := appConf.SparseSync
+	if enabled && !client.HasIndexer {
+		logger.Errorf(context.TODO(), "sparse sync needs ckb_node.indexer_url, every block will be synced")
+		enabled = false
+	}
+	return &SparseScanner{
+		client:        client,
+		kvPairUsecase: kvPairUsecase,
+		logger:        logger,
+		safeDepth:     reorganizer.MaxDepth(),
+		enabled:       enabled,
+	}
+}
+
+// Skip advances checkInfo over the leading blocks without transactions touching the scripts and returns the check info
+// to continue from together with the highest block the caller should sync block by block in this round.
+func (s *SparseScanner) Skip(ctx context.Context, checkInfo biz.CheckInfo, tipBlockNumber uint64, scripts []SystemScript, checkTypes ...biz.CheckType) (biz.CheckInfo, uint64, error) {
+	if !s.enabled || checkInfo.BlockNumber+s.safeDepth >= tipBlockNumber {
+		return checkInfo, tipBlockNumber, nil
+	}
+	endBlockNumber := tipBlockNumber - s.safeDepth
+	indexerTip, err := s.client.Rpc.GetTip(ctx)
+	metrics.RpcCalls.Add("get_indexer_tip", 1)
+	if err != nil {
+		return checkInfo, tipBlockNumber, err
+	}
+	if indexerTip.BlockNumber < endBlockNumber {
+		endBlockNumber = indexerTip.BlockNumber
+	}
+	if checkInfo.BlockNumber >= endBlockNumber {
+		return checkInfo, tipBlockNumber, nil
+	}
+	nextBlockNumber, err := s.nextActiveBlock(ctx, checkInfo.BlockNumber+1, endBlockNumber, scripts)
+	if err != nil {
+		return checkInfo, tipBlockNumber, err
+	}
+	if nextBlockNumber == checkInfo.BlockNumber+1 {
+		return checkInfo, nextBlockNumber, nil
+	}
+	// the skipped range only extends a canonical check info, after a fork the block by block sync rolls back first
+	if checkInfo.BlockHash != "" {
+		blockHash, err := s.client.Rpc.GetBlockHash(ctx, checkInfo.BlockNumber)
+		if err != nil {
+			return checkInfo, tipBlockNumber, err
+		}
+		if blockHash.String()[2:] != checkInfo.BlockHash {
+			return checkInfo, checkInfo.BlockNumber + 1, nil
+		}
+	}
+	header, err := s.client.Rpc.GetHeaderByNumber(ctx, nextBlockNumber-1)
+	if err != nil {
+		return checkInfo, tipBlockNumber, err
+	}
+	skipped := biz.CheckInfo{
+		BlockNumber: nextBlockNumber - 1,
+		BlockHash:   header.Hash.String()[2:],
+		CheckType:   checkInfo.CheckType,
+	}
+	if err = s.kvPairUsecase.SkipBlocks(ctx, checkInfo, skipped, checkTypes...); err != nil {
+		return checkInfo, tipBlockNumber, err
+	}
+	s.logger.Infof(ctx, "skip blocks (%d, %d] without cota transactions", checkInfo.BlockNumber, skipped.BlockNumber)
+	if nextBlockNumber > endBlockNumber {
+		return skipped, tipBlockNumber, nil
+	}
+	return skipped, nextBlockNumber, nil
+}
+
+// nextActiveBlock returns the lowest block in [from, to] with a transaction touching one of the scripts, or to + 1
+func (s *SparseScanner) nextActiveBlock(ctx context.Context, from, to uint64, scripts []SystemScript) (uint64, error) {
+	next := to + 1
+	for _, script := range scripts {
+		searchKey := &indexer.SearchKey{
+			Script: &ckbTypes.Script{
+				CodeHash: script.CodeHash,
+				HashType: script.HashType,
+				Args:     script.Args,
+			},
+			ScriptType: indexer.ScriptTypeType,
+			Filter: &indexer.CellsFilter{
+				BlockRange: &[2]uint64{from, next},
+			},
+		}
+		txs, err := s.client.Rpc.GetTransactions(ctx, searchKey, indexer.SearchOrderAsc, 1, "")
+		metrics.RpcCalls.Add("get_transactions", 1)
+		if err != nil {
+			return 0, err
+		}
+		if len(txs.Objects) > 0 && txs.Objects[0].BlockNumber < next {
+			next = txs.Objects[0].BlockNumber
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,144 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func blockHash(blockNumber uint64) ckbTypes.Hash {
+	return ckbTypes.HexToHash(fmt.Sprintf("0x%064x", blockNumber))
+}
+
+// sparseNode answers the indexer and header calls from the blocks holding cota transactions per script code hash
+type sparseNode struct {
+	rpc.Client
+	indexerTip   uint64
+	activeBlocks map[ckbTypes.Hash][]uint64
+}
+
+func (n sparseNode) GetTip(context.Context) (*indexer.TipHeader, error) {
+	return &indexer.TipHeader{BlockNumber: n.indexerTip, BlockHash: blockHash(n.indexerTip)}, nil
+}
+
+func (n sparseNode) GetTransactions(_ context.Context, searchKey *indexer.SearchKey, _ indexer.SearchOrder, _ uint64, _ string) (*indexer.Transactions, error) {
+	txs := &indexer.Transactions{}
+	blockRange := searchKey.Filter.BlockRange
+	for _, blockNumber := range n.activeBlocks[searchKey.Script.CodeHash] {
+		if blockNumber >= blockRange[0] && blockNumber < blockRange[1] {
+			txs.Objects = append(txs.Objects, &indexer.Transaction{BlockNumber: blockNumber})
+			break
+		}
+	}
+	return txs, nil
+}
+
+func (n sparseNode) GetBlockHash(_ context.Context, blockNumber uint64) (*ckbTypes.Hash, error) {
+	hash := blockHash(blockNumber)
+	return &hash, nil
+}
+
+func (n sparseNode) GetHeaderByNumber(_ context.Context, blockNumber uint64) (*ckbTypes.Header, error) {
+	return &ckbTypes.Header{Number: blockNumber, Hash: blockHash(blockNumber)}, nil
+}
+
+type skippedBlocks struct {
+	biz.KvPairRepo
+	skipped []biz.CheckInfo
+}
+
+func (r *skippedBlocks) SkipBlocks(_ context.Context, _ biz.CheckInfo, checkInfo biz.CheckInfo, _ ...biz.CheckType) error {
+	r.skipped = append(r.skipped, checkInfo)
+	return nil
+}
+
+func TestSparseScanner_Skip(t *testing.T) {
+	cotaType := SystemScript{CodeHash: ckbTypes.HexToHash("0x01")}
+	registryType := SystemScript{CodeHash: ckbTypes.HexToHash("0x02")}
+	activeBlocks := map[ckbTypes.Hash][]uint64{
+		cotaType.CodeHash:     {500, 900},
+		registryType.CodeHash: {300},
+	}
+	tests := []struct {
+		name           string
+		blockNumber    uint64
+		tipBlockNumber uint64
+		indexerTip     uint64
+		want           uint64
+		wantCommit     uint64
+	}{
+		{
+			name:           "should skip to the block before the first active block of any script",
+			blockNumber:    100,
+			tipBlockNumber: 2000,
+			indexerTip:     2000,
+			want:           299,
+			wantCommit:     300,
+		},
+		{
+			name:           "should not skip when the next block is active",
+			blockNumber:    299,
+			tipBlockNumber: 2000,
+			indexerTip:     2000,
+			want:           299,
+			wantCommit:     300,
+		},
+		{
+			name:           "should skip up to the reorg depth below the tip when no block is active",
+			blockNumber:    900,
+			tipBlockNumber: 2000,
+			indexerTip:     2000,
+			want:           1900,
+			wantCommit:     2000,
+		},
+		{
+			name:           "should not skip past the indexer tip",
+			blockNumber:    900,
+			tipBlockNumber: 2000,
+			indexerTip:     1500,
+			want:           1500,
+			wantCommit:     2000,
+		},
+		{
+			name:           "should not skip near the tip",
+			blockNumber:    1950,
+			tipBlockNumber: 2000,
+			indexerTip:     2000,
+			want:           1950,
+			wantCommit:     2000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &skippedBlocks{}
+			s := &SparseScanner{
+				client:        &CkbNodeClient{Rpc: sparseNode{indexerTip: tt.indexerTip, activeBlocks: activeBlocks}, HasIndexer: true},
+				kvPairUsecase: biz.NewSyncKvPairUsecase(repo, logger.NewLogger(io.Discard, "", 0)),
+				logger:        logger.NewLogger(io.Discard, "", 0),
+				safeDepth:     defaultMaxReorgDepth,
+				enabled:       true,
+			}
+			checkInfo := biz.CheckInfo{BlockNumber: tt.blockNumber, BlockHash: blockHash(tt.blockNumber).String()[2:], CheckType: biz.SyncBlock}
+			got, gotCommit, err := s.Skip(context.Background(), checkInfo, tt.tipBlockNumber, []SystemScript{cotaType, registryType}, biz.SyncBlock)
+			if err != nil {
+				t.Fatalf("Skip() error = %v", err)
+			}
+			if got.BlockNumber != tt.want || got.BlockHash != blockHash(tt.want).String()[2:] {
+				t.Errorf("Skip() got check info %d %s, want %d", got.BlockNumber, got.BlockHash, tt.want)
+			}
+			if gotCommit != tt.wantCommit {
+				t.Errorf("Skip() got commit block %d, want %d", gotCommit, tt.wantCommit)
+			}
+			if skipped := tt.want != tt.blockNumber; skipped != (len(repo.skipped) == 1) {
+				t.Errorf("Skip() stored %d skips, want skipped %v", len(repo.skipped), skipped)
+			}
+		})
+	}
+}
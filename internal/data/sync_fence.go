@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ biz.SyncFenceRepo = (*syncFenceRepo)(nil)
+
+type SyncFence struct {
+	ID        uint `gorm:"primaryKey"`
+	Name      string
+	Token     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type syncFenceRepo struct {
+	data   *Data
+	logger *logger.Logger
+}
+
+func NewSyncFenceRepo(data *Data, logger *logger.Logger) biz.SyncFenceRepo {
+	return &syncFenceRepo{
+		data:   data,
+		logger: logger,
+	}
+}
+
+// AcquireSyncFence inserts the fence row. The insert waits for the gap locks taken by checkFence in the running
+// commits, so once it returns every later commit sees the fence.
+func (rp syncFenceRepo) AcquireSyncFence(ctx context.Context, name string) (string, error) {
+	fence := SyncFence{Name: name, Token: uuid.NewString()}
+	result := rp.data.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&fence)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", fmt.Errorf("sync fence %s is already held", name)
+	}
+	return fence.Token, nil
+}
+
+func (rp syncFenceRepo) ReleaseSyncFence(ctx context.Context, name string, token string) error {
+	return rp.data.db.WithContext(ctx).Where("name = ? and token = ?", name, token).Delete(SyncFence{}).Error
+}
+
+func (rp syncFenceRepo) ForceReleaseSyncFence(ctx context.Context, name string) error {
+	return rp.data.db.WithContext(ctx).Where("name = ?", name).Delete(SyncFence{}).Error
+}
+
+// checkFence fails with biz.ErrSyncFenced when a fence is held by someone else than the token carried by ctx.
+// The locking read makes a concurrent AcquireSyncFence wait until the calling transaction ends.
+func checkFence(ctx context.Context, tx *gorm.DB) error {
+	var fences []SyncFence
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "SHARE"}).Find(&fences).Error; err != nil {
+		return err
+	}
+	token := biz.FenceToken(ctx)
+	for _, fence := range fences {
+		if fence.Token != token {
+			return biz.ErrSyncFenced
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package data
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// builtinSystemScripts are the cota deployments on the mainnet and the testnet, they apply unless the chain is configured
+var builtinSystemScripts = map[string]config.ChainScripts{
+	"ckb": {
+		CotaRegistryType: []config.Script{{
+			CodeHash: "0x90ca618be6c15f5857d3cbd09f9f24ca6770af047ba9ee70989ec3b229419ac7",
+			HashType: "type",
+			Args:     "0x563631b49cee549f3585ab4dde5f9d590f507f1f",
+			TxHash:   "0x42a5b04df6ff0e2819ec6b814d33a028ed1593bc9e1cca463f679af555dce106",
+			DepType:  "dep_group",
+		}},
+		CotaType: []config.Script{{
+			CodeHash: "0x1122a4fb54697cf2e6e3a96c9d80fd398a936559b90954c6e88eb7ba0cf652df",
+			HashType: "type",
+			TxHash:   "0x42a5b04df6ff0e2819ec6b814d33a028ed1593bc9e1cca463f679af555dce106",
+			DepType:  "dep_group",
+		}},
+	},
+	"ckb_testnet": {
+		CotaRegistryType: []config.Script{{
+			CodeHash: "0x9302db6cc1344b81a5efee06962abcb40427ecfcbe69d471b01b2658ed948075",
+			HashType: "type",
+			Args:     "0xf9910364e0ca81a0e074f3aa42fe78cfcc880da6",
+			TxHash:   "0x698f2a29021ebd741b4a38b4a5f8fa3686103ba66773e7549b204a67db015ba0",
+			DepType:  "dep_group",
+		}},
+		CotaType: []config.Script{{
+			CodeHash: "0x89cd8003a0eaf8e65e0c31525b7d1d5c1becefd2ea75bb4cff87810ae37764d8",
+			HashType: "type",
+			TxHash:   "0x698f2a29021ebd741b4a38b4a5f8fa3686103ba66773e7549b204a67db015ba0",
+			DepType:  "dep_group",
+		}},
+	},
+}
+
+// SystemScript is a deployment of a script, it is valid from FromBlock to ToBlock inclusive, a zero ToBlock means
+// no end. Version tells the deployments apart and is stored with the entries parsed from cells under the script.
+type SystemScript struct {
+	CodeHash  ckbTypes.Hash
+	HashType  ckbTypes.ScriptHashType
+	Args      []byte
+	OutPoint  ckbTypes.OutPoint
+	DepType   ckbTypes.DepType
+	Version   uint8
+	FromBlock uint64
+	ToBlock   uint64
+}
+
+// matchesCode reports whether script runs the code of s, whatever its args
+func (s SystemScript) matchesCode(script *ckbTypes.Script) bool {
+	return script != nil && script.CodeHash == s.CodeHash && script.HashType == s.HashType
+}
+
+func (s SystemScript) activeAt(blockNumber uint64) bool {
+	return blockNumber >= s.FromBlock && (s.ToBlock == 0 || blockNumber <= s.ToBlock)
+}
+
+// SystemScriptList holds the deployments of a script, e.g. the versions before and after an upgrade
+type SystemScriptList []SystemScript
+
+// At keeps the deployments valid at the block
+func (l SystemScriptList) At(blockNumber uint64) SystemScriptList {
+	var active SystemScriptList
+	for _, s := range l {
+		if s.activeAt(blockNumber) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// find returns the deployment script belongs to, the args are compared only with withArgs
+func (l SystemScriptList) find(script *ckbTypes.Script, withArgs bool) (SystemScript, bool) {
+	for _, s := range l {
+		if s.matchesCode(script) && (!withArgs || argsEq(script.Args, s.Args)) {
+			return s, true
+		}
+	}
+	return SystemScript{}, false
+}
+
+// versionOf returns the version of the deployment running the code of script, zero when none does
+func (l SystemScriptList) versionOf(script *ckbTypes.Script) uint8 {
+	s, _ := l.find(script, false)
+	return s.Version
+}
+
+func (l SystemScriptList) matchesCode(script *ckbTypes.Script) bool {
+	_, ok := l.find(script, false)
+	return ok
+}
+
+func (l SystemScriptList) matches(script *ckbTypes.Script) bool {
+	_, ok := l.find(script, true)
+	return ok
+}
+
+type SystemScripts struct {
+	CotaRegistryType SystemScriptList
+	CotaType         SystemScriptList
+}
+
+// All lists the deployments of the cota and the registry type scripts
+func (s SystemScripts) All() []SystemScript {
+	return append(append([]SystemScript{}, s.CotaType...), s.CotaRegistryType...)
+}
+
+// At keeps the deployments valid at the block, the cells of a block are matched against these only
+func (s SystemScripts) At(blockNumber uint64) SystemScripts {
+	return SystemScripts{CotaRegistryType: s.CotaRegistryType.At(blockNumber), CotaType: s.CotaType.At(blockNumber)}
+}
+
+// Identity is the canonical text of the script set, one `list code_hash hash_type args` line per deployment. The cell
+// deps and the block ranges are left out, they may change without the deployments changing.
+func (s SystemScripts) Identity() string {
+	var lines []string
+	for _, list := range []struct {
+		name    string
+		scripts SystemScriptList
+	}{{"cota_registry_type", s.CotaRegistryType}, {"cota_type", s.CotaType}} {
+		for _, script := range list.scripts {
+			lines = append(lines, fmt.Sprintf("%s %s %s 0x%x", list.name, script.CodeHash, script.HashType, script.Args))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func NewSystemScripts(client *CkbNodeClient, conf *config.CkbNode, logger *logger.Logger) SystemScripts {
+	chainInfo, err := client.Rpc.GetBlockchainInfo(context.Background())
+	if err != nil {
+		logger.Fatalf(context.Background(), "RPC get_blockchain_info error")
+	}
+	scripts, err := newSystemScripts(chainInfo.Chain, conf.SystemScripts)
+	if err != nil {
+		logger.Fatalf(context.Background(), "system scripts of chain %s error: %v", chainInfo.Chain, err)
+	}
+	return scripts
+}
+
+// newSystemScripts takes each script list of the chain from the config, falling back to the built-in deployments
+func newSystemScripts(chain string, configured map[string]config.ChainScripts) (SystemScripts, error) {
+	builtin := builtinSystemScripts[chain]
+	chainScripts := configured[chain]
+	if len(chainScripts.CotaRegistryType) == 0 {
+		chainScripts.CotaRegistryType = builtin.CotaRegistryType
+	}
+	if len(chainScripts.CotaType) == 0 {
+		chainScripts.CotaType = builtin.CotaType
+	}
+	if len(chainScripts.CotaRegistryType) == 0 || len(chainScripts.CotaType) == 0 {
+		return SystemScripts{}, fmt.Errorf("no cota scripts configured for chain %s, add them to ckb_node.system_scripts", chain)
+	}
+	registryType, err := parseSystemScripts(chainScripts.CotaRegistryType)
+	if err != nil {
+		return SystemScripts{}, fmt.Errorf("cota_registry_type: %w", err)
+	}
+	cotaType, err := parseSystemScripts(chainScripts.CotaType)
+	if err != nil {
+		return SystemScripts{}, fmt.Errorf("cota_type: %w", err)
+	}
+	return SystemScripts{CotaRegistryType: registryType, CotaType: cotaType}, nil
+}
+
+func parseSystemScripts(scripts []config.Script) (SystemScriptList, error) {
+	list := make(SystemScriptList, len(scripts))
+	for i, script := range scripts {
+		codeHash, err := parseHash(script.CodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("code_hash %q: %w", script.CodeHash, err)
+		}
+		txHash, err := parseHash(script.TxHash)
+		if err != nil {
+			return nil, fmt.Errorf("tx_hash %q: %w", script.TxHash, err)
+		}
+		var args []byte
+		if script.Args != "" {
+			if args, err = hex.DecodeString(strings.TrimPrefix(script.Args, "0x")); err != nil {
+				return nil, fmt.Errorf("args %q: %w", script.Args, err)
+			}
+		}
+		hashType := ckbTypes.ScriptHashType(script.HashType)
+		if indexOf(scriptHashTypes, hashType) < 0 {
+			return nil, fmt.Errorf("unknown hash_type %q", script.HashType)
+		}
+		depType := ckbTypes.DepType(script.DepType)
+		if indexOf(depTypes, depType) < 0 {
+			return nil, fmt.Errorf("unknown dep_type %q", script.DepType)
+		}
+		if script.ToBlock != 0 && script.ToBlock < script.FromBlock {
+			return nil, fmt.Errorf("to_block %d is below from_block %d", script.ToBlock, script.FromBlock)
+		}
+		list[i] = SystemScript{
+			CodeHash:  codeHash,
+			HashType:  hashType,
+			Args:      args,
+			OutPoint:  ckbTypes.OutPoint{TxHash: txHash, Index: script.Index},
+			DepType:   depType,
+			Version:   script.Version,
+			FromBlock: script.FromBlock,
+			ToBlock:   script.ToBlock,
+		}
+	}
+	return list, nil
+}
+
+func parseHash(s string) (ckbTypes.Hash, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return ckbTypes.Hash{}, err
+	}
+	if len(b) != ckbTypes.HashLength {
+		return ckbTypes.Hash{}, fmt.Errorf("%d bytes, want %d", len(b), ckbTypes.HashLength)
+	}
+	return ckbTypes.BytesToHash(b), nil
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func Test_newSystemScripts(t *testing.T) {
+	devRegistry := config.Script{
+		CodeHash: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		HashType: "type",
+		Args:     "0xabcd",
+		TxHash:   "0x0202020202020202020202020202020202020202020202020202020202020202",
+		Index:    1,
+		DepType:  "code",
+	}
+	devType := config.Script{
+		CodeHash: "0x0303030303030303030303030303030303030303030303030303030303030303",
+		HashType: "data1",
+		TxHash:   "0x0202020202020202020202020202020202020202020202020202020202020202",
+		DepType:  "code",
+	}
+	upgradedType := devType
+	upgradedType.CodeHash = "0x0404040404040404040404040404040404040404040404040404040404040404"
+	tests := []struct {
+		name           string
+		chain          string
+		configured     map[string]config.ChainScripts
+		wantRegistry   []ckbTypes.Hash
+		wantType       []ckbTypes.Hash
+		wantRegistryTx ckbTypes.OutPoint
+		wantErr        bool
+	}{
+		{
+			name:         "should use the built-in testnet scripts",
+			chain:        "ckb_testnet",
+			wantRegistry: []ckbTypes.Hash{ckbTypes.HexToHash("0x9302db6cc1344b81a5efee06962abcb40427ecfcbe69d471b01b2658ed948075")},
+			wantType:     []ckbTypes.Hash{ckbTypes.HexToHash("0x89cd8003a0eaf8e65e0c31525b7d1d5c1becefd2ea75bb4cff87810ae37764d8")},
+			wantRegistryTx: ckbTypes.OutPoint{
+				TxHash: ckbTypes.HexToHash("0x698f2a29021ebd741b4a38b4a5f8fa3686103ba66773e7549b204a67db015ba0"),
+			},
+		},
+		{
+			name:  "should use the configured scripts of a devnet",
+			chain: "ckb_dev",
+			configured: map[string]config.ChainScripts{
+				"ckb_dev": {CotaRegistryType: []config.Script{devRegistry}, CotaType: []config.Script{devType, upgradedType}},
+			},
+			wantRegistry:   []ckbTypes.Hash{ckbTypes.HexToHash(devRegistry.CodeHash)},
+			wantType:       []ckbTypes.Hash{ckbTypes.HexToHash(devType.CodeHash), ckbTypes.HexToHash(upgradedType.CodeHash)},
+			wantRegistryTx: ckbTypes.OutPoint{TxHash: ckbTypes.HexToHash(devRegistry.TxHash), Index: 1},
+		},
+		{
+			name:  "should fill the lists left out with the built-in scripts",
+			chain: "ckb",
+			configured: map[string]config.ChainScripts{
+				"ckb": {CotaType: []config.Script{upgradedType}},
+			},
+			wantRegistry: []ckbTypes.Hash{ckbTypes.HexToHash("0x90ca618be6c15f5857d3cbd09f9f24ca6770af047ba9ee70989ec3b229419ac7")},
+			wantType:     []ckbTypes.Hash{ckbTypes.HexToHash(upgradedType.CodeHash)},
+			wantRegistryTx: ckbTypes.OutPoint{
+				TxHash: ckbTypes.HexToHash("0x42a5b04df6ff0e2819ec6b814d33a028ed1593bc9e1cca463f679af555dce106"),
+			},
+		},
+		{
+			name:    "should refuse a chain without scripts",
+			chain:   "ckb_dev",
+			wantErr: true,
+		},
+		{
+			name:  "should refuse an unknown hash type",
+			chain: "ckb_dev",
+			configured: map[string]config.ChainScripts{
+				"ckb_dev": {CotaRegistryType: []config.Script{devRegistry}, CotaType: []config.Script{{CodeHash: devType.CodeHash, HashType: "data2", TxHash: devType.TxHash, DepType: "code"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "should refuse a short code hash",
+			chain: "ckb_dev",
+			configured: map[string]config.ChainScripts{
+				"ckb_dev": {CotaRegistryType: []config.Script{devRegistry}, CotaType: []config.Script{{CodeHash: "0x0303", HashType: "type", TxHash: devType.TxHash, DepType: "code"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "should refuse a to_block below the from_block",
+			chain: "ckb_dev",
+			configured: map[string]config.ChainScripts{
+				"ckb_dev": {CotaRegistryType: []config.Script{devRegistry}, CotaType: []config.Script{{CodeHash: devType.CodeHash, HashType: "type", TxHash: devType.TxHash, DepType: "code", FromBlock: 100, ToBlock: 99}}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSystemScripts(tt.chain, tt.configured)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSystemScripts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if codeHashes := scriptCodeHashes(got.CotaRegistryType); !reflect.DeepEqual(codeHashes, tt.wantRegistry) {
+				t.Errorf("registry code hashes = %v, want %v", codeHashes, tt.wantRegistry)
+			}
+			if codeHashes := scriptCodeHashes(got.CotaType); !reflect.DeepEqual(codeHashes, tt.wantType) {
+				t.Errorf("type code hashes = %v, want %v", codeHashes, tt.wantType)
+			}
+			if got.CotaRegistryType[0].OutPoint != tt.wantRegistryTx {
+				t.Errorf("registry out point = %v, want %v", got.CotaRegistryType[0].OutPoint, tt.wantRegistryTx)
+			}
+			if len(got.CotaRegistryType[0].Args) == 0 {
+				t.Error("registry args are empty")
+			}
+		})
+	}
+}
+
+func scriptCodeHashes(scripts SystemScriptList) []ckbTypes.Hash {
+	var codeHashes []ckbTypes.Hash
+	for _, script := range scripts {
+		codeHashes = append(codeHashes, script.CodeHash)
+	}
+	return codeHashes
+}
+
+func TestSystemScriptList_At(t *testing.T) {
+	oldType := SystemScript{CodeHash: ckbTypes.HexToHash("0x03"), HashType: ckbTypes.HashTypeType, Version: 0, ToBlock: 99}
+	newType := SystemScript{CodeHash: ckbTypes.HexToHash("0x04"), HashType: ckbTypes.HashTypeType, Version: 1, FromBlock: 100}
+	list := SystemScriptList{oldType, newType}
+	tests := []struct {
+		name        string
+		blockNumber uint64
+		script      *ckbTypes.Script
+		wantMatch   bool
+		wantVersion uint8
+	}{
+		{
+			name:        "should match the old script before the upgrade",
+			blockNumber: 99,
+			script:      &ckbTypes.Script{CodeHash: oldType.CodeHash, HashType: oldType.HashType},
+			wantMatch:   true,
+			wantVersion: 0,
+		},
+		{
+			name:        "should not match the new script before the upgrade",
+			blockNumber: 99,
+			script:      &ckbTypes.Script{CodeHash: newType.CodeHash, HashType: newType.HashType},
+		},
+		{
+			name:        "should match the new script from the upgrade",
+			blockNumber: 100,
+			script:      &ckbTypes.Script{CodeHash: newType.CodeHash, HashType: newType.HashType},
+			wantMatch:   true,
+			wantVersion: 1,
+		},
+		{
+			name:        "should not match the old script after the upgrade",
+			blockNumber: 100,
+			script:      &ckbTypes.Script{CodeHash: oldType.CodeHash, HashType: oldType.HashType},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active := list.At(tt.blockNumber)
+			if got := active.matchesCode(tt.script); got != tt.wantMatch {
+				t.Fatalf("matchesCode() = %v, want %v", got, tt.wantMatch)
+			}
+			if got := active.versionOf(tt.script); got != tt.wantVersion {
+				t.Errorf("versionOf() = %d, want %d", got, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	mMsql "github.com/golang-migrate/migrate/v4/database/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// newTestData connects to the mysql database of TEST_DATABASE_URL, migrates it and empties every table. The tests
+// needing a database are skipped without it.
+func newTestData(t *testing.T) *Data {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatalf("open database error = %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db error = %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	driver, err := mMsql.WithInstance(sqlDB, &mMsql.Config{})
+	if err != nil {
+		t.Fatalf("migration driver error = %v", err)
+	}
+	migration, err := migrate.NewWithDatabaseInstance("file://../db/migrations", db.Migrator().CurrentDatabase(), driver)
+	if err != nil {
+		t.Fatalf("migration error = %v", err)
+	}
+	if err = migration.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up error = %v", err)
+	}
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatalf("list tables error = %v", err)
+	}
+	for _, table := range tables {
+		if table == "schema_migrations" {
+			continue
+		}
+		if err = db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("empty %s error = %v", table, err)
+		}
+	}
+	return &Data{db: db}
+}
@@ -0,0 +1,265 @@
+package data
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+const (
+	newTipHeaderTopic      = "new_tip_header"
+	minResubscribeInterval = time.Second
+	maxResubscribeInterval = time.Minute
+	subscriptionBufferSize = 1024 * 1024
+)
+
+type subscriptionRequest struct {
+	Id      uint64   `json:"id"`
+	JsonRpc string   `json:"jsonrpc"`
+	Method  string   `json:"method"`
+	Params  []string `json:"params"`
+}
+
+type subscriptionMessage struct {
+	Id     *uint64          `json:"id"`
+	Result json.RawMessage  `json:"result"`
+	Error  *json.RawMessage `json:"error"`
+	Method string           `json:"method"`
+	Params struct {
+		Result       string `json:"result"`
+		Subscription string `json:"subscription"`
+	} `json:"params"`
+}
+
+type tipHeader struct {
+	Number hexutil.Uint64 `json:"number"`
+}
+
+// subscriptionConn is a newline delimited tcp or a websocket connection to the node's subscription endpoint
+type subscriptionConn interface {
+	write(message []byte) error
+	read() ([]byte, error)
+	Close() error
+}
+
+type tcpSubscriptionConn struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+func (c tcpSubscriptionConn) write(message []byte) error {
+	_, err := c.conn.Write(append(message, '\n'))
+	return err
+}
+
+func (c tcpSubscriptionConn) read() ([]byte, error) {
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("subscription connection closed")
+	}
+	return c.scanner.Bytes(), nil
+}
+
+func (c tcpSubscriptionConn) Close() error {
+	return c.conn.Close()
+}
+
+type wsSubscriptionConn struct {
+	conn *websocket.Conn
+}
+
+func (c wsSubscriptionConn) write(message []byte) error {
+	return c.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+func (c wsSubscriptionConn) read() ([]byte, error) {
+	_, message, err := c.conn.ReadMessage()
+	return message, err
+}
+
+func (c wsSubscriptionConn) Close() error {
+	return c.conn.Close()
+}
+
+// TipSubscriber subscribes to the node's new_tip_header topic and wakes the waiting sync loops on every new tip.
+// A dropped subscription is re-established with exponential backoff, meanwhile Connected reports false
+// and the sync loops fall back to polling.
+type TipSubscriber struct {
+	url       string
+	logger    *logger.Logger
+	once      sync.Once
+	mu        sync.Mutex
+	listeners []chan uint64
+	connected bool
+}
+
+func NewTipSubscriber(conf *config.CkbNode, logger *logger.Logger) *TipSubscriber {
+	subscriptionURL := os.Getenv("SUBSCRIPTION_URL")
+	if subscriptionURL == "" {
+		subscriptionURL = conf.SubscriptionUrl
+	}
+	return &TipSubscriber{
+		url:    subscriptionURL,
+		logger: logger,
+	}
+}
+
+// Enabled reports whether a subscription url is configured
+func (s *TipSubscriber) Enabled() bool {
+	return s.url != ""
+}
+
+// Connected reports whether the subscription is currently established
+func (s *TipSubscriber) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connected
+}
+
+func (s *TipSubscriber) setConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected = connected
+}
+
+// Subscribe returns a channel receiving the number of every new tip, the first call starts the subscription.
+// Notifications are dropped while the listener is busy, only the latest tip is kept.
+func (s *TipSubscriber) Subscribe(ctx context.Context) <-chan uint64 {
+	listener := make(chan uint64, 1)
+	s.mu.Lock()
+	s.listeners = append(s.listeners, listener)
+	s.mu.Unlock()
+	if s.Enabled() {
+		s.once.Do(func() {
+			go s.run(ctx)
+		})
+	}
+	return listener
+}
+
+func (s *TipSubscriber) run(ctx context.Context) {
+	interval := minResubscribeInterval
+	for {
+		err := s.subscribe(ctx, func() {
+			interval = minResubscribeInterval
+		})
+		s.setConnected(false)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Errorf(ctx, "tip subscription dropped, retry in %v: %v", interval, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxResubscribeInterval {
+			interval = maxResubscribeInterval
+		}
+	}
+}
+
+// subscribe blocks until the connection fails or ctx is done
+func (s *TipSubscriber) subscribe(ctx context.Context, onSubscribed func()) error {
+	conn, err := dialSubscription(ctx, s.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	request, err := json.Marshal(subscriptionRequest{Id: 1, JsonRpc: "2.0", Method: "subscribe", Params: []string{newTipHeaderTopic}})
+	if err != nil {
+		return err
+	}
+	if err = conn.write(request); err != nil {
+		return err
+	}
+	for {
+		message, err := conn.read()
+		if err != nil {
+			return err
+		}
+		var msg subscriptionMessage
+		if err = json.Unmarshal(message, &msg); err != nil {
+			return err
+		}
+		if msg.Id != nil {
+			if msg.Error != nil {
+				return fmt.Errorf("subscribe %s error: %s", newTipHeaderTopic, string(*msg.Error))
+			}
+			s.setConnected(true)
+			onSubscribed()
+			s.logger.Infof(ctx, "subscribed to %s at %s", newTipHeaderTopic, s.url)
+			continue
+		}
+		if msg.Method != "subscribe" {
+			continue
+		}
+		var header tipHeader
+		if err = json.Unmarshal([]byte(msg.Params.Result), &header); err != nil {
+			return err
+		}
+		s.notify(uint64(header.Number))
+	}
+}
+
+func (s *TipSubscriber) notify(tipBlockNumber uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, listener := range s.listeners {
+		select {
+		case <-listener:
+		default:
+		}
+		listener <- tipBlockNumber
+	}
+}
+
+func dialSubscription(ctx context.Context, subscriptionURL string) (subscriptionConn, error) {
+	u, err := url.Parse(subscriptionURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "tcp":
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(conn)
+		scanner.Buffer(make([]byte, 0, 64*1024), subscriptionBufferSize)
+		return tcpSubscriptionConn{conn: conn, scanner: scanner}, nil
+	case "ws", "wss":
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, subscriptionURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		conn.SetReadLimit(subscriptionBufferSize)
+		return wsSubscriptionConn{conn: conn}, nil
+	}
+	return nil, fmt.Errorf("unsupported subscription url scheme %q", u.Scheme)
+}
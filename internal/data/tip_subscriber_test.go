@@ -0,0 +1,112 @@
+package data
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// tipNotification mimics the new_tip_header notification of the node
+func tipNotification(tipBlockNumber uint64) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"subscribe","params":{"result":"{\"number\":\"0x%x\"}","subscription":"0x0"}}`, tipBlockNumber)
+}
+
+const subscriptionAck = `{"id":1,"jsonrpc":"2.0","result":"0x0"}`
+
+// newTcpNode serves the subscription over newline delimited tcp, each connection pushes one tip and is then closed
+func newTcpNode(t *testing.T, tips []uint64) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for _, tip := range tips {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			request, err := bufio.NewReader(conn).ReadString('\n')
+			if err == nil && strings.Contains(request, newTipHeaderTopic) {
+				fmt.Fprintf(conn, "%s\n%s\n", subscriptionAck, tipNotification(tip))
+			}
+			conn.Close()
+		}
+	}()
+	return "tcp://" + listener.Addr().String()
+}
+
+// newWsNode serves the subscription over websocket, each connection pushes one tip and is then closed
+func newWsNode(t *testing.T, tips []uint64) string {
+	next := make(chan uint64, len(tips))
+	for _, tip := range tips {
+		next <- tip
+	}
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, request, err := conn.ReadMessage()
+		if err != nil || !strings.Contains(string(request), newTipHeaderTopic) {
+			return
+		}
+		select {
+		case tip := <-next:
+			conn.WriteMessage(websocket.TextMessage, []byte(subscriptionAck))
+			conn.WriteMessage(websocket.TextMessage, []byte(tipNotification(tip)))
+		default:
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestTipSubscriber_Subscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		newNode func(t *testing.T, tips []uint64) string
+	}{
+		{
+			name:    "should receive tips over tcp and resubscribe after a drop",
+			newNode: newTcpNode,
+		},
+		{
+			name:    "should receive tips over websocket and resubscribe after a drop",
+			newNode: newWsNode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []uint64{0x10, 0x11}
+			url := tt.newNode(t, want)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			subscriber := NewTipSubscriber(&config.CkbNode{SubscriptionUrl: url}, logger.NewLogger(io.Discard, "", 0))
+			tips := subscriber.Subscribe(ctx)
+			for _, tip := range want {
+				select {
+				case got := <-tips:
+					if got != tip {
+						t.Errorf("Subscribe() got tip %d, want %d", got, tip)
+					}
+				case <-ctx.Done():
+					t.Fatalf("Subscribe() timed out waiting for tip %d", tip)
+				}
+			}
+		})
+	}
+}
@@ -60,7 +60,11 @@ func NewTransferCotaKvPairRepo(data *Data, logger *logger.Logger) biz.TransferCo
 }
 
 func generateTransferUpdateWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp transferCotaKvPairRepo) (claimedCotas []biz.ClaimedCotaNftKvPair, withdrawCotas []biz.WithdrawCotaNftKvPair, err error) {
-	entries := smt.TransferUpdateCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.TransferUpdateCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	claimedCotaKeyVec := entries.ClaimKeys()
 	lockHash, err := entry.LockScript.Hash()
 	if err != nil {
@@ -73,14 +77,15 @@ func generateTransferUpdateWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
 		claimedCotas = append(claimedCotas, biz.ClaimedCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.NftId().CotaId().RawData()),
-			CotaIdCRC:   crc32.ChecksumIEEE([]byte(cotaId)),
-			TokenIndex:  binary.BigEndian.Uint32(key.NftId().Index().RawData()),
-			OutPoint:    outpointStr,
-			OutPointCrc: crc32.ChecksumIEEE([]byte(outpointStr)),
-			LockHash:    lockHashStr,
-			LockHashCrc: lockHashCRC32,
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.NftId().CotaId().RawData()),
+			CotaIdCRC:     crc32.ChecksumIEEE([]byte(cotaId)),
+			TokenIndex:    binary.BigEndian.Uint32(key.NftId().Index().RawData()),
+			OutPoint:      outpointStr,
+			OutPointCrc:   crc32.ChecksumIEEE([]byte(outpointStr)),
+			LockHash:      lockHashStr,
+			LockHashCrc:   lockHashCRC32,
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	withdrawKeyVec := entries.WithdrawalKeys()
@@ -90,7 +95,11 @@ func generateTransferUpdateWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.CotaId().RawData())
 		outpointStr := hex.EncodeToString(value.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -114,6 +123,7 @@ func generateTransferUpdateWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
@@ -125,12 +135,20 @@ func generateTransferUpdateWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.E
 	var withdrawValueVec *smt.WithdrawalCotaNFTValueV1Vec = nil
 
 	if entry.Version == 1 {
-		entries := smt.TransferUpdateCotaNFTV1EntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.TransferUpdateCotaNFTV1EntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		claimedCotaKeyVec = entries.ClaimKeys()
 		withdrawKeyVec = entries.WithdrawalKeys()
 		withdrawValueVec = entries.WithdrawalValues()
 	} else {
-		entries := smt.TransferUpdateCotaNFTV2EntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.TransferUpdateCotaNFTV2EntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		claimedCotaKeyVec = entries.ClaimKeys()
 		withdrawKeyVec = entries.WithdrawalKeys()
 		withdrawValueVec = entries.WithdrawalValues()
@@ -146,14 +164,15 @@ func generateTransferUpdateWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.E
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
 		claimedCotas = append(claimedCotas, biz.ClaimedCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.NftId().CotaId().RawData()),
-			CotaIdCRC:   crc32.ChecksumIEEE([]byte(cotaId)),
-			TokenIndex:  binary.BigEndian.Uint32(key.NftId().Index().RawData()),
-			OutPoint:    outpointStr,
-			OutPointCrc: crc32.ChecksumIEEE([]byte(outpointStr)),
-			LockHash:    lockHashStr,
-			LockHashCrc: lockHashCRC32,
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.NftId().CotaId().RawData()),
+			CotaIdCRC:     crc32.ChecksumIEEE([]byte(cotaId)),
+			TokenIndex:    binary.BigEndian.Uint32(key.NftId().Index().RawData()),
+			OutPoint:      outpointStr,
+			OutPointCrc:   crc32.ChecksumIEEE([]byte(outpointStr)),
+			LockHash:      lockHashStr,
+			LockHashCrc:   lockHashCRC32,
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	for i := uint(0); i < withdrawKeyVec.Len(); i++ {
@@ -161,7 +180,11 @@ func generateTransferUpdateWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.E
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -185,13 +208,18 @@ func generateTransferUpdateWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.E
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
 }
 
 func generateTransferWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp transferCotaKvPairRepo) (claimedCotas []biz.ClaimedCotaNftKvPair, withdrawCotas []biz.WithdrawCotaNftKvPair, err error) {
-	entries := smt.TransferCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.TransferCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	claimedCotaKeyVec := entries.ClaimKeys()
 	lockHash, err := entry.LockScript.Hash()
 	if err != nil {
@@ -204,14 +232,15 @@ func generateTransferWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp t
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
 		claimedCotas = append(claimedCotas, biz.ClaimedCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.NftId().CotaId().RawData()),
-			CotaIdCRC:   crc32.ChecksumIEEE([]byte(cotaId)),
-			TokenIndex:  binary.BigEndian.Uint32(key.NftId().Index().RawData()),
-			OutPoint:    outpointStr,
-			OutPointCrc: crc32.ChecksumIEEE([]byte(outpointStr)),
-			LockHash:    lockHashStr,
-			LockHashCrc: lockHashCRC32,
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.NftId().CotaId().RawData()),
+			CotaIdCRC:     crc32.ChecksumIEEE([]byte(cotaId)),
+			TokenIndex:    binary.BigEndian.Uint32(key.NftId().Index().RawData()),
+			OutPoint:      outpointStr,
+			OutPointCrc:   crc32.ChecksumIEEE([]byte(outpointStr)),
+			LockHash:      lockHashStr,
+			LockHashCrc:   lockHashCRC32,
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	withdrawKeyVec := entries.WithdrawalKeys()
@@ -221,7 +250,11 @@ func generateTransferWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp t
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.CotaId().RawData())
 		outpointStr := hex.EncodeToString(value.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -245,6 +278,7 @@ func generateTransferWithdrawV0KvPairs(blockNumber uint64, entry biz.Entry, rp t
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
@@ -255,12 +289,20 @@ func generateTransferWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.Entry,
 	var withdrawKeyVec *smt.WithdrawalCotaNFTKeyV1Vec = nil
 	var withdrawValueVec *smt.WithdrawalCotaNFTValueV1Vec = nil
 	if entry.Version == 1 {
-		entries := smt.TransferCotaNFTV1EntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.TransferCotaNFTV1EntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		claimedCotaKeyVec = entries.ClaimKeys()
 		withdrawKeyVec = entries.WithdrawalKeys()
 		withdrawValueVec = entries.WithdrawalValues()
 	} else {
-		entries := smt.TransferCotaNFTV2EntriesFromSliceUnchecked(entry.InputType[1:])
+		entries, parseErr := smt.TransferCotaNFTV2EntriesFromSlice(entry.InputType[1:], true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		claimedCotaKeyVec = entries.ClaimKeys()
 		withdrawKeyVec = entries.WithdrawalKeys()
 		withdrawValueVec = entries.WithdrawalValues()
@@ -276,14 +318,15 @@ func generateTransferWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.Entry,
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
 		claimedCotas = append(claimedCotas, biz.ClaimedCotaNftKvPair{
-			BlockNumber: blockNumber,
-			CotaId:      hex.EncodeToString(key.NftId().CotaId().RawData()),
-			CotaIdCRC:   crc32.ChecksumIEEE([]byte(cotaId)),
-			TokenIndex:  binary.BigEndian.Uint32(key.NftId().Index().RawData()),
-			OutPoint:    outpointStr,
-			OutPointCrc: crc32.ChecksumIEEE([]byte(outpointStr)),
-			LockHash:    lockHashStr,
-			LockHashCrc: lockHashCRC32,
+			BlockNumber:   blockNumber,
+			CotaId:        hex.EncodeToString(key.NftId().CotaId().RawData()),
+			CotaIdCRC:     crc32.ChecksumIEEE([]byte(cotaId)),
+			TokenIndex:    binary.BigEndian.Uint32(key.NftId().Index().RawData()),
+			OutPoint:      outpointStr,
+			OutPointCrc:   crc32.ChecksumIEEE([]byte(outpointStr)),
+			LockHash:      lockHashStr,
+			LockHashCrc:   lockHashCRC32,
+			ScriptVersion: entry.ScriptVersion,
 		})
 	}
 	for i := uint(0); i < withdrawKeyVec.Len(); i++ {
@@ -291,7 +334,11 @@ func generateTransferWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.Entry,
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -315,6 +362,7 @@ func generateTransferWithdrawV1ToV2KvPairs(blockNumber uint64, entry biz.Entry,
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
@@ -0,0 +1,173 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"gorm.io/gorm"
+)
+
+var _ biz.UnconfirmedKvPairRepo = (*unconfirmedKvPairRepo)(nil)
+
+type UnconfirmedBlock struct {
+	ID          uint `gorm:"primaryKey"`
+	BlockNumber uint64
+	BlockHash   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type UnconfirmedKvPair struct {
+	ID          uint `gorm:"primaryKey"`
+	BlockNumber uint64
+	PairType    string
+	LockHash    string
+	CotaId      string
+	TokenIndex  uint32
+	Payload     string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type unconfirmedKvPairRepo struct {
+	data   *Data
+	logger *logger.Logger
+}
+
+func NewUnconfirmedKvPairRepo(data *Data, logger *logger.Logger) biz.UnconfirmedKvPairRepo {
+	return &unconfirmedKvPairRepo{
+		data:   data,
+		logger: logger,
+	}
+}
+
+func (rp unconfirmedKvPairRepo) FindUnconfirmedBlocks(ctx context.Context) ([]biz.UnconfirmedBlock, error) {
+	var blocks []UnconfirmedBlock
+	if err := rp.data.db.WithContext(ctx).Order("block_number asc").Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	result := make([]biz.UnconfirmedBlock, len(blocks))
+	for i, block := range blocks {
+		result[i] = biz.UnconfirmedBlock{
+			BlockNumber: block.BlockNumber,
+			BlockHash:   block.BlockHash,
+		}
+	}
+	return result, nil
+}
+
+func (rp unconfirmedKvPairRepo) CreateUnconfirmedKvPairs(ctx context.Context, block biz.UnconfirmedBlock, kvPair *biz.KvPair) error {
+	pairs, err := unconfirmedKvPairs(block.BlockNumber, kvPair)
+	if err != nil {
+		return err
+	}
+	return rp.data.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("block_number >= ?", block.BlockNumber).Delete(UnconfirmedKvPair{}).Error; err != nil {
+			return err
+		}
+		if err := tx.WithContext(ctx).Where("block_number >= ?", block.BlockNumber).Delete(UnconfirmedBlock{}).Error; err != nil {
+			return err
+		}
+		if len(pairs) > 0 {
+			if err := tx.Model(UnconfirmedKvPair{}).WithContext(ctx).Create(pairs).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(UnconfirmedBlock{}).WithContext(ctx).Create(&UnconfirmedBlock{
+			BlockNumber: block.BlockNumber,
+			BlockHash:   block.BlockHash,
+		}).Error
+	})
+}
+
+func (rp unconfirmedKvPairRepo) DeleteConfirmedKvPairs(ctx context.Context, blockNumber uint64) error {
+	return rp.data.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("block_number <= ?", blockNumber).Delete(UnconfirmedKvPair{}).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Where("block_number <= ?", blockNumber).Delete(UnconfirmedBlock{}).Error
+	})
+}
+
+func (rp unconfirmedKvPairRepo) DeleteOrphanedKvPairs(ctx context.Context, blockNumber uint64) error {
+	return rp.data.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("block_number >= ?", blockNumber).Delete(UnconfirmedKvPair{}).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Where("block_number >= ?", blockNumber).Delete(UnconfirmedBlock{}).Error
+	})
+}
+
+// unconfirmedKvPairs flattens the kv pairs of a block into overlay rows, the full pair is kept as json payload
+func unconfirmedKvPairs(blockNumber uint64, kvPair *biz.KvPair) ([]UnconfirmedKvPair, error) {
+	var pairs []UnconfirmedKvPair
+	add := func(pairType, lockHash, cotaId string, tokenIndex uint32, pair any) error {
+		payload, err := json.Marshal(pair)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, UnconfirmedKvPair{
+			BlockNumber: blockNumber,
+			PairType:    pairType,
+			LockHash:    lockHash,
+			CotaId:      cotaId,
+			TokenIndex:  tokenIndex,
+			Payload:     string(payload),
+		})
+		return nil
+	}
+	for _, register := range kvPair.Registers {
+		if err := add("register", register.LockHash, "", 0, register); err != nil {
+			return nil, err
+		}
+	}
+	for _, history := range kvPair.RegistryHistories {
+		if err := add("registry_history", "", "", 0, history); err != nil {
+			return nil, err
+		}
+	}
+	for _, define := range kvPair.DefineCotas {
+		if err := add("define", define.LockHash, define.CotaId, 0, define); err != nil {
+			return nil, err
+		}
+	}
+	for _, define := range kvPair.UpdatedDefineCotas {
+		if err := add("update_define", define.LockHash, define.CotaId, 0, define); err != nil {
+			return nil, err
+		}
+	}
+	for _, hold := range kvPair.HoldCotas {
+		if err := add("hold", hold.LockHash, hold.CotaId, hold.TokenIndex, hold); err != nil {
+			return nil, err
+		}
+	}
+	for _, hold := range kvPair.UpdatedHoldCotas {
+		if err := add("update_hold", hold.LockHash, hold.CotaId, hold.TokenIndex, hold); err != nil {
+			return nil, err
+		}
+	}
+	for _, withdraw := range kvPair.WithdrawCotas {
+		if err := add("withdraw", withdraw.LockHash, withdraw.CotaId, withdraw.TokenIndex, withdraw); err != nil {
+			return nil, err
+		}
+	}
+	for _, claimed := range kvPair.ClaimedCotas {
+		if err := add("claimed", claimed.LockHash, claimed.CotaId, claimed.TokenIndex, claimed); err != nil {
+			return nil, err
+		}
+	}
+	for _, issuer := range kvPair.IssuerInfos {
+		if err := add("issuer_info", issuer.LockHash, "", 0, issuer); err != nil {
+			return nil, err
+		}
+	}
+	for _, class := range kvPair.ClassInfos {
+		if err := add("class_info", "", class.CotaId, 0, class); err != nil {
+			return nil, err
+		}
+	}
+	return pairs, nil
+}
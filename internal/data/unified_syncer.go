@@ -0,0 +1,38 @@
+package data
+
+import (
+	"context"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+)
+
+// UnifiedSyncer stores the cota entries and the metadata of a block parsed once by BlockSyncer.Parse
+type UnifiedSyncer struct {
+	blockSyncer    BlockSyncer
+	metadataSyncer MetadataSyncer
+	kvPairUsecase  *biz.SyncKvPairUsecase
+}
+
+func NewUnifiedSyncer(blockSyncer BlockSyncer, metadataSyncer MetadataSyncer, kvPairUsecase *biz.SyncKvPairUsecase) UnifiedSyncer {
+	return UnifiedSyncer{
+		blockSyncer:    blockSyncer,
+		metadataSyncer: metadataSyncer,
+		kvPairUsecase:  kvPairUsecase,
+	}
+}
+
+// Save stores both kinds of kv pairs and advances the SyncBlock and SyncMetadata check infos in one transaction
+func (bp UnifiedSyncer) Save(ctx context.Context, parsedBlock ParsedBlock, checkInfo biz.CheckInfo) error {
+	pairs, err := bp.blockSyncer.KvPairs(parsedBlock)
+	if err != nil {
+		return err
+	}
+	metadata, err := bp.metadataSyncer.ParsedKvPairs(ctx, parsedBlock)
+	if err != nil {
+		return err
+	}
+	pairs.IssuerInfos = metadata.IssuerInfos
+	pairs.ClassInfos = metadata.ClassInfos
+	pairs.DeadLetters = append(pairs.DeadLetters, metadata.DeadLetters...)
+	return bp.kvPairUsecase.CreateKvPairs(ctx, checkInfo, &pairs)
+}
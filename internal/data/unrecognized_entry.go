@@ -0,0 +1,87 @@
+package data
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/metrics"
+	"gorm.io/gorm"
+)
+
+type UnrecognizedEntry struct {
+	ID            uint `gorm:"primaryKey"`
+	BlockNumber   uint64
+	TxIndex       uint32
+	LockHash      string
+	ActionType    uint8
+	Version       uint8
+	ScriptVersion uint8
+	RawData       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// recognizeEntries splits off the entries without a handler for their action type and version, they are logged,
+// counted and returned for storage instead of being fed to the handlers of other versions. Entries without input
+// type only carry metadata, which does not depend on the version.
+func recognizeEntries(ctx context.Context, blockNumber uint64, entries []biz.Entry, handlers *biz.EntryHandlerRegistry, logger *logger.Logger) ([]biz.Entry, []biz.UnrecognizedEntry, error) {
+	recognized := entries[:0:0]
+	var unrecognized []biz.UnrecognizedEntry
+	for _, entry := range entries {
+		if len(entry.InputType) == 0 {
+			recognized = append(recognized, entry)
+			continue
+		}
+		actionType := entry.InputType[0]
+		if _, ok := handlers.Lookup(actionType, entry.Version); ok {
+			recognized = append(recognized, entry)
+			continue
+		}
+		reason := "version"
+		if !handlers.HasAction(actionType) {
+			reason = "action_type"
+		}
+		lockHash, err := entry.LockScript.Hash()
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Warnf(ctx, "unrecognized cota entry in tx %d of block %d: action type %d, version %d", entry.TxIndex, blockNumber, actionType, entry.Version)
+		metrics.UnrecognizedEntries.Add(reason, 1)
+		unrecognized = append(unrecognized, biz.UnrecognizedEntry{
+			BlockNumber:   blockNumber,
+			TxIndex:       entry.TxIndex,
+			LockHash:      lockHash.String()[2:],
+			ActionType:    actionType,
+			Version:       entry.Version,
+			ScriptVersion: entry.ScriptVersion,
+			RawData:       hex.EncodeToString(entry.InputType),
+		})
+	}
+	return recognized, unrecognized, nil
+}
+
+func createUnrecognizedEntries(ctx context.Context, tx *gorm.DB, kvPair *biz.KvPair) error {
+	if len(kvPair.UnrecognizedEntries) == 0 {
+		return nil
+	}
+	entries := make([]UnrecognizedEntry, len(kvPair.UnrecognizedEntries))
+	for i, entry := range kvPair.UnrecognizedEntries {
+		entries[i] = UnrecognizedEntry{
+			BlockNumber:   entry.BlockNumber,
+			TxIndex:       entry.TxIndex,
+			LockHash:      entry.LockHash,
+			ActionType:    entry.ActionType,
+			Version:       entry.Version,
+			ScriptVersion: entry.ScriptVersion,
+			RawData:       entry.RawData,
+		}
+	}
+	return tx.Model(UnrecognizedEntry{}).WithContext(ctx).Create(entries).Error
+}
+
+func restoreUnrecognizedEntries(ctx context.Context, tx *gorm.DB, blockNumber uint64) error {
+	return tx.WithContext(ctx).Where("block_number = ?", blockNumber).Delete(UnrecognizedEntry{}).Error
+}
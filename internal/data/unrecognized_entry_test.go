@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func Test_recognizeEntries(t *testing.T) {
+	lock := &ckbTypes.Script{CodeHash: ckbTypes.HexToHash("0x01"), HashType: ckbTypes.HashTypeType, Args: []byte{0x02}}
+	lockHash, err := lock.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name             string
+		entry            biz.Entry
+		wantRecognized   bool
+		wantUnrecognized biz.UnrecognizedEntry
+	}{
+		{
+			name:           "should keep a known action type",
+			entry:          biz.Entry{InputType: []byte{0x06, 0xaa}, LockScript: lock, Version: 2},
+			wantRecognized: true,
+		},
+		{
+			name:           "should keep a metadata entry of any version",
+			entry:          biz.Entry{OutputType: []byte("{}"), LockScript: lock, Version: 9},
+			wantRecognized: true,
+		},
+		{
+			name:           "should keep an action type with a registered handler",
+			entry:          biz.Entry{InputType: []byte{0x0a}, LockScript: lock, Version: 3},
+			wantRecognized: true,
+		},
+		{
+			name:  "should record an unknown action type",
+			entry: biz.Entry{InputType: []byte{0x09, 0xaa}, LockScript: lock, TxIndex: 3, Version: 1, ScriptVersion: 1},
+			wantUnrecognized: biz.UnrecognizedEntry{
+				BlockNumber:   100,
+				TxIndex:       3,
+				LockHash:      lockHash.String()[2:],
+				ActionType:    9,
+				Version:       1,
+				ScriptVersion: 1,
+				RawData:       "09aa",
+			},
+		},
+		{
+			name:  "should record an extension entry",
+			entry: biz.Entry{InputType: []byte{0xf0, 0xaa}, LockScript: lock, Version: 1},
+			wantUnrecognized: biz.UnrecognizedEntry{
+				BlockNumber: 100,
+				LockHash:    lockHash.String()[2:],
+				ActionType:  0xf0,
+				Version:     1,
+				RawData:     "f0aa",
+			},
+		},
+		{
+			name:  "should record a fungible token entry",
+			entry: biz.Entry{InputType: []byte{0xf3, 0xaa}, LockScript: lock, Version: 2},
+			wantUnrecognized: biz.UnrecognizedEntry{
+				BlockNumber: 100,
+				LockHash:    lockHash.String()[2:],
+				ActionType:  0xf3,
+				Version:     2,
+				RawData:     "f3aa",
+			},
+		},
+		{
+			name:  "should record an unknown version",
+			entry: biz.Entry{InputType: []byte{0x01}, LockScript: lock, Version: 3},
+			wantUnrecognized: biz.UnrecognizedEntry{
+				BlockNumber: 100,
+				LockHash:    lockHash.String()[2:],
+				ActionType:  1,
+				Version:     3,
+				RawData:     "01",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers := biz.NewEntryHandlerRegistry(nil, nil, nil, nil, nil, nil)
+			// an extension handler of an embedding application
+			handlers.Register(10, 3, biz.EntryHandlerFunc(func(uint64, biz.Entry, *biz.KvPair) error { return nil }))
+			recognized, unrecognized, err := recognizeEntries(context.Background(), 100, []biz.Entry{tt.entry}, handlers, logger.NewLogger(io.Discard, "", 0))
+			if err != nil {
+				t.Fatalf("recognizeEntries() error = %v", err)
+			}
+			if got := len(recognized) == 1; got != tt.wantRecognized {
+				t.Fatalf("recognized = %v, want %v", got, tt.wantRecognized)
+			}
+			if tt.wantRecognized {
+				if len(unrecognized) != 0 {
+					t.Errorf("unrecognized = %+v, want none", unrecognized)
+				}
+				return
+			}
+			if len(unrecognized) != 1 || unrecognized[0] != tt.wantUnrecognized {
+				t.Errorf("unrecognized = %+v, want %+v", unrecognized, tt.wantUnrecognized)
+			}
+		})
+	}
+}
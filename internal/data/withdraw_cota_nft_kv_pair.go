@@ -30,6 +30,7 @@ type WithdrawCotaNftKvPair struct {
 	LockHash             string
 	LockHashCrc          uint32
 	Version              uint8
+	ScriptVersion        uint8
 	CreatedAt            time.Time
 	UpdatedAt            time.Time
 }
@@ -102,7 +103,11 @@ func hashType(hashTypeStr string) (int64, error) {
 }
 
 func generateV0WithdrawKvPair(blockNumber uint64, entry biz.Entry, rp withdrawCotaNftKvPairRepo) (withdrawCotas []biz.WithdrawCotaNftKvPair, err error) {
-	entries := smt.WithdrawalCotaNFTEntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.WithdrawalCotaNFTEntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	withdrawKeyVec := entries.WithdrawalKeys()
 	withdrawValueVec := entries.WithdrawalValues()
 	lockHash, err := entry.LockScript.Hash()
@@ -116,7 +121,11 @@ func generateV0WithdrawKvPair(blockNumber uint64, entry biz.Entry, rp withdrawCo
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.CotaId().RawData())
 		outpointStr := hex.EncodeToString(value.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -140,13 +149,18 @@ func generateV0WithdrawKvPair(blockNumber uint64, entry biz.Entry, rp withdrawCo
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
 }
 
 func generateV1WithdrawKvPair(blockNumber uint64, entry biz.Entry, rp withdrawCotaNftKvPairRepo) (withdrawCotas []biz.WithdrawCotaNftKvPair, err error) {
-	entries := smt.WithdrawalCotaNFTV1EntriesFromSliceUnchecked(entry.InputType[1:])
+	entries, parseErr := smt.WithdrawalCotaNFTV1EntriesFromSlice(entry.InputType[1:], true)
+	if parseErr != nil {
+		err = biz.MalformedEntry(entry.TxIndex, parseErr)
+		return
+	}
 	withdrawKeyVec := entries.WithdrawalKeys()
 	withdrawValueVec := entries.WithdrawalValues()
 	lockHash, err := entry.LockScript.Hash()
@@ -160,7 +174,11 @@ func generateV1WithdrawKvPair(blockNumber uint64, entry biz.Entry, rp withdrawCo
 		value := withdrawValueVec.Get(i)
 		cotaId := hex.EncodeToString(key.NftId().CotaId().RawData())
 		outpointStr := hex.EncodeToString(key.OutPoint().RawData())
-		receiverLock := blockchain.ScriptFromSliceUnchecked(value.ToLock().RawData())
+		receiverLock, parseErr := blockchain.ScriptFromSlice(value.ToLock().RawData(), true)
+		if parseErr != nil {
+			err = biz.MalformedEntry(entry.TxIndex, parseErr)
+			return
+		}
 		script := biz.Script{
 			CodeHash: hex.EncodeToString(receiverLock.CodeHash().RawData()),
 			HashType: hex.EncodeToString(receiverLock.HashType().AsSlice()),
@@ -184,6 +202,7 @@ func generateV1WithdrawKvPair(blockNumber uint64, entry biz.Entry, rp withdrawCo
 			LockHash:             lockHashStr,
 			LockHashCrc:          lockHashCRC32,
 			Version:              entry.Version,
+			ScriptVersion:        entry.ScriptVersion,
 		})
 	}
 	return
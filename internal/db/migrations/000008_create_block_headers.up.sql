@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS block_headers (
+    id bigint NOT NULL AUTO_INCREMENT,
+    block_number bigint unsigned NOT NULL,
+    block_hash char(64) NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT uc_block_headers_on_block_number UNIQUE (block_number)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT IGNORE INTO block_headers (block_number, block_hash, created_at, updated_at)
+SELECT block_number, block_hash, now(), now() FROM check_infos WHERE check_type = 0;
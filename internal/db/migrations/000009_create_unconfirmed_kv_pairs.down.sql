@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS unconfirmed_kv_pairs;
+DROP TABLE IF EXISTS unconfirmed_blocks;
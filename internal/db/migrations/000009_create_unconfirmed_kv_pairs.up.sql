@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS unconfirmed_blocks (
+    id bigint NOT NULL AUTO_INCREMENT,
+    block_number bigint unsigned NOT NULL,
+    block_hash char(64) NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT uc_unconfirmed_blocks_on_block_number UNIQUE (block_number)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS unconfirmed_kv_pairs (
+    id bigint NOT NULL AUTO_INCREMENT,
+    block_number bigint unsigned NOT NULL,
+    pair_type varchar(40) NOT NULL,
+    lock_hash char(64) NOT NULL,
+    cota_id char(40) NOT NULL,
+    token_index int unsigned NOT NULL,
+    payload text NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    KEY index_unconfirmed_kv_pairs_on_block_number (block_number),
+    KEY index_unconfirmed_kv_pairs_on_lock_hash (lock_hash),
+    KEY index_unconfirmed_kv_pairs_on_cota_id_and_token_index (cota_id, token_index)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
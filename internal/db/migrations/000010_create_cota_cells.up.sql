@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS cota_cells (
+    id bigint NOT NULL AUTO_INCREMENT,
+    tx_hash char(64) NOT NULL,
+    output_index int unsigned NOT NULL,
+    lock_hash char(64) NOT NULL,
+    type_hash char(64) NOT NULL,
+    type_code_hash char(64) NOT NULL,
+    type_hash_type varchar(10) NOT NULL,
+    type_args varchar(255) NOT NULL,
+    version tinyint unsigned NOT NULL,
+    smt_root char(64) NOT NULL,
+    block_number bigint unsigned NOT NULL,
+    consumed_block_number bigint unsigned NOT NULL DEFAULT 0,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    KEY index_cota_cells_on_lock_hash (lock_hash),
+    KEY index_cota_cells_on_block_number (block_number),
+    KEY index_cota_cells_on_consumed_block_number (consumed_block_number),
+    CONSTRAINT uc_cota_cells_on_out_point UNIQUE (tx_hash, output_index)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
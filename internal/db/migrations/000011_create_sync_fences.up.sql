@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS sync_fences (
+    id bigint NOT NULL AUTO_INCREMENT,
+    name varchar(64) NOT NULL,
+    token char(36) NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT uc_sync_fences_on_name UNIQUE (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
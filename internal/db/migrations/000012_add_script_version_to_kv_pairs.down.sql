@@ -0,0 +1,16 @@
+ALTER TABLE claimed_cota_nft_kv_pairs
+    DROP COLUMN script_version;
+ALTER TABLE withdraw_cota_nft_kv_pairs
+    DROP COLUMN script_version;
+ALTER TABLE hold_cota_nft_kv_pair_versions
+    DROP COLUMN script_version,
+    DROP COLUMN old_script_version;
+ALTER TABLE hold_cota_nft_kv_pairs
+    DROP COLUMN script_version;
+ALTER TABLE define_cota_nft_kv_pair_versions
+    DROP COLUMN script_version,
+    DROP COLUMN old_script_version;
+ALTER TABLE define_cota_nft_kv_pairs
+    DROP COLUMN script_version;
+ALTER TABLE register_cota_kv_pairs
+    DROP COLUMN script_version;
@@ -0,0 +1,16 @@
+ALTER TABLE register_cota_kv_pairs
+    ADD script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER lock_hash;
+ALTER TABLE define_cota_nft_kv_pairs
+    ADD script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER lock_hash_crc;
+ALTER TABLE define_cota_nft_kv_pair_versions
+    ADD old_script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER lock_hash,
+    ADD script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER old_script_version;
+ALTER TABLE hold_cota_nft_kv_pairs
+    ADD script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER lock_hash_crc;
+ALTER TABLE hold_cota_nft_kv_pair_versions
+    ADD old_script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER lock_hash,
+    ADD script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER old_script_version;
+ALTER TABLE withdraw_cota_nft_kv_pairs
+    ADD script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER version;
+ALTER TABLE claimed_cota_nft_kv_pairs
+    ADD script_version tinyint unsigned NOT NULL DEFAULT 0 AFTER lock_hash_crc;
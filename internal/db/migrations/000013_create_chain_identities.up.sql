@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS chain_identities (
+    id bigint NOT NULL AUTO_INCREMENT,
+    chain varchar(64) NOT NULL,
+    genesis_hash char(64) NOT NULL,
+    scripts text NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id bigint NOT NULL AUTO_INCREMENT,
+    block_number bigint unsigned NOT NULL,
+    tx_index int unsigned NOT NULL,
+    tx_hash char(64) NOT NULL,
+    check_type tinyint unsigned NOT NULL,
+    error_kind varchar(64) NOT NULL,
+    error text NOT NULL,
+    witnesses mediumtext NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    KEY index_dead_letters_on_block_number_and_check_type (block_number, check_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
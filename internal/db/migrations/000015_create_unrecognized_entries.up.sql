@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS unrecognized_entries (
+    id bigint NOT NULL AUTO_INCREMENT,
+    block_number bigint unsigned NOT NULL,
+    tx_index int unsigned NOT NULL,
+    lock_hash char(64) NOT NULL,
+    action_type tinyint unsigned NOT NULL,
+    version tinyint unsigned NOT NULL,
+    script_version tinyint unsigned NOT NULL DEFAULT 0,
+    raw_data mediumtext NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    KEY index_unrecognized_entries_on_block_number (block_number),
+    KEY index_unrecognized_entries_on_lock_hash (lock_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
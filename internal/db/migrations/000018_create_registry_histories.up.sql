@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS registry_histories (
+    id bigint NOT NULL AUTO_INCREMENT,
+    block_number bigint unsigned NOT NULL,
+    tx_index int unsigned NOT NULL,
+    tx_hash char(64) NOT NULL,
+    output_index int unsigned NOT NULL,
+    smt_root char(64) NOT NULL,
+    register_count int unsigned NOT NULL,
+    script_version tinyint unsigned NOT NULL DEFAULT 0,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id),
+    KEY index_registry_histories_on_block_number (block_number),
+    CONSTRAINT uc_registry_histories_on_tx_hash UNIQUE (tx_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS cota_cell_coverages (
+    id bigint NOT NULL AUTO_INCREMENT,
+    from_block_number bigint unsigned NOT NULL,
+    created_at datetime(6) NOT NULL,
+    updated_at datetime(6) NOT NULL,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
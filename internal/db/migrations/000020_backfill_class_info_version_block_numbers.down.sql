@@ -0,0 +1 @@
+UPDATE class_info_versions SET block_number = 0 WHERE action_type = 0;
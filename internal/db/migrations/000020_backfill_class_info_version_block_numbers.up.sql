@@ -0,0 +1,9 @@
+UPDATE class_info_versions v
+JOIN (SELECT cota_id, MIN(old_block_number) AS block_number FROM class_info_versions WHERE action_type = 1 GROUP BY cota_id) u ON u.cota_id = v.cota_id
+SET v.block_number = u.block_number
+WHERE v.action_type = 0 AND v.block_number = 0;
+
+UPDATE class_info_versions v
+JOIN class_infos c ON c.cota_id = v.cota_id
+SET v.block_number = c.block_number
+WHERE v.action_type = 0 AND v.block_number = 0;
@@ -0,0 +1,37 @@
+package metrics
+
+import "expvar"
+
+// The counters are published through expvar and served at /debug/vars by MetricsService
+var (
+	// RpcCalls counts the node rpc requests by method, a batch request counts once
+	RpcCalls = expvar.NewMap("rpc_calls")
+	// RpcBatchItems counts the calls sent inside batch requests by method
+	RpcBatchItems = expvar.NewMap("rpc_batch_items")
+	// RpcFailovers counts the switches of the primary rpc endpoint
+	RpcFailovers = expvar.NewInt("rpc_failovers")
+	// RpcErrors counts the failed node rpc calls by method, each attempt counts
+	RpcErrors = expvar.NewMap("rpc_errors")
+	// RpcTimeouts counts the node rpc calls by method that ran into the rpc_timeout
+	RpcTimeouts = expvar.NewMap("rpc_timeouts")
+	// RpcRetries counts the retried node rpc calls by method
+	RpcRetries = expvar.NewMap("rpc_retries")
+	// RpcRejected counts the node rpc calls by method rejected by the open circuit breaker
+	RpcRejected = expvar.NewMap("rpc_rejected")
+	// RpcBreakerTrips counts how often the circuit breaker opened
+	RpcBreakerTrips = expvar.NewInt("rpc_breaker_trips")
+	// RpcBreakerOpen is 1 while the circuit breaker is open
+	RpcBreakerOpen = expvar.NewInt("rpc_breaker_open")
+	// InputCacheHits counts the previous outputs resolved from the input cell cache
+	InputCacheHits = expvar.NewInt("input_cache_hits")
+	// InputCacheMisses counts the previous outputs that had to be fetched from the node or the cota cell index
+	InputCacheMisses = expvar.NewInt("input_cache_misses")
+	// LocalInputQueries counts the cota cell index queries made to resolve inputs
+	LocalInputQueries = expvar.NewInt("local_input_queries")
+	// DeadLetters counts the transactions quarantined by check type
+	DeadLetters = expvar.NewMap("dead_letters")
+	// UnrecognizedEntries counts the cota entries with an unknown action type or entry version by the unknown field
+	UnrecognizedEntries = expvar.NewMap("unrecognized_entries")
+	// SmtRootChecks counts the smt roots of the cota cells checked after the blocks by result: match or mismatch
+	SmtRootChecks = expvar.NewMap("smt_root_checks")
+)
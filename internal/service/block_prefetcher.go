@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+)
+
+const (
+	defaultPrefetchWindow = 10
+	defaultParseWorkers   = 4
+)
+
+type prefetchedBlock struct {
+	parsedBlock data.ParsedBlock
+	err         error
+}
+
+// blockPrefetcher fetches and parses a window of upcoming blocks concurrently,
+// the results are handed out strictly in height order.
+// With local input resolution a block can only be parsed after the cota cells of its predecessors are indexed,
+// so the blocks are only fetched ahead and parsed by complete right before they are committed.
+type blockPrefetcher struct {
+	client        *data.CkbNodeClient
+	blockSyncer   data.BlockSyncer
+	systemScripts data.SystemScripts
+	window        uint64
+	workers       int
+	deferParse    bool
+}
+
+func newBlockPrefetcher(client *data.CkbNodeClient, blockSyncer data.BlockSyncer, systemScripts data.SystemScripts, appConf *config.App) blockPrefetcher {
+	window, workers := appConf.PrefetchWindow, appConf.ParseWorkers
+	if window < 1 {
+		window = defaultPrefetchWindow
+	}
+	if workers < 1 {
+		workers = defaultParseWorkers
+	}
+	return blockPrefetcher{
+		client:        client,
+		blockSyncer:   blockSyncer,
+		systemScripts: systemScripts,
+		window:        uint64(window),
+		workers:       workers,
+		deferParse:    appConf.InputResolution == config.LocalInputResolution,
+	}
+}
+
+// prefetch starts fetching the blocks in [from, min(to, from+window-1)] and returns one channel per block.
+// Every channel receives exactly one result, so abandoned channels never block the fetching goroutines.
+func (p blockPrefetcher) prefetch(ctx context.Context, from, to uint64) []chan prefetchedBlock {
+	if to-from+1 > p.window {
+		to = from + p.window - 1
+	}
+	results := make([]chan prefetchedBlock, to-from+1)
+	for i := range results {
+		result := make(chan prefetchedBlock, 1)
+		results[i] = result
+		blockNumber := from + uint64(i)
+		go func() {
+			parsedBlock, err := p.fetch(ctx, blockNumber)
+			result <- prefetchedBlock{parsedBlock: parsedBlock, err: err}
+		}()
+	}
+	return results
+}
+
+func (p blockPrefetcher) fetch(ctx context.Context, blockNumber uint64) (data.ParsedBlock, error) {
+	block, err := p.client.Rpc.GetBlockByNumber(ctx, blockNumber)
+	if err != nil {
+		return data.ParsedBlock{}, err
+	}
+	if p.deferParse {
+		return data.ParsedBlock{Block: block}, nil
+	}
+	return p.blockSyncer.Parse(ctx, block, p.systemScripts, p.workers)
+}
+
+// complete parses a block whose parsing was deferred
+func (p blockPrefetcher) complete(ctx context.Context, parsedBlock data.ParsedBlock) (data.ParsedBlock, error) {
+	if !p.deferParse {
+		return parsedBlock, nil
+	}
+	return p.blockSyncer.Parse(ctx, parsedBlock.Block, p.systemScripts, p.workers)
+}
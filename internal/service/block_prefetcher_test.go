@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+type chain func(blockNumber uint64) ckbTypes.Hash
+
+func mainChain(blockNumber uint64) ckbTypes.Hash {
+	return chainHash(blockNumber, false)
+}
+
+// forkedChain branches off the main chain at block fork, marker tells the blocks of different forks apart
+func forkedChain(marker string, fork uint64) chain {
+	return func(blockNumber uint64) ckbTypes.Hash {
+		if blockNumber < fork {
+			return mainChain(blockNumber)
+		}
+		return ckbTypes.HexToHash(fmt.Sprintf("0x%s%062x", marker, blockNumber))
+	}
+}
+
+// switchingNode serves every block from the chain picked by serving, so the node can switch chains within a
+// prefetch window. With reversed a block is only returned after the block above it, the workers finish last first.
+type switchingNode struct {
+	rpc.Client
+	mu        sync.Mutex
+	canonical chain
+	serving   func(blockNumber uint64) chain
+	reversed  bool
+	last      uint64
+	done      map[uint64]chan struct{}
+	returned  []uint64
+}
+
+func (n *switchingNode) doneChan(blockNumber uint64) chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.done == nil {
+		n.done = make(map[uint64]chan struct{})
+	}
+	if _, ok := n.done[blockNumber]; !ok {
+		n.done[blockNumber] = make(chan struct{})
+	}
+	return n.done[blockNumber]
+}
+
+// serve switches the chain the blocks are served from, the abandoned fetches of an earlier window may still run
+func (n *switchingNode) serve(serving func(blockNumber uint64) chain) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.serving = serving
+}
+
+func (n *switchingNode) GetBlockHash(_ context.Context, blockNumber uint64) (*ckbTypes.Hash, error) {
+	hash := n.canonical(blockNumber)
+	return &hash, nil
+}
+
+func (n *switchingNode) GetBlockByNumber(_ context.Context, blockNumber uint64) (*ckbTypes.Block, error) {
+	if n.reversed {
+		if blockNumber < n.last {
+			<-n.doneChan(blockNumber + 1)
+		}
+		defer close(n.doneChan(blockNumber))
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.returned = append(n.returned, blockNumber)
+	served := n.serving(blockNumber)
+	return &ckbTypes.Block{Header: &ckbTypes.Header{
+		Number:     blockNumber,
+		Hash:       served(blockNumber),
+		ParentHash: served(blockNumber - 1),
+	}}, nil
+}
+
+// committedChain keeps the committed check infos, which double as the stored block headers, and rewinds them on a reorg
+type committedChain struct {
+	biz.KvPairRepo
+	committed []biz.CheckInfo
+}
+
+func (c *committedChain) save(_ context.Context, _ data.ParsedBlock, checkInfo biz.CheckInfo) error {
+	c.committed = append(c.committed, checkInfo)
+	return nil
+}
+
+func (c *committedChain) FindLatestBlockHeaders(_ context.Context, limit int) ([]biz.BlockHeader, error) {
+	var headers []biz.BlockHeader
+	for i := len(c.committed) - 1; i >= 0 && len(headers) < limit; i-- {
+		headers = append(headers, biz.BlockHeader{BlockNumber: c.committed[i].BlockNumber, BlockHash: c.committed[i].BlockHash})
+	}
+	return headers, nil
+}
+
+func (c *committedChain) CleanBlockHeaders(context.Context, uint64) error {
+	return nil
+}
+
+func (c *committedChain) RewindKvPairs(_ context.Context, ancestor biz.CheckInfo) error {
+	for len(c.committed) > 0 && c.committed[len(c.committed)-1].BlockNumber > ancestor.BlockNumber {
+		c.committed = c.committed[:len(c.committed)-1]
+	}
+	return nil
+}
+
+func (c *committedChain) blocks() []biz.CheckInfo {
+	return append([]biz.CheckInfo(nil), c.committed...)
+}
+
+func checkInfosOf(c chain, from, to uint64) []biz.CheckInfo {
+	var checkInfos []biz.CheckInfo
+	for blockNumber := from; blockNumber <= to; blockNumber++ {
+		checkInfos = append(checkInfos, biz.CheckInfo{BlockNumber: blockNumber, BlockHash: c(blockNumber).String()[2:], CheckType: biz.SyncBlock})
+	}
+	return checkInfos
+}
+
+func newTestPrefetcher(node rpc.Client, appConf *config.App, log *logger.Logger) (*data.CkbNodeClient, blockPrefetcher) {
+	appConf.InputCacheSize = 10
+	client := &data.CkbNodeClient{Rpc: node}
+	blockSyncer := data.NewBlockSyncer(data.NewRegistryParser(client, appConf), data.NewCotaWitnessArgsParser(client, nil, appConf), nil,
+		biz.NewEntryHandlerRegistry(nil, nil, nil, nil, nil, nil), appConf, log)
+	return client, newBlockPrefetcher(client, blockSyncer, data.SystemScripts{}, appConf)
+}
+
+func TestCommitPrefetched_inOrder(t *testing.T) {
+	tests := []struct {
+		name            string
+		inputResolution string
+	}{
+		{
+			name:            "should commit in height order when the workers finish last first",
+			inputResolution: config.RpcInputResolution,
+		},
+		{
+			name:            "should commit in height order when the parsing is deferred",
+			inputResolution: config.LocalInputResolution,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(io.Discard, "", 0)
+			node := &switchingNode{canonical: mainChain, serving: func(uint64) chain { return mainChain }, reversed: true, last: 8}
+			client, prefetcher := newTestPrefetcher(node, &config.App{PrefetchWindow: 6, ParseWorkers: 2, InputResolution: tt.inputResolution}, log)
+			committed := &committedChain{}
+			reorganizer := data.NewChainReorganizer(client, biz.NewSyncKvPairUsecase(committed, log), biz.NewBlockHeaderUsecase(committed, log), &config.App{}, log)
+			checkInfo := checkInfosOf(mainChain, 2, 2)[0]
+
+			if idle := commitPrefetched(context.Background(), prefetcher, reorganizer, log, checkInfo, 8, committed.save); !idle {
+				t.Errorf("commitPrefetched() idle = false, want true")
+			}
+			if want := []uint64{8, 7, 6, 5, 4, 3}; !reflect.DeepEqual(node.returned, want) {
+				t.Fatalf("fetched blocks returned in order %v, want %v", node.returned, want)
+			}
+			if want := checkInfosOf(mainChain, 3, 8); !reflect.DeepEqual(committed.blocks(), want) {
+				t.Errorf("committed = %v, want %v", committed.blocks(), want)
+			}
+		})
+	}
+}
+
+func TestCommitPrefetched_reorg(t *testing.T) {
+	log := logger.NewLogger(io.Discard, "", 0)
+	// the node switches from the main chain to fork a within the first window and then settles on fork b,
+	// both fork off at block 4
+	forkA, forkB := forkedChain("aa", 4), forkedChain("bb", 4)
+	node := &switchingNode{canonical: forkB, serving: func(blockNumber uint64) chain {
+		if blockNumber < 5 {
+			return mainChain
+		}
+		return forkA
+	}}
+	client, prefetcher := newTestPrefetcher(node, &config.App{PrefetchWindow: 6, ParseWorkers: 2}, log)
+	committed := &committedChain{committed: checkInfosOf(mainChain, 1, 2)}
+	reorganizer := data.NewChainReorganizer(client, biz.NewSyncKvPairUsecase(committed, log), biz.NewBlockHeaderUsecase(committed, log), &config.App{}, log)
+	ctx := context.Background()
+
+	if idle := commitPrefetched(ctx, prefetcher, reorganizer, log, committed.blocks()[1], 8, committed.save); idle {
+		t.Errorf("commitPrefetched() idle = true at the fork, want false")
+	}
+	// block 5 of fork a does not extend block 4 of the main chain, which is rewound with the blocks of fork a left in the window
+	if want := checkInfosOf(mainChain, 1, 3); !reflect.DeepEqual(committed.blocks(), want) {
+		t.Fatalf("committed after the reorg = %v, want %v", committed.blocks(), want)
+	}
+
+	node.serve(func(uint64) chain { return forkB })
+	if idle := commitPrefetched(ctx, prefetcher, reorganizer, log, committed.blocks()[2], 8, committed.save); !idle {
+		t.Errorf("commitPrefetched() idle = false after the reorg, want true")
+	}
+	if want := append(checkInfosOf(mainChain, 1, 3), checkInfosOf(forkB, 4, 8)...); !reflect.DeepEqual(committed.blocks(), want) {
+		t.Errorf("committed = %v, want %v", committed.blocks(), want)
+	}
+}
@@ -12,16 +12,22 @@ import (
 var _ Service = (*CheckInfoCleanerService)(nil)
 
 type CheckInfoCleanerService struct {
-	checkInfoUsecase *biz.CheckInfoUsecase
-	logger           *logger.Logger
-	client           *data.CkbNodeClient
+	checkInfoUsecase   *biz.CheckInfoUsecase
+	blockHeaderUsecase *biz.BlockHeaderUsecase
+	cotaCellUsecase    *biz.CotaCellUsecase
+	reorganizer        *data.ChainReorganizer
+	logger             *logger.Logger
+	client             *data.CkbNodeClient
 }
 
-func NewCheckInfoService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logger.Logger, client *data.CkbNodeClient) *CheckInfoCleanerService {
+func NewCheckInfoService(checkInfoUsecase *biz.CheckInfoUsecase, blockHeaderUsecase *biz.BlockHeaderUsecase, cotaCellUsecase *biz.CotaCellUsecase, reorganizer *data.ChainReorganizer, logger *logger.Logger, client *data.CkbNodeClient) *CheckInfoCleanerService {
 	return &CheckInfoCleanerService{
-		checkInfoUsecase: checkInfoUsecase,
-		logger:           logger,
-		client:           client,
+		checkInfoUsecase:   checkInfoUsecase,
+		blockHeaderUsecase: blockHeaderUsecase,
+		cotaCellUsecase:    cotaCellUsecase,
+		reorganizer:        reorganizer,
+		logger:             logger,
+		client:             client,
 	}
 }
 
@@ -29,6 +35,24 @@ func (scv CheckInfoCleanerService) clean(ctx context.Context, checkType biz.Chec
 	return scv.checkInfoUsecase.Clean(ctx, checkType)
 }
 
+// cleanCotaCells removes the cota cells consumed deeper than the reorg depth below both check infos
+func (scv CheckInfoCleanerService) cleanCotaCells(ctx context.Context) error {
+	blockNumber := uint64(0)
+	for _, checkType := range []biz.CheckType{biz.SyncBlock, biz.SyncMetadata} {
+		checkInfo := biz.CheckInfo{CheckType: checkType}
+		if err := scv.checkInfoUsecase.LastCheckInfo(ctx, &checkInfo); err != nil {
+			return err
+		}
+		if blockNumber == 0 || checkInfo.BlockNumber < blockNumber {
+			blockNumber = checkInfo.BlockNumber
+		}
+	}
+	if blockNumber <= scv.reorganizer.MaxDepth() {
+		return nil
+	}
+	return scv.cotaCellUsecase.Clean(ctx, blockNumber-scv.reorganizer.MaxDepth())
+}
+
 func (scv CheckInfoCleanerService) Start(ctx context.Context, mode string) error {
 	scv.logger.Info(ctx, "Successfully started the check info cleaner~")
 	go func() {
@@ -45,6 +69,12 @@ func (scv CheckInfoCleanerService) Start(ctx context.Context, mode string) error
 						return scv.clean(ctx, cType)
 					})
 				}
+				eg.Go(func() error {
+					return scv.blockHeaderUsecase.Clean(ctx, scv.reorganizer.MaxDepth())
+				})
+				eg.Go(func() error {
+					return scv.cleanCotaCells(ctx)
+				})
 				if err := eg.Wait(); err != nil {
 					scv.logger.Errorf(ctx, "clean check info failed, %v", err)
 				}
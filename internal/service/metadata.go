@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
@@ -16,9 +17,15 @@ type MetadataSyncService struct {
 	status           chan struct{}
 	systemScripts    data.SystemScripts
 	metadataSyncer   data.MetadataSyncer
+	reorganizer      *data.ChainReorganizer
+	subscriber       *data.TipSubscriber
+	sparseScanner    *data.SparseScanner
+	confirmations    uint64
+	enabled          bool
+	localInputs      bool
 }
 
-func NewMetadataSyncService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logger.Logger, client *data.CkbNodeClient, systemScripts data.SystemScripts, metadataSyncer data.MetadataSyncer) *MetadataSyncService {
+func NewMetadataSyncService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logger.Logger, client *data.CkbNodeClient, systemScripts data.SystemScripts, metadataSyncer data.MetadataSyncer, reorganizer *data.ChainReorganizer, subscriber *data.TipSubscriber, sparseScanner *data.SparseScanner, appConf *config.App) *MetadataSyncService {
 	return &MetadataSyncService{
 		checkInfoUsecase: checkInfoUsecase,
 		logger:           logger,
@@ -26,11 +33,21 @@ func NewMetadataSyncService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logg
 		status:           make(chan struct{}, 1),
 		systemScripts:    systemScripts,
 		metadataSyncer:   metadataSyncer,
+		reorganizer:      reorganizer,
+		subscriber:       subscriber,
+		sparseScanner:    sparseScanner,
+		confirmations:    appConf.Confirmations,
+		enabled:          appConf.SyncMode != config.UnifiedSyncMode,
+		localInputs:      appConf.InputResolution == config.LocalInputResolution,
 	}
 }
 
 func (s *MetadataSyncService) Start(ctx context.Context, mode string) error {
+	if !s.enabled {
+		return nil
+	}
 	s.logger.Info(ctx, "Successfully started the sync service~")
+	waiter := newTipWaiter(ctx, s.subscriber, s.client.Breaker)
 	go func() {
 		for {
 			select {
@@ -39,10 +56,7 @@ func (s *MetadataSyncService) Start(ctx context.Context, mode string) error {
 				s.logger.Infof(ctx, "receive cancel signal %v", ctx.Err())
 				return
 			default:
-				s.sync(ctx)
-				if mode == "normal" {
-					time.Sleep(1 * time.Second)
-				}
+				waiter.wait(ctx, mode, s.sync(ctx))
 			}
 		}
 	}()
@@ -50,6 +64,9 @@ func (s *MetadataSyncService) Start(ctx context.Context, mode string) error {
 }
 
 func (s *MetadataSyncService) Stop(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
 	s.client.Rpc.Close()
 	for {
 		select {
@@ -62,7 +79,8 @@ func (s *MetadataSyncService) Stop(ctx context.Context) error {
 	}
 }
 
-func (s *MetadataSyncService) sync(ctx context.Context) {
+// sync reports whether the loop is idle, see commitPrefetched
+func (s *MetadataSyncService) sync(ctx context.Context) bool {
 	checkInfo := biz.CheckInfo{CheckType: biz.SyncMetadata}
 	err := s.checkInfoUsecase.LastCheckInfo(ctx, &checkInfo)
 	if err != nil {
@@ -72,27 +90,39 @@ func (s *MetadataSyncService) sync(ctx context.Context) {
 	if err != nil {
 		s.logger.Errorf(ctx, "get tip block number rpc error: %v", err)
 	}
+	tipBlockNumber = confirmedBlockNumber(tipBlockNumber, s.confirmations)
+	// inputs resolved from the cota cell index are only known up to the last block synced by BlockSyncService
+	if s.localInputs {
+		blockCheckInfo := biz.CheckInfo{CheckType: biz.SyncBlock}
+		if err = s.checkInfoUsecase.LastCheckInfo(ctx, &blockCheckInfo); err != nil {
+			s.logger.Errorf(ctx, "get %s check info error: %v", blockCheckInfo.CheckType.String(), err)
+			return true
+		}
+		if blockCheckInfo.BlockNumber < tipBlockNumber {
+			tipBlockNumber = blockCheckInfo.BlockNumber
+		}
+	}
 	s.logger.Infof(ctx, "check tip block number: %v, tip block number: %v", checkInfo.BlockNumber, tipBlockNumber)
-	if checkInfo.BlockNumber > tipBlockNumber {
-		return
+	if checkInfo.BlockNumber >= tipBlockNumber {
+		return true
 	}
-	targetBlockNumber := checkInfo.BlockNumber + 1
-	if targetBlockNumber > tipBlockNumber {
-		return
+	// the metadata only comes from cota transactions, registry transactions can be skipped
+	checkInfo, _, err = s.sparseScanner.Skip(ctx, checkInfo, tipBlockNumber, s.systemScripts.CotaType, biz.SyncMetadata)
+	if err != nil {
+		s.logger.Errorf(ctx, "skip %s blocks error: %v", checkInfo.CheckType.String(), err)
+		return true
 	}
+	targetBlockNumber := checkInfo.BlockNumber + 1
 	targetBlock, err := s.client.Rpc.GetBlockByNumber(ctx, targetBlockNumber)
 	if err != nil {
 		s.logger.Errorf(ctx, "get block %d rpc error: %v", targetBlockNumber, err)
-		return
+		return true
 	}
 	// rollback
 	if isForked(checkInfo, targetBlock) {
 		s.logger.Info(ctx, "forked")
-		err = s.rollback(ctx, checkInfo.BlockNumber)
-		if err != nil {
-			s.logger.Errorf(ctx, "rollback %s error: %v", checkInfo.CheckType.String(), err)
-		}
-		return
+		reorg(ctx, s.reorganizer, s.logger, checkInfo)
+		return false
 	}
 	// save key pairs
 	checkInfo.BlockNumber = targetBlockNumber
@@ -100,13 +130,11 @@ func (s *MetadataSyncService) sync(ctx context.Context) {
 	err = s.syncMetadata(ctx, targetBlock, checkInfo)
 	if err != nil {
 		s.logger.Errorf(ctx, "save %s kv pairs error: %v", checkInfo.CheckType.String(), err)
+		return true
 	}
+	return targetBlockNumber >= tipBlockNumber
 }
 
 func (s *MetadataSyncService) syncMetadata(ctx context.Context, block *ckbTypes.Block, checkInfo biz.CheckInfo) error {
 	return s.metadataSyncer.Sync(ctx, block, checkInfo, s.systemScripts)
 }
-
-func (s *MetadataSyncService) rollback(ctx context.Context, blockNumber uint64) error {
-	return s.metadataSyncer.Rollback(ctx, blockNumber)
-}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+var _ Service = (*MetricsService)(nil)
+
+// MetricsService serves the expvar metrics at /debug/vars when metrics_addr is configured
+type MetricsService struct {
+	logger *logger.Logger
+	server *http.Server
+}
+
+func NewMetricsService(logger *logger.Logger, appConf *config.App) *MetricsService {
+	s := &MetricsService{logger: logger}
+	if appConf.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/vars", expvar.Handler())
+		s.server = &http.Server{Addr: appConf.MetricsAddr, Handler: mux}
+	}
+	return s
+}
+
+func (s *MetricsService) Start(ctx context.Context, _ string) error {
+	if s.server == nil {
+		return nil
+	}
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorf(ctx, "metrics server error: %v", err)
+		}
+	}()
+	s.logger.Infof(ctx, "Successfully started the metrics service at %s~", s.server.Addr)
+	return nil
+}
+
+func (s *MetricsService) Stop(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
@@ -2,15 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/google/wire"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
 	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
 	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
 	"time"
 )
 
-var ProviderSet = wire.NewSet(NewBlockSyncService, NewCheckInfoService, NewMetadataSyncService, NewInvalidDataService)
+var ProviderSet = wire.NewSet(NewBlockSyncService, NewCheckInfoService, NewMetadataSyncService, NewInvalidDataService, NewUnconfirmedOverlayService,
+	NewUnifiedSyncService, NewMetricsService)
 
 type BlockSyncService struct {
 	checkInfoUsecase *biz.CheckInfoUsecase
@@ -19,10 +22,20 @@ type BlockSyncService struct {
 	status           chan struct{}
 	systemScripts    data.SystemScripts
 	blockSyncer      data.BlockSyncer
+	prefetcher       blockPrefetcher
+	reorganizer      *data.ChainReorganizer
+	subscriber       *data.TipSubscriber
+	sparseScanner    *data.SparseScanner
+	confirmations    uint64
+	enabled          bool
 }
 
 func (s *BlockSyncService) Start(ctx context.Context, mode string) error {
+	if !s.enabled {
+		return nil
+	}
 	s.logger.Info(ctx, "Successfully started the sync service~")
+	waiter := newTipWaiter(ctx, s.subscriber, s.client.Breaker)
 	go func() {
 		for {
 			select {
@@ -31,17 +44,15 @@ func (s *BlockSyncService) Start(ctx context.Context, mode string) error {
 				s.logger.Infof(ctx, "receive cancel signal %v", ctx.Err())
 				return
 			default:
-				s.sync(ctx)
-				if mode == "normal" {
-					time.Sleep(1 * time.Second)
-				}
+				waiter.wait(ctx, mode, s.sync(ctx))
 			}
 		}
 	}()
 	return nil
 }
 
-func (s *BlockSyncService) sync(ctx context.Context) {
+// sync commits the next blocks and reports whether the loop is idle, that is caught up with the tip or failed
+func (s *BlockSyncService) sync(ctx context.Context) bool {
 	checkInfo := biz.CheckInfo{CheckType: biz.SyncBlock}
 	err := s.checkInfoUsecase.LastCheckInfo(ctx, &checkInfo)
 	if err != nil {
@@ -51,35 +62,70 @@ func (s *BlockSyncService) sync(ctx context.Context) {
 	if err != nil {
 		s.logger.Errorf(ctx, "get tip block number rpc error: %v", err)
 	}
+	tipBlockNumber = confirmedBlockNumber(tipBlockNumber, s.confirmations)
 	s.logger.Infof(ctx, "check tip block number: %v, tip block number: %v", checkInfo.BlockNumber, tipBlockNumber)
-	if checkInfo.BlockNumber > tipBlockNumber {
-		return
-	}
-	targetBlockNumber := checkInfo.BlockNumber + 1
-	if targetBlockNumber > tipBlockNumber {
-		return
+	if checkInfo.BlockNumber >= tipBlockNumber {
+		return true
 	}
-	targetBlock, err := s.client.Rpc.GetBlockByNumber(ctx, targetBlockNumber)
+	scripts := s.systemScripts.All()
+	checkInfo, commitBlockNumber, err := s.sparseScanner.Skip(ctx, checkInfo, tipBlockNumber, scripts, biz.SyncBlock)
 	if err != nil {
-		s.logger.Errorf(ctx, "get block %d rpc error: %v", targetBlockNumber, err)
-		return
+		s.logger.Errorf(ctx, "skip %s blocks error: %v", checkInfo.CheckType.String(), err)
+		return true
 	}
-	// rollback
-	if isForked(checkInfo, targetBlock) {
-		s.logger.Info(ctx, "forked")
-		err = s.rollback(ctx, checkInfo.BlockNumber)
+	idle := commitPrefetched(ctx, s.prefetcher, s.reorganizer, s.logger, checkInfo, commitBlockNumber, s.saveBlock)
+	return idle && commitBlockNumber == tipBlockNumber
+}
+
+// commitPrefetched commits the prefetched blocks following checkInfo in height order, it stops at the first fork or error.
+// It reports whether the loop is idle: all blocks up to the tip are committed or an error occurred.
+func commitPrefetched(ctx context.Context, prefetcher blockPrefetcher, reorganizer *data.ChainReorganizer, logger *logger.Logger,
+	checkInfo biz.CheckInfo, tipBlockNumber uint64, save func(context.Context, data.ParsedBlock, biz.CheckInfo) error) bool {
+	targetBlockNumber := checkInfo.BlockNumber + 1
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, result := range prefetcher.prefetch(ctx, targetBlockNumber, tipBlockNumber) {
+		var prefetched prefetchedBlock
+		select {
+		case <-ctx.Done():
+			return true
+		case prefetched = <-result:
+		}
+		if prefetched.err != nil {
+			logger.Errorf(ctx, "get block %d error: %v", targetBlockNumber, prefetched.err)
+			return true
+		}
+		targetBlock := prefetched.parsedBlock.Block
+		// rollback
+		if isForked(checkInfo, targetBlock) {
+			logger.Info(ctx, "forked")
+			reorg(ctx, reorganizer, logger, checkInfo)
+			return false
+		}
+		parsedBlock, err := prefetcher.complete(ctx, prefetched.parsedBlock)
 		if err != nil {
-			s.logger.Errorf(ctx, "rollback %s error: %v", checkInfo.CheckType.String(), err)
+			logger.Errorf(ctx, "parse block %d error: %v", targetBlockNumber, err)
+			return true
 		}
-		return
+		// save key pairs
+		checkInfo.BlockNumber = targetBlockNumber
+		checkInfo.BlockHash = targetBlock.Header.Hash.String()[2:]
+		err = save(ctx, parsedBlock, checkInfo)
+		if err != nil {
+			logger.Errorf(ctx, "save %s kv pairs error: %v", checkInfo.CheckType.String(), err)
+			return true
+		}
+		targetBlockNumber++
 	}
-	// save key pairs
-	checkInfo.BlockNumber = targetBlockNumber
-	checkInfo.BlockHash = targetBlock.Header.Hash.String()[2:]
-	err = s.syncBlock(ctx, targetBlock, checkInfo)
-	if err != nil {
-		s.logger.Errorf(ctx, "save %s kv pairs error: %v", checkInfo.CheckType.String(), err)
+	return targetBlockNumber > tipBlockNumber
+}
+
+// confirmedBlockNumber returns the highest block with at least confirmations blocks on top of it
+func confirmedBlockNumber(tipBlockNumber, confirmations uint64) uint64 {
+	if tipBlockNumber < confirmations {
+		return 0
 	}
+	return tipBlockNumber - confirmations
 }
 
 func isForked(checkInfo biz.CheckInfo, targetBlock *ckbTypes.Block) bool {
@@ -89,15 +135,25 @@ func isForked(checkInfo biz.CheckInfo, targetBlock *ckbTypes.Block) bool {
 	return checkInfo.BlockHash != targetBlock.Header.ParentHash.String()[2:]
 }
 
-func (s *BlockSyncService) syncBlock(ctx context.Context, block *ckbTypes.Block, checkInfo biz.CheckInfo) error {
-	return s.blockSyncer.Sync(ctx, block, checkInfo, s.systemScripts)
+func (s *BlockSyncService) saveBlock(ctx context.Context, parsedBlock data.ParsedBlock, checkInfo biz.CheckInfo) error {
+	return s.blockSyncer.Save(ctx, parsedBlock, checkInfo)
 }
 
-func (s *BlockSyncService) rollback(ctx context.Context, blockNumber uint64) error {
-	return s.blockSyncer.Rollback(ctx, blockNumber)
+// reorg rolls back the orphaned blocks and halts the syncer if the reorg is deeper than the stored header chain
+func reorg(ctx context.Context, reorganizer *data.ChainReorganizer, logger *logger.Logger, checkInfo biz.CheckInfo) {
+	err := reorganizer.Reorg(ctx, checkInfo)
+	if errors.Is(err, biz.ErrReorgTooDeep) {
+		logger.Fatalf(ctx, "reorg %s at block %d error: %v", checkInfo.CheckType.String(), checkInfo.BlockNumber, err)
+	}
+	if err != nil {
+		logger.Errorf(ctx, "reorg %s error: %v", checkInfo.CheckType.String(), err)
+	}
 }
 
 func (s *BlockSyncService) Stop(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
 	s.client.Rpc.Close()
 	for {
 		select {
@@ -110,7 +166,7 @@ func (s *BlockSyncService) Stop(ctx context.Context) error {
 	}
 }
 
-func NewBlockSyncService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logger.Logger, client *data.CkbNodeClient, systemScripts data.SystemScripts, blockSyncer data.BlockSyncer) *BlockSyncService {
+func NewBlockSyncService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logger.Logger, client *data.CkbNodeClient, systemScripts data.SystemScripts, blockSyncer data.BlockSyncer, reorganizer *data.ChainReorganizer, subscriber *data.TipSubscriber, sparseScanner *data.SparseScanner, appConf *config.App) *BlockSyncService {
 	return &BlockSyncService{
 		checkInfoUsecase: checkInfoUsecase,
 		logger:           logger,
@@ -118,6 +174,12 @@ func NewBlockSyncService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logger.
 		status:           make(chan struct{}, 1),
 		systemScripts:    systemScripts,
 		blockSyncer:      blockSyncer,
+		prefetcher:       newBlockPrefetcher(client, blockSyncer, systemScripts, appConf),
+		reorganizer:      reorganizer,
+		subscriber:       subscriber,
+		sparseScanner:    sparseScanner,
+		confirmations:    appConf.Confirmations,
+		enabled:          appConf.SyncMode != config.UnifiedSyncMode,
 	}
 }
 
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+)
+
+const (
+	// subscribedPollInterval re-checks the tip now and then in case a notification got lost
+	subscribedPollInterval = 30 * time.Second
+	minIdlePollInterval    = time.Second
+	maxIdlePollInterval    = 10 * time.Second
+)
+
+// tipWaiter pauses a sync loop between two rounds. Without a subscription it keeps the original behaviour:
+// one second in normal mode and no pause in wild mode. With a subscription an idle loop sleeps until the next
+// tip arrives, and while the subscription is down it polls with a growing interval. While the rpc circuit breaker
+// is open the loop pauses until the breaker lets a probe call through.
+type tipWaiter struct {
+	subscriber *data.TipSubscriber
+	breaker    *data.CircuitBreaker
+	tips       <-chan uint64
+	interval   time.Duration
+}
+
+func newTipWaiter(ctx context.Context, subscriber *data.TipSubscriber, breaker *data.CircuitBreaker) *tipWaiter {
+	return &tipWaiter{
+		subscriber: subscriber,
+		breaker:    breaker,
+		tips:       subscriber.Subscribe(ctx),
+	}
+}
+
+// wait blocks until the next round should start, idle tells whether the last round caught up with the tip or failed
+func (w *tipWaiter) wait(ctx context.Context, mode string, idle bool) {
+	if remaining := w.breaker.Remaining(); remaining > 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(remaining):
+		}
+		return
+	}
+	if !w.subscriber.Enabled() {
+		if mode == "normal" {
+			time.Sleep(1 * time.Second)
+		}
+		return
+	}
+	if !idle {
+		w.interval = 0
+		return
+	}
+	timeout := subscribedPollInterval
+	if w.subscriber.Connected() {
+		w.interval = 0
+	} else {
+		if w.interval *= 2; w.interval < minIdlePollInterval {
+			w.interval = minIdlePollInterval
+		}
+		if w.interval > maxIdlePollInterval {
+			w.interval = maxIdlePollInterval
+		}
+		timeout = w.interval
+	}
+	select {
+	case <-ctx.Done():
+	case <-w.tips:
+	case <-time.After(timeout):
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+var _ Service = (*UnconfirmedOverlayService)(nil)
+
+// UnconfirmedOverlayService records the kv pairs of the blocks that are not deep enough for the final tables,
+// consumers can merge them with the final tables to get an optimistic view
+type UnconfirmedOverlayService struct {
+	checkInfoUsecase   *biz.CheckInfoUsecase
+	unconfirmedUsecase *biz.UnconfirmedKvPairUsecase
+	logger             *logger.Logger
+	client             *data.CkbNodeClient
+	status             chan struct{}
+	systemScripts      data.SystemScripts
+	blockSyncer        data.BlockSyncer
+	metadataSyncer     data.MetadataSyncer
+	subscriber         *data.TipSubscriber
+	confirmations      uint64
+	enabled            bool
+}
+
+func NewUnconfirmedOverlayService(checkInfoUsecase *biz.CheckInfoUsecase, unconfirmedUsecase *biz.UnconfirmedKvPairUsecase, logger *logger.Logger,
+	client *data.CkbNodeClient, systemScripts data.SystemScripts, blockSyncer data.BlockSyncer, metadataSyncer data.MetadataSyncer, subscriber *data.TipSubscriber, appConf *config.App) *UnconfirmedOverlayService {
+	return &UnconfirmedOverlayService{
+		checkInfoUsecase:   checkInfoUsecase,
+		unconfirmedUsecase: unconfirmedUsecase,
+		logger:             logger,
+		client:             client,
+		status:             make(chan struct{}, 1),
+		systemScripts:      systemScripts,
+		blockSyncer:        blockSyncer,
+		metadataSyncer:     metadataSyncer,
+		subscriber:         subscriber,
+		confirmations:      appConf.Confirmations,
+		enabled:            appConf.UnconfirmedOverlay && appConf.Confirmations > 0,
+	}
+}
+
+func (s *UnconfirmedOverlayService) Start(ctx context.Context, mode string) error {
+	if !s.enabled {
+		return nil
+	}
+	s.logger.Info(ctx, "Successfully started the unconfirmed overlay service~")
+	waiter := newTipWaiter(ctx, s.subscriber, s.client.Breaker)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				s.status <- struct{}{}
+				s.logger.Infof(ctx, "receive cancel signal %v", ctx.Err())
+				return
+			default:
+				waiter.wait(ctx, mode, s.sync(ctx))
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *UnconfirmedOverlayService) Stop(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	for {
+		select {
+		case <-s.status:
+			s.logger.Info(ctx, "Successfully closed the unconfirmed overlay service~")
+			return nil
+		default:
+			time.Sleep(1 * time.Second)
+		}
+	}
+}
+
+// sync reports whether the loop is idle, that is the overlay reached the tip or failed
+func (s *UnconfirmedOverlayService) sync(ctx context.Context) bool {
+	checkInfo := biz.CheckInfo{CheckType: biz.SyncBlock}
+	err := s.checkInfoUsecase.LastCheckInfo(ctx, &checkInfo)
+	if err != nil {
+		s.logger.Errorf(ctx, "get %s check info error: %v", checkInfo.CheckType.String(), err)
+		return true
+	}
+	tipBlockNumber, err := s.client.Rpc.GetTipBlockNumber(ctx)
+	if err != nil {
+		s.logger.Errorf(ctx, "get tip block number rpc error: %v", err)
+		return true
+	}
+	// the blocks stored in the final tables leave the overlay
+	if err = s.unconfirmedUsecase.Confirm(ctx, checkInfo.BlockNumber); err != nil {
+		s.logger.Errorf(ctx, "confirm unconfirmed blocks error: %v", err)
+		return true
+	}
+	blocks, err := s.unconfirmedUsecase.UnconfirmedBlocks(ctx)
+	if err != nil {
+		s.logger.Errorf(ctx, "get unconfirmed blocks error: %v", err)
+		return true
+	}
+	// without recorded blocks the overlay starts right above the confirmed blocks,
+	// otherwise it continues after the last recorded block so that it never has gaps
+	lastBlockNumber, lastBlockHash := checkInfo.BlockNumber, checkInfo.BlockHash
+	if confirmed := confirmedBlockNumber(tipBlockNumber, s.confirmations); confirmed > lastBlockNumber {
+		lastBlockNumber, lastBlockHash = confirmed, ""
+	}
+	for _, block := range blocks {
+		blockHash, err := s.client.Rpc.GetBlockHash(ctx, block.BlockNumber)
+		if err != nil {
+			s.logger.Errorf(ctx, "get block hash %d rpc error: %v", block.BlockNumber, err)
+			return true
+		}
+		if blockHash.String()[2:] != block.BlockHash {
+			s.logger.Infof(ctx, "unconfirmed block %d forked", block.BlockNumber)
+			if err = s.unconfirmedUsecase.Rollback(ctx, block.BlockNumber); err != nil {
+				s.logger.Errorf(ctx, "rollback unconfirmed blocks error: %v", err)
+				return true
+			}
+			break
+		}
+		lastBlockNumber, lastBlockHash = block.BlockNumber, block.BlockHash
+	}
+	for blockNumber := lastBlockNumber + 1; blockNumber <= tipBlockNumber; blockNumber++ {
+		block, err := s.client.Rpc.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			s.logger.Errorf(ctx, "get block %d rpc error: %v", blockNumber, err)
+			return true
+		}
+		// the chain changed while walking up, the next round detects the fork
+		if lastBlockHash != "" && block.Header.ParentHash.String()[2:] != lastBlockHash {
+			return false
+		}
+		parsedBlock, err := s.blockSyncer.Parse(ctx, block, s.systemScripts, 1)
+		if err != nil {
+			s.logger.Errorf(ctx, "parse unconfirmed block %d error: %v", blockNumber, err)
+			return true
+		}
+		pairs, err := s.blockSyncer.KvPairs(parsedBlock)
+		if err != nil {
+			s.logger.Errorf(ctx, "parse unconfirmed block %d error: %v", blockNumber, err)
+			return true
+		}
+		metadata, err := s.metadataSyncer.ParsedKvPairs(ctx, parsedBlock)
+		if err != nil {
+			s.logger.Errorf(ctx, "parse unconfirmed block %d error: %v", blockNumber, err)
+			return true
+		}
+		pairs.IssuerInfos = metadata.IssuerInfos
+		pairs.ClassInfos = metadata.ClassInfos
+		lastBlockHash = block.Header.Hash.String()[2:]
+		err = s.unconfirmedUsecase.Create(ctx, biz.UnconfirmedBlock{BlockNumber: blockNumber, BlockHash: lastBlockHash}, &pairs)
+		if err != nil {
+			s.logger.Errorf(ctx, "save unconfirmed block %d error: %v", blockNumber, err)
+			return true
+		}
+	}
+	return true
+}
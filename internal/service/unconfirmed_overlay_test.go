@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbTypes "github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// chainHash is the hash of a block of the chain, a forked block has another one
+func chainHash(blockNumber uint64, forked bool) ckbTypes.Hash {
+	if forked {
+		return ckbTypes.HexToHash(fmt.Sprintf("0xff%062x", blockNumber))
+	}
+	return ckbTypes.HexToHash(fmt.Sprintf("0x%064x", blockNumber))
+}
+
+// forkingNode serves empty blocks up to tip, the blocks from fork on are forked, zero means no fork
+type forkingNode struct {
+	rpc.Client
+	tip  uint64
+	fork uint64
+}
+
+func (n forkingNode) hash(blockNumber uint64) ckbTypes.Hash {
+	return chainHash(blockNumber, n.fork > 0 && blockNumber >= n.fork)
+}
+
+func (n forkingNode) GetTipBlockNumber(context.Context) (uint64, error) {
+	return n.tip, nil
+}
+
+func (n forkingNode) GetBlockHash(_ context.Context, blockNumber uint64) (*ckbTypes.Hash, error) {
+	hash := n.hash(blockNumber)
+	return &hash, nil
+}
+
+func (n forkingNode) GetBlockByNumber(_ context.Context, blockNumber uint64) (*ckbTypes.Block, error) {
+	return &ckbTypes.Block{Header: &ckbTypes.Header{
+		Number:     blockNumber,
+		Hash:       n.hash(blockNumber),
+		ParentHash: n.hash(blockNumber - 1),
+	}}, nil
+}
+
+// syncedCheckInfos returns the check info of the last block in the final tables
+type syncedCheckInfos struct {
+	biz.CheckInfoRepo
+	last biz.CheckInfo
+}
+
+func (r syncedCheckInfos) FindLastCheckInfo(_ context.Context, info *biz.CheckInfo) error {
+	info.BlockNumber, info.BlockHash = r.last.BlockNumber, r.last.BlockHash
+	return nil
+}
+
+// memUnconfirmedBlocks keeps the overlay blocks in memory ordered by block number
+type memUnconfirmedBlocks struct {
+	blocks []biz.UnconfirmedBlock
+}
+
+func (r *memUnconfirmedBlocks) FindUnconfirmedBlocks(context.Context) ([]biz.UnconfirmedBlock, error) {
+	return append([]biz.UnconfirmedBlock(nil), r.blocks...), nil
+}
+
+func (r *memUnconfirmedBlocks) CreateUnconfirmedKvPairs(_ context.Context, block biz.UnconfirmedBlock, _ *biz.KvPair) error {
+	r.blocks = append(r.blocks, block)
+	return nil
+}
+
+func (r *memUnconfirmedBlocks) DeleteConfirmedKvPairs(_ context.Context, blockNumber uint64) error {
+	return r.keep(func(block biz.UnconfirmedBlock) bool { return block.BlockNumber > blockNumber })
+}
+
+func (r *memUnconfirmedBlocks) DeleteOrphanedKvPairs(_ context.Context, blockNumber uint64) error {
+	return r.keep(func(block biz.UnconfirmedBlock) bool { return block.BlockNumber < blockNumber })
+}
+
+func (r *memUnconfirmedBlocks) keep(keep func(block biz.UnconfirmedBlock) bool) error {
+	var kept []biz.UnconfirmedBlock
+	for _, block := range r.blocks {
+		if keep(block) {
+			kept = append(kept, block)
+		}
+	}
+	r.blocks = kept
+	return nil
+}
+
+func unconfirmedBlocks(fork uint64, blockNumbers ...uint64) []biz.UnconfirmedBlock {
+	blocks := make([]biz.UnconfirmedBlock, len(blockNumbers))
+	for i, blockNumber := range blockNumbers {
+		blocks[i] = biz.UnconfirmedBlock{BlockNumber: blockNumber, BlockHash: chainHash(blockNumber, fork > 0 && blockNumber >= fork).String()[2:]}
+	}
+	return blocks
+}
+
+func TestUnconfirmedOverlayService_sync(t *testing.T) {
+	tests := []struct {
+		name      string
+		synced    uint64
+		recorded  []biz.UnconfirmedBlock
+		node      forkingNode
+		wantBlock []biz.UnconfirmedBlock
+	}{
+		{
+			name:      "should start right above the confirmation depth",
+			synced:    5,
+			node:      forkingNode{tip: 12},
+			wantBlock: unconfirmedBlocks(0, 10, 11, 12),
+		},
+		{
+			name:      "should drop the blocks promoted to the final tables and record the new tip",
+			synced:    9,
+			recorded:  unconfirmedBlocks(0, 8, 9, 10),
+			node:      forkingNode{tip: 12},
+			wantBlock: unconfirmedBlocks(0, 10, 11, 12),
+		},
+		{
+			name:      "should roll back the blocks above a fork below the confirmation depth",
+			synced:    9,
+			recorded:  unconfirmedBlocks(0, 10, 11, 12),
+			node:      forkingNode{tip: 12, fork: 11},
+			wantBlock: unconfirmedBlocks(11, 10, 11, 12),
+		},
+		{
+			name:      "should roll back every block when the fork reaches the first one",
+			synced:    9,
+			recorded:  unconfirmedBlocks(0, 10, 11),
+			node:      forkingNode{tip: 11, fork: 10},
+			wantBlock: unconfirmedBlocks(10, 10, 11),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(io.Discard, "", 0)
+			appConf := &config.App{UnconfirmedOverlay: true, Confirmations: 3, InputCacheSize: 10}
+			client := &data.CkbNodeClient{Rpc: tt.node}
+			parser := data.NewCotaWitnessArgsParser(client, nil, appConf)
+			repo := &memUnconfirmedBlocks{blocks: tt.recorded}
+			checkInfos := syncedCheckInfos{last: biz.CheckInfo{BlockNumber: tt.synced, BlockHash: chainHash(tt.synced, false).String()[2:]}}
+			s := NewUnconfirmedOverlayService(biz.NewCheckInfoUsecase(checkInfos, log), biz.NewUnconfirmedKvPairUsecase(repo, log), log, client,
+				data.SystemScripts{}, data.NewBlockSyncer(data.NewRegistryParser(client, appConf), parser, nil, biz.NewEntryHandlerRegistry(nil, nil, nil, nil, nil, nil), appConf, log),
+				data.NewMetadataSyncer(nil, parser, nil, nil, appConf, log), nil, appConf)
+			if idle := s.sync(context.Background()); !idle {
+				t.Fatalf("sync() idle = false, want true")
+			}
+			if !reflect.DeepEqual(repo.blocks, tt.wantBlock) {
+				t.Errorf("unconfirmed blocks = %v, want %v", repo.blocks, tt.wantBlock)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+var _ Service = (*UnifiedSyncService)(nil)
+
+// UnifiedSyncService fetches and parses every block once and commits the cota entries and the metadata together,
+// it replaces BlockSyncService and MetadataSyncService when sync_mode is unified
+type UnifiedSyncService struct {
+	checkInfoUsecase *biz.CheckInfoUsecase
+	logger           *logger.Logger
+	client           *data.CkbNodeClient
+	status           chan struct{}
+	systemScripts    data.SystemScripts
+	blockSyncer      data.BlockSyncer
+	metadataSyncer   data.MetadataSyncer
+	unifiedSyncer    data.UnifiedSyncer
+	prefetcher       blockPrefetcher
+	reorganizer      *data.ChainReorganizer
+	subscriber       *data.TipSubscriber
+	sparseScanner    *data.SparseScanner
+	confirmations    uint64
+	enabled          bool
+}
+
+func NewUnifiedSyncService(checkInfoUsecase *biz.CheckInfoUsecase, logger *logger.Logger, client *data.CkbNodeClient, systemScripts data.SystemScripts,
+	blockSyncer data.BlockSyncer, metadataSyncer data.MetadataSyncer, unifiedSyncer data.UnifiedSyncer, reorganizer *data.ChainReorganizer, subscriber *data.TipSubscriber, sparseScanner *data.SparseScanner, appConf *config.App) *UnifiedSyncService {
+	return &UnifiedSyncService{
+		checkInfoUsecase: checkInfoUsecase,
+		logger:           logger,
+		client:           client,
+		status:           make(chan struct{}, 1),
+		systemScripts:    systemScripts,
+		blockSyncer:      blockSyncer,
+		metadataSyncer:   metadataSyncer,
+		unifiedSyncer:    unifiedSyncer,
+		prefetcher:       newBlockPrefetcher(client, blockSyncer, systemScripts, appConf),
+		reorganizer:      reorganizer,
+		subscriber:       subscriber,
+		sparseScanner:    sparseScanner,
+		confirmations:    appConf.Confirmations,
+		enabled:          appConf.SyncMode == config.UnifiedSyncMode,
+	}
+}
+
+func (s *UnifiedSyncService) Start(ctx context.Context, mode string) error {
+	if !s.enabled {
+		return nil
+	}
+	s.logger.Info(ctx, "Successfully started the unified sync service~")
+	waiter := newTipWaiter(ctx, s.subscriber, s.client.Breaker)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				s.status <- struct{}{}
+				s.logger.Infof(ctx, "receive cancel signal %v", ctx.Err())
+				return
+			default:
+				waiter.wait(ctx, mode, s.sync(ctx))
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *UnifiedSyncService) Stop(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.client.Rpc.Close()
+	for {
+		select {
+		case <-s.status:
+			s.logger.Info(ctx, "Successfully closed the unified sync service~")
+			return nil
+		default:
+			time.Sleep(1 * time.Second)
+		}
+	}
+}
+
+// sync reports whether the loop is idle, see commitPrefetched
+func (s *UnifiedSyncService) sync(ctx context.Context) bool {
+	blockCheckInfo := biz.CheckInfo{CheckType: biz.SyncBlock}
+	err := s.checkInfoUsecase.LastCheckInfo(ctx, &blockCheckInfo)
+	if err != nil {
+		s.logger.Errorf(ctx, "get %s check info error: %v", blockCheckInfo.CheckType.String(), err)
+		return true
+	}
+	metadataCheckInfo := biz.CheckInfo{CheckType: biz.SyncMetadata}
+	err = s.checkInfoUsecase.LastCheckInfo(ctx, &metadataCheckInfo)
+	if err != nil {
+		s.logger.Errorf(ctx, "get %s check info error: %v", metadataCheckInfo.CheckType.String(), err)
+		return true
+	}
+	tipBlockNumber, err := s.client.Rpc.GetTipBlockNumber(ctx)
+	if err != nil {
+		s.logger.Errorf(ctx, "get tip block number rpc error: %v", err)
+		return true
+	}
+	tipBlockNumber = confirmedBlockNumber(tipBlockNumber, s.confirmations)
+	s.logger.Infof(ctx, "check tip block number: %v, tip block number: %v", blockCheckInfo.BlockNumber, tipBlockNumber)
+	// the cursors written by the separate services may have drifted apart, the lagging one catches up first
+	if blockCheckInfo.BlockNumber != metadataCheckInfo.BlockNumber {
+		return s.catchUp(ctx, blockCheckInfo, metadataCheckInfo, tipBlockNumber)
+	}
+	if blockCheckInfo.BlockNumber >= tipBlockNumber {
+		return true
+	}
+	scripts := s.systemScripts.All()
+	blockCheckInfo, commitBlockNumber, err := s.sparseScanner.Skip(ctx, blockCheckInfo, tipBlockNumber, scripts, biz.SyncBlock, biz.SyncMetadata)
+	if err != nil {
+		s.logger.Errorf(ctx, "skip blocks error: %v", err)
+		return true
+	}
+	idle := commitPrefetched(ctx, s.prefetcher, s.reorganizer, s.logger, blockCheckInfo, commitBlockNumber, s.unifiedSyncer.Save)
+	return idle && commitBlockNumber == tipBlockNumber
+}
+
+// catchUp syncs the next block of the lagging check type alone
+func (s *UnifiedSyncService) catchUp(ctx context.Context, blockCheckInfo, metadataCheckInfo biz.CheckInfo, tipBlockNumber uint64) bool {
+	checkInfo := blockCheckInfo
+	if metadataCheckInfo.BlockNumber < blockCheckInfo.BlockNumber {
+		checkInfo = metadataCheckInfo
+	}
+	targetBlockNumber := checkInfo.BlockNumber + 1
+	if targetBlockNumber > tipBlockNumber {
+		return true
+	}
+	targetBlock, err := s.client.Rpc.GetBlockByNumber(ctx, targetBlockNumber)
+	if err != nil {
+		s.logger.Errorf(ctx, "get block %d rpc error: %v", targetBlockNumber, err)
+		return true
+	}
+	if isForked(checkInfo, targetBlock) {
+		s.logger.Info(ctx, "forked")
+		reorg(ctx, s.reorganizer, s.logger, checkInfo)
+		return false
+	}
+	checkInfo.BlockNumber = targetBlockNumber
+	checkInfo.BlockHash = targetBlock.Header.Hash.String()[2:]
+	if checkInfo.CheckType == biz.SyncBlock {
+		err = s.blockSyncer.Sync(ctx, targetBlock, checkInfo, s.systemScripts)
+	} else {
+		err = s.metadataSyncer.Sync(ctx, targetBlock, checkInfo, s.systemScripts)
+	}
+	if err != nil {
+		s.logger.Errorf(ctx, "save %s kv pairs error: %v", checkInfo.CheckType.String(), err)
+		return true
+	}
+	return false
+}
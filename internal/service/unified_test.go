@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/biz"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/config"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/data"
+	"github.com/nervina-labs/cota-nft-entries-syncer/internal/logger"
+)
+
+// cursorStore keeps the last check info of each check type and logs the commits advancing them
+type cursorStore struct {
+	biz.KvPairRepo
+	biz.CheckInfoRepo
+	cursors map[biz.CheckType]uint64
+	commits []string
+}
+
+func (s *cursorStore) FindLastCheckInfo(_ context.Context, info *biz.CheckInfo) error {
+	if blockNumber, ok := s.cursors[info.CheckType]; ok {
+		info.BlockNumber, info.BlockHash = blockNumber, mainChain(blockNumber).String()[2:]
+	}
+	return nil
+}
+
+func (s *cursorStore) commit(kind string, checkInfo biz.CheckInfo, checkTypes ...biz.CheckType) error {
+	if checkInfo.BlockHash != mainChain(checkInfo.BlockNumber).String()[2:] {
+		return fmt.Errorf("check info of block %d has the hash %s", checkInfo.BlockNumber, checkInfo.BlockHash)
+	}
+	for _, checkType := range checkTypes {
+		s.cursors[checkType] = checkInfo.BlockNumber
+	}
+	s.commits = append(s.commits, fmt.Sprintf("%s %d", kind, checkInfo.BlockNumber))
+	return nil
+}
+
+func (s *cursorStore) CreateCotaEntryKvPairs(_ context.Context, checkInfo biz.CheckInfo, _ *biz.KvPair) error {
+	return s.commit("entries", checkInfo, biz.SyncBlock)
+}
+
+func (s *cursorStore) CreateMetadataKvPairs(_ context.Context, checkInfo biz.CheckInfo, _ *biz.KvPair) error {
+	return s.commit("metadata", checkInfo, biz.SyncMetadata)
+}
+
+func (s *cursorStore) CreateKvPairs(_ context.Context, checkInfo biz.CheckInfo, _ *biz.KvPair) error {
+	return s.commit("unified", checkInfo, biz.SyncBlock, biz.SyncMetadata)
+}
+
+func TestUnifiedSyncService_sync(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursors map[biz.CheckType]uint64
+		want    []string
+	}{
+		{
+			name:    "should catch up a lagging metadata cursor before the unified commits",
+			cursors: map[biz.CheckType]uint64{biz.SyncBlock: 6, biz.SyncMetadata: 3},
+			want:    []string{"metadata 4", "metadata 5", "metadata 6", "unified 7", "unified 8", "unified 9", "unified 10"},
+		},
+		{
+			name:    "should catch up a lagging block cursor before the unified commits",
+			cursors: map[biz.CheckType]uint64{biz.SyncBlock: 7, biz.SyncMetadata: 9},
+			want:    []string{"entries 8", "entries 9", "unified 10"},
+		},
+		{
+			name:    "should commit both check types together when the cursors agree",
+			cursors: map[biz.CheckType]uint64{biz.SyncBlock: 8, biz.SyncMetadata: 8},
+			want:    []string{"unified 9", "unified 10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(io.Discard, "", 0)
+			appConf := &config.App{SyncMode: config.UnifiedSyncMode, InputCacheSize: 10}
+			client := &data.CkbNodeClient{Rpc: forkingNode{tip: 10}}
+			store := &cursorStore{cursors: tt.cursors}
+			kvPairUsecase := biz.NewSyncKvPairUsecase(store, log)
+			parser := data.NewCotaWitnessArgsParser(client, nil, appConf)
+			blockSyncer := data.NewBlockSyncer(data.NewRegistryParser(client, appConf), parser, kvPairUsecase,
+				biz.NewEntryHandlerRegistry(nil, nil, nil, nil, nil, nil), appConf, log)
+			metadataSyncer := data.NewMetadataSyncer(kvPairUsecase, parser, nil, nil, appConf, log)
+			reorganizer := data.NewChainReorganizer(client, kvPairUsecase, biz.NewBlockHeaderUsecase(nil, log), appConf, log)
+			s := NewUnifiedSyncService(biz.NewCheckInfoUsecase(store, log), log, client, data.SystemScripts{}, blockSyncer, metadataSyncer,
+				data.NewUnifiedSyncer(blockSyncer, metadataSyncer, kvPairUsecase), reorganizer, nil, data.NewSparseScanner(client, kvPairUsecase, reorganizer, appConf, log), appConf)
+
+			for rounds := 0; !s.sync(context.Background()); rounds++ {
+				if rounds == 10 {
+					t.Fatalf("sync() is not idle after %d rounds, commits %v", rounds, store.commits)
+				}
+			}
+			if !reflect.DeepEqual(store.commits, tt.want) {
+				t.Errorf("commits = %v, want %v", store.commits, tt.want)
+			}
+		})
+	}
+}